@@ -16,11 +16,22 @@ package xchain
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"fmt"
+	"io"
 	"math/big"
 
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/rand"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+	dtxecdsa "github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecies"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/envelope"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hdwallet"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/sm2"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/sm4"
 
 	ml_common "github.com/PaddlePaddle/PaddleDTX/crypto/core/machine_learning/common"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/machine_learning/linear_regression/gradient_descent"
@@ -30,10 +41,15 @@ import (
 
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/merkle"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/pairing"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/proxy_reencrypt"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/secret_share/complex_secret_share"
 )
 
 type XchainCryptoClient struct {
+	// Suite 密码套件，取值为config.SuiteStandard或config.SuiteGM，为空时使用国际标准算法
+	Suite string
+	// Curve 秘密分享等与曲线相关的算法使用的曲线名称，如"P-256"、"secp256k1"，为空时使用ecdsa的默认曲线
+	Curve string
 }
 
 // --- 哈希算法相关 start ---
@@ -44,6 +60,11 @@ func (xcc *XchainCryptoClient) HashUsingSha256(data []byte) []byte {
 	return hashResult
 }
 
+// HashUsingSM3 使用国密SM3做单次哈希运算
+func (xcc *XchainCryptoClient) HashUsingSM3(data []byte) []byte {
+	return hash.HashUsingSM3(data)
+}
+
 // --- 哈希算法相关 end ---
 
 // --- 随机数相关 start ---
@@ -56,6 +77,379 @@ func (xcc *XchainCryptoClient) GenerateEntropy(bitSize int) ([]byte, error) {
 
 // --- 随机数相关 end ---
 
+// --- 助记词与分层确定性密钥相关 start ---
+
+// GenerateMnemonic 生成指定安全强度的BIP-39助记词
+// - strength 安全强度，取值为rand.KeyStrengthEasy、rand.KeyStrengthMiddle、rand.KeyStrengthHard
+// - wordList 词表，如hdwallet.English、hdwallet.ChineseSimplified
+func (xcc *XchainCryptoClient) GenerateMnemonic(strength int, wordList *hdwallet.WordList) (string, error) {
+	return hdwallet.GenerateMnemonic(strength, wordList)
+}
+
+// NewMasterKeyFromMnemonic 从助记词和口令恢复指定曲线上的主密钥
+// - curveName 曲线名称，支持"secp256k1"、"P-256"
+func (xcc *XchainCryptoClient) NewMasterKeyFromMnemonic(mnemonic, passphrase string, wordList *hdwallet.WordList, curveName string) (*hdwallet.ExtendedKey, error) {
+	return hdwallet.NewMasterKeyFromMnemonic(mnemonic, passphrase, wordList, curveName)
+}
+
+// DeriveKeyByPath 按派生路径从主密钥派生私钥，如"m/44'/0'/0'/0/1"
+func (xcc *XchainCryptoClient) DeriveKeyByPath(masterKey *hdwallet.ExtendedKey, path string) (*ecdsa.PrivateKey, error) {
+	key, err := masterKey.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey()
+}
+
+// --- 助记词与分层确定性密钥相关 end ---
+
+// --- 带曲线标识的ECDSA签名相关 start ---
+
+// GenerateTaggedKeyPair 在指定曲线上生成带曲线标识的密钥对
+// - curveName 曲线名称，如"P-256"、"P-384"、"secp256k1"、"SM2-P-256"
+func (xcc *XchainCryptoClient) GenerateTaggedKeyPair(curveName string) (dtxecdsa.TaggedPrivateKey, dtxecdsa.TaggedPublicKey, error) {
+	return dtxecdsa.GenerateTaggedKeyPair(curveName)
+}
+
+// SignWithTaggedKey 使用带曲线标识的私钥对摘要签名
+func (xcc *XchainCryptoClient) SignWithTaggedKey(privateKey dtxecdsa.TaggedPrivateKey, digest []byte) (dtxecdsa.TaggedSignature, error) {
+	return dtxecdsa.SignTagged(privateKey, digest)
+}
+
+// VerifyWithTaggedKey 使用带曲线标识的公钥验证签名
+func (xcc *XchainCryptoClient) VerifyWithTaggedKey(publicKey dtxecdsa.TaggedPublicKey, digest []byte, signature dtxecdsa.TaggedSignature) error {
+	return dtxecdsa.VerifyTagged(publicKey, digest, signature)
+}
+
+// --- 带曲线标识的ECDSA签名相关 end ---
+
+// --- 国密SM2/SM4 start ---
+
+// SM2GenerateKeyPair 生成SM2密钥对
+func (xcc *XchainCryptoClient) SM2GenerateKeyPair() (sm2.PrivateKey, sm2.PublicKey, error) {
+	return sm2.GenerateKeyPair()
+}
+
+// SM2Sign 使用SM2私钥和用户标识对消息签名，uid为空时使用默认标识
+func (xcc *XchainCryptoClient) SM2Sign(privateKey sm2.PrivateKey, msg, uid []byte) (sm2.Signature, error) {
+	return sm2.Sign(privateKey, msg, uid)
+}
+
+// SM2Verify 使用SM2公钥和用户标识验证签名
+func (xcc *XchainCryptoClient) SM2Verify(publicKey sm2.PublicKey, msg, uid []byte, signature sm2.Signature) error {
+	return sm2.Verify(publicKey, msg, uid, signature)
+}
+
+// SM2Encrypt SM2公钥加密
+func (xcc *XchainCryptoClient) SM2Encrypt(publicKey sm2.PublicKey, msg []byte) ([]byte, error) {
+	return sm2.Encrypt(publicKey, msg)
+}
+
+// SM2Decrypt SM2私钥解密
+func (xcc *XchainCryptoClient) SM2Decrypt(privateKey sm2.PrivateKey, cypherText []byte) ([]byte, error) {
+	return sm2.Decrypt(privateKey, cypherText)
+}
+
+// EncryptUsingSM4GCM 使用SM4-GCM加密，key为16字节
+func (xcc *XchainCryptoClient) EncryptUsingSM4GCM(key sm4.SM4Key, plaintext []byte) ([]byte, error) {
+	return sm4.EncryptUsingSM4GCM(key, plaintext, nil)
+}
+
+// DecryptUsingSM4GCM 使用SM4-GCM解密
+func (xcc *XchainCryptoClient) DecryptUsingSM4GCM(key sm4.SM4Key, ciphertext []byte) ([]byte, error) {
+	return sm4.DecryptUsingSM4GCM(key, ciphertext, nil)
+}
+
+// --- 国密SM2/SM4 end ---
+
+// --- 密码套件相关 start ---
+// 以下方法根据Suite选择算法，国际标准算法为SHA-256、ECDSA(P-256)、ECIES和AES-GCM，国密算法为SM3、SM2和SM4-GCM，
+// 两种套件的公私钥长度相同，分别为64字节和32字节
+
+// Hash 计算哈希
+func (xcc *XchainCryptoClient) Hash(data []byte) ([]byte, error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, err
+	}
+	if gm {
+		return hash.HashUsingSM3(data), nil
+	}
+	return hash.HashUsingSha256(data), nil
+}
+
+// GenerateKeyPair 生成签名和加密使用的密钥对
+func (xcc *XchainCryptoClient) GenerateKeyPair() (privateKey, publicKey []byte, err error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, nil, err
+	}
+	if gm {
+		privkey, pubkey, err := sm2.GenerateKeyPair()
+		return privkey[:], pubkey[:], err
+	}
+	privkey, pubkey, err := dtxecdsa.GenerateKeyPair()
+	return privkey[:], pubkey[:], err
+}
+
+// Sign 对消息签名，国际标准算法对消息的SHA-256摘要做ECDSA签名，国密算法使用默认用户标识做SM2签名
+func (xcc *XchainCryptoClient) Sign(privateKey, msg []byte) ([]byte, error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, err
+	}
+	if gm {
+		var privkey sm2.PrivateKey
+		if err := copyKey(privkey[:], privateKey); err != nil {
+			return nil, err
+		}
+		sig, err := sm2.Sign(privkey, msg, nil)
+		return sig[:], err
+	}
+
+	var privkey dtxecdsa.PrivateKey
+	if err := copyKey(privkey[:], privateKey); err != nil {
+		return nil, err
+	}
+	sig, err := dtxecdsa.Sign(privkey, hash.HashUsingSha256(msg))
+	return sig[:], err
+}
+
+// Verify 验证消息的签名
+func (xcc *XchainCryptoClient) Verify(publicKey, msg, signature []byte) error {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return err
+	}
+	if gm {
+		var pubkey sm2.PublicKey
+		var sig sm2.Signature
+		if err := copyKey(pubkey[:], publicKey); err != nil {
+			return err
+		}
+		if err := copyKey(sig[:], signature); err != nil {
+			return err
+		}
+		return sm2.Verify(pubkey, msg, nil, sig)
+	}
+
+	var pubkey dtxecdsa.PublicKey
+	var sig dtxecdsa.Signature
+	if err := copyKey(pubkey[:], publicKey); err != nil {
+		return err
+	}
+	if err := copyKey(sig[:], signature); err != nil {
+		return err
+	}
+	return dtxecdsa.Verify(pubkey, hash.HashUsingSha256(msg), sig)
+}
+
+// Encrypt 公钥加密，国际标准算法使用ECIES，国密算法使用SM2
+func (xcc *XchainCryptoClient) Encrypt(publicKey, msg []byte) ([]byte, error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, err
+	}
+	if gm {
+		var pubkey sm2.PublicKey
+		if err := copyKey(pubkey[:], publicKey); err != nil {
+			return nil, err
+		}
+		return sm2.Encrypt(pubkey, msg)
+	}
+
+	var pubkey dtxecdsa.PublicKey
+	if err := copyKey(pubkey[:], publicKey); err != nil {
+		return nil, err
+	}
+	ecPubkey, err := dtxecdsa.ParsePublicKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return ecies.Encrypt(&ecPubkey, msg)
+}
+
+// Decrypt 私钥解密
+func (xcc *XchainCryptoClient) Decrypt(privateKey, cypherText []byte) ([]byte, error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, err
+	}
+	if gm {
+		var privkey sm2.PrivateKey
+		if err := copyKey(privkey[:], privateKey); err != nil {
+			return nil, err
+		}
+		return sm2.Decrypt(privkey, cypherText)
+	}
+
+	var privkey dtxecdsa.PrivateKey
+	if err := copyKey(privkey[:], privateKey); err != nil {
+		return nil, err
+	}
+	ecPrivkey := dtxecdsa.ParsePrivateKey(privkey)
+	return ecies.Decrypt(&ecPrivkey, cypherText)
+}
+
+// ProxyEncrypt 代理重加密方案中将数据加密给数据所有者，返回胶囊和密文，仅支持国际标准算法
+func (xcc *XchainCryptoClient) ProxyEncrypt(publicKey, msg []byte) (*proxy_reencrypt.Capsule, []byte, error) {
+	var pubkey dtxecdsa.PublicKey
+	if err := copyKey(pubkey[:], publicKey); err != nil {
+		return nil, nil, err
+	}
+	ecPubkey, err := dtxecdsa.ParsePublicKey(pubkey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proxy_reencrypt.Encrypt(&ecPubkey, msg)
+}
+
+// GenerateReEncryptKeys 数据所有者为数据使用者生成重加密密钥碎片，分发给shares个代理，任意threshold个代理即可完成重加密
+func (xcc *XchainCryptoClient) GenerateReEncryptKeys(privateKey, delegateePublicKey []byte, threshold, shares int) ([]*proxy_reencrypt.KFrag, error) {
+	var privkey dtxecdsa.PrivateKey
+	if err := copyKey(privkey[:], privateKey); err != nil {
+		return nil, err
+	}
+	var pubkey dtxecdsa.PublicKey
+	if err := copyKey(pubkey[:], delegateePublicKey); err != nil {
+		return nil, err
+	}
+	ecPubkey, err := dtxecdsa.ParsePublicKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	ecPrivkey := dtxecdsa.ParsePrivateKey(privkey)
+	return proxy_reencrypt.GenerateKFrags(&ecPrivkey, &ecPubkey, threshold, shares)
+}
+
+// ReEncrypt 代理使用重加密密钥碎片对胶囊重加密
+func (xcc *XchainCryptoClient) ReEncrypt(capsule *proxy_reencrypt.Capsule, kfrag *proxy_reencrypt.KFrag) (*proxy_reencrypt.CFrag, error) {
+	return proxy_reencrypt.ReEncrypt(capsule, kfrag)
+}
+
+// DecryptReEncrypted 数据使用者使用不少于门限值的胶囊碎片解密
+func (xcc *XchainCryptoClient) DecryptReEncrypted(privateKey, delegatorPublicKey []byte, capsule *proxy_reencrypt.Capsule, cfrags []*proxy_reencrypt.CFrag, ciphertext []byte) ([]byte, error) {
+	var privkey dtxecdsa.PrivateKey
+	if err := copyKey(privkey[:], privateKey); err != nil {
+		return nil, err
+	}
+	var pubkey dtxecdsa.PublicKey
+	if err := copyKey(pubkey[:], delegatorPublicKey); err != nil {
+		return nil, err
+	}
+	ecPubkey, err := dtxecdsa.ParsePublicKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	ecPrivkey := dtxecdsa.ParsePrivateKey(privkey)
+	return proxy_reencrypt.DecryptFragments(&ecPrivkey, &ecPubkey, capsule, cfrags, ciphertext)
+}
+
+// SymmetricEncrypt 对称加密，国际标准算法使用AES-GCM(32字节密钥)，国密算法使用SM4-GCM(16字节密钥)，nonce为12字节
+func (xcc *XchainCryptoClient) SymmetricEncrypt(key, nonce, ad, plaintext []byte) ([]byte, error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, err
+	}
+	if gm {
+		return sm4.EncryptUsingSM4GCM(sm4.SM4Key{Key: key, Nonce: nonce, AD: ad}, plaintext, nil)
+	}
+	return aes.EncryptUsingAESGCM(aes.AESKey{Key: key, Nonce: nonce, AD: ad}, plaintext, nil)
+}
+
+// SymmetricDecrypt 对称解密
+func (xcc *XchainCryptoClient) SymmetricDecrypt(key, nonce, ad, ciphertext []byte) ([]byte, error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, err
+	}
+	if gm {
+		return sm4.DecryptUsingSM4GCM(sm4.SM4Key{Key: key, Nonce: nonce, AD: ad}, ciphertext, nil)
+	}
+	return aes.DecryptUsingAESGCM(aes.AESKey{Key: key, Nonce: nonce, AD: ad}, ciphertext, nil)
+}
+
+// NewStreamEncrypter 创建AES-GCM流加密写入器，适用于大文件，chunkSize为0时使用默认块大小
+func (xcc *XchainCryptoClient) NewStreamEncrypter(dst io.Writer, key, ad []byte, chunkSize int) (io.WriteCloser, error) {
+	return aes.NewStreamWriter(dst, key, ad, chunkSize)
+}
+
+// NewStreamDecrypter 创建AES-GCM流解密读取器，流被截断或篡改时读取返回错误
+func (xcc *XchainCryptoClient) NewStreamDecrypter(src io.Reader, key, ad []byte) (io.Reader, error) {
+	return aes.NewStreamReader(src, key, ad)
+}
+
+// NewEnvelopeEncrypter 创建多接收者信封加密写入器，数据密钥使用每个接收者的公钥封装，仅支持国际标准算法
+func (xcc *XchainCryptoClient) NewEnvelopeEncrypter(dst io.Writer, publicKeys [][]byte, chunkSize int) (io.WriteCloser, error) {
+	gm, err := xcc.isGM()
+	if err != nil {
+		return nil, err
+	}
+	if gm {
+		return nil, fmt.Errorf("envelope encryption is not supported by suite %s", xcc.Suite)
+	}
+
+	var recipients []*ecdsa.PublicKey
+	for _, publicKey := range publicKeys {
+		var pubkey dtxecdsa.PublicKey
+		if err := copyKey(pubkey[:], publicKey); err != nil {
+			return nil, err
+		}
+		ecPubkey, err := dtxecdsa.ParsePublicKey(pubkey)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, &ecPubkey)
+	}
+	return envelope.NewWriter(dst, recipients, chunkSize)
+}
+
+// NewEnvelopeDecrypter 使用接收者私钥打开信封
+func (xcc *XchainCryptoClient) NewEnvelopeDecrypter(src io.Reader, privateKey []byte) (io.Reader, error) {
+	var privkey dtxecdsa.PrivateKey
+	if err := copyKey(privkey[:], privateKey); err != nil {
+		return nil, err
+	}
+	ecPrivkey := dtxecdsa.ParsePrivateKey(privkey)
+	return envelope.NewReader(src, &ecPrivkey)
+}
+
+// SplitEncryptedStream 将流密文切分为密文头和加密块，加密块可直接作为副本保持证明的段
+func (xcc *XchainCryptoClient) SplitEncryptedStream(ciphertext []byte) ([]byte, [][]byte, error) {
+	return aes.SplitStream(ciphertext)
+}
+
+// isGM 判断是否使用国密套件
+func (xcc *XchainCryptoClient) isGM() (bool, error) {
+	switch xcc.Suite {
+	case "", config.SuiteStandard:
+		return false, nil
+	case config.SuiteGM:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported crypto suite[%s]", xcc.Suite)
+	}
+}
+
+// curve 获取配置的曲线，未配置时使用ecdsa的默认曲线
+func (xcc *XchainCryptoClient) curve() (elliptic.Curve, error) {
+	if xcc.Curve == "" {
+		return dtxecdsa.DefaultCurve(), nil
+	}
+	return ecc.CurveByName(xcc.Curve)
+}
+
+// copyKey 将密钥或签名复制到定长类型中，并检查长度
+func copyKey(dst, src []byte) error {
+	if len(src) != len(dst) {
+		return fmt.Errorf("invalid key or signature length %d, expected %d", len(src), len(dst))
+	}
+	copy(dst, src)
+	return nil
+}
+
+// --- 密码套件相关 end ---
+
 // --- secret_share 秘密分享算法相关 start ---
 
 // SecretSplit 将秘密信息分割为指定数量的碎片
@@ -63,16 +457,44 @@ func (xcc *XchainCryptoClient) GenerateEntropy(bitSize int) ([]byte, error) {
 // - minimumShareNumber 能够还原出原信息的最少碎片数量
 // - secret 待分割的秘密信息
 func (xcc *XchainCryptoClient) SecretSplit(totalShareNumber, minimumShareNumber int, secret []byte) (shares map[int]*big.Int, err error) {
-	curve := elliptic.P256()
+	curve, err := xcc.curve()
+	if err != nil {
+		return nil, err
+	}
 	return complex_secret_share.ComplexSecretSplit(totalShareNumber, minimumShareNumber, secret, curve)
 }
 
 // SecretRetrieve 利用碎片还原秘密值
 func (xcc *XchainCryptoClient) SecretRetrieve(shares map[int]*big.Int) ([]byte, error) {
-	curve := elliptic.P256()
+	curve, err := xcc.curve()
+	if err != nil {
+		return nil, err
+	}
 	return complex_secret_share.ComplexSecretRetrieve(shares, curve)
 }
 
+// SecretSplitToShares 将任意长度的秘密信息分割为指定数量的碎片，碎片可通过EncodeToString()编码
+func (xcc *XchainCryptoClient) SecretSplitToShares(totalShareNumber, minimumShareNumber int, secret []byte) ([]*complex_secret_share.Share, error) {
+	curve, err := xcc.curve()
+	if err != nil {
+		return nil, err
+	}
+	return complex_secret_share.ComplexSecretSplitToShares(totalShareNumber, minimumShareNumber, secret, curve)
+}
+
+// SecretRetrieveFromEncodedShares 利用编码后的碎片还原任意长度的秘密值
+func (xcc *XchainCryptoClient) SecretRetrieveFromEncodedShares(encodedShares []string) ([]byte, error) {
+	shares := make([]*complex_secret_share.Share, len(encodedShares))
+	for i, s := range encodedShares {
+		share, err := complex_secret_share.DecodeShareFromString(s)
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = share
+	}
+	return complex_secret_share.ComplexSecretRetrieveFromShares(shares)
+}
+
 // --- secret_share 秘密分享算法相关 end ---
 
 // --- PDP 副本保持证明相关 start ---
@@ -82,6 +504,26 @@ func (xcc *XchainCryptoClient) GetMerkleRoot(objects [][]byte) []byte {
 	return merkle.GetMerkleRoot(objects)
 }
 
+// GenerateMerkleProof 生成文件段的梅克尔树存在性证明，采用域分离的哈希模式
+// - segments 文件的全部段
+// - index 待证明的段索引
+func (xcc *XchainCryptoClient) GenerateMerkleProof(segments [][]byte, index int) ([]byte, merkle.Proof, error) {
+	if len(segments) == 0 {
+		return nil, nil, fmt.Errorf("empty segments")
+	}
+	store := merkle.BuildMerkleTreeStoreWithMode(segments, merkle.HashModeDomainSeparated)
+	proof, err := merkle.GenerateProof(store, index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store[len(store)-1], proof, nil
+}
+
+// VerifyMerkleProof 验证文件段的梅克尔树存在性证明，采用域分离的哈希模式
+func (xcc *XchainCryptoClient) VerifyMerkleProof(root, segment []byte, proof merkle.Proof) (bool, error) {
+	return merkle.VerifyProofWithMode(root, segment, proof, merkle.HashModeDomainSeparated)
+}
+
 // GenPairingKeyPair 随机生成基于双线性映射副本保持证明的公私钥对
 func (xcc *XchainCryptoClient) GenPairingKeyPair() ([]byte, []byte, error) {
 	privkey, pubkey, err := pairing.GenKeyPair()
@@ -158,6 +600,26 @@ func (xcc *XchainCryptoClient) VerifyPairingProof(sigma, mu, randV, randU, pubke
 	return pairing.Verify(param)
 }
 
+// BatchVerifyPairingProofs 使用一次多重配对批量验证多个挑战的证明，失败时二分查找无效的证明
+// - params 各挑战的验证参数，可由pairing.VerifyParamsFromBytes得到，可来自不同文件和不同公钥
+// 返回是否全部有效以及无效证明的索引
+func (xcc *XchainCryptoClient) BatchVerifyPairingProofs(params []pairing.VerifyParams) (bool, []int, error) {
+	return pairing.BatchVerify(params)
+}
+
+// DeriveAuditChallenge 由区块哈希等公开随机数派生第三方审计的挑战，无需数据所有者的秘密
+// - beacon 公开随机数，如区块哈希
+// - segmentNum 文件的段数
+// - challengeNum 挑战的段数
+func (xcc *XchainCryptoClient) DeriveAuditChallenge(beacon []byte, segmentNum, challengeNum int) ([]*big.Int, []*big.Int, error) {
+	return pairing.DeriveAuditChallenge(beacon, segmentNum, challengeNum)
+}
+
+// AuditVerifyPairingProof 第三方审计者仅使用公开信息验证盲化的审计证明
+func (xcc *XchainCryptoClient) AuditVerifyPairingProof(param pairing.AuditVerifyParams) (bool, error) {
+	return pairing.AuditVerify(param)
+}
+
 // --- PDP 副本保持证明相关 end ---
 
 // --- Paillier 加法同态相关 start ---
@@ -284,6 +746,37 @@ func (xcc *XchainCryptoClient) PSIntersect(sampleID []string, reEncSetLocal *lin
 	return linear_vertical.Intersect(sampleID, reEncSetLocal, reEncSetOthers)
 }
 
+// PSIEncryptSampleLabels 发送方使用标签私钥加密样本关联的标签
+// - sampleID 原始ID列表
+// - labels 样本标签列表，与原始ID列表顺序一致
+// - labelKey 标签私钥，必须与PSI使用的私钥不同
+func (xcc *XchainCryptoClient) PSIEncryptSampleLabels(sampleID []string, labels [][]byte, labelKey *ecdsa.PrivateKey) (*linear_vertical.LabeledEncSet, error) {
+	return linear_vertical.EncryptSampleLabels(sampleID, labels, labelKey)
+}
+
+// PSIBlindSampleIDSet 接收方盲化己方的ID列表，返回盲化后的ID列表和用于去盲化的随机数
+func (xcc *XchainCryptoClient) PSIBlindSampleIDSet(sampleID []string, curve elliptic.Curve) (*linear_vertical.EncSet, *big.Int, error) {
+	return linear_vertical.BlindSampleIDSet(sampleID, curve)
+}
+
+// PSIEvaluateBlindedIDSet 发送方使用标签私钥二次加密接收方盲化后的ID列表
+func (xcc *XchainCryptoClient) PSIEvaluateBlindedIDSet(blindedSet *linear_vertical.EncSet, labelKey *ecdsa.PrivateKey) (*linear_vertical.EncSet, error) {
+	return linear_vertical.EvaluateBlindedIDSet(blindedSet, labelKey)
+}
+
+// PSIUnblindIDSet 接收方去盲化发送方二次加密后的ID列表
+func (xcc *XchainCryptoClient) PSIUnblindIDSet(evaluatedSet *linear_vertical.EncSet, r *big.Int, curve elliptic.Curve) (*linear_vertical.EncSet, error) {
+	return linear_vertical.UnblindIDSet(evaluatedSet, r, curve)
+}
+
+// PSIDecryptSampleLabels 接收方解密交集内样本对应的标签
+// - sampleID 原始ID列表
+// - unblindedSet 去盲化后的己方ID列表
+// - labeledEncSet 发送方加密的标签集合
+func (xcc *XchainCryptoClient) PSIDecryptSampleLabels(sampleID []string, unblindedSet *linear_vertical.EncSet, labeledEncSet *linear_vertical.LabeledEncSet) (map[string][]byte, error) {
+	return linear_vertical.DecryptSampleLabels(sampleID, unblindedSet, labeledEncSet)
+}
+
 // --- 联邦学习-通用-纵向 end ---
 
 // --- 联邦学习-多元线性回归-纵向 start ---
@@ -15,9 +15,15 @@ package main
 
 import (
 	"github.com/PaddlePaddle/PaddleDTX/crypto/client/service/xchain"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
 )
 
 // GetInstance return the default xchain client   返回默认的 xchain 客户端
 func GetInstance() interface{} {
 	return &xchain.XchainCryptoClient{}
 }
+
+// GetGMInstance return the xchain client using GM/T algorithms   返回使用国密算法的 xchain 客户端
+func GetGMInstance() interface{} {
+	return &xchain.XchainCryptoClient{Suite: config.SuiteGM}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 椭圆曲线注册表
+// 每条曲线有一个唯一的单字节标识CurveID，写入带曲线标识的密钥、签名等编码中，
+// 解码时根据标识找到对应的曲线，从而让每个部署可以自行选择曲线
+// 内置的曲线：P-256、P-384、P-521(NIST)，secp256k1(区块链)，SM2-P-256(国密)
+
+// CurveID 曲线标识
+type CurveID byte
+
+const (
+	CurveIDP256      CurveID = 1
+	CurveIDP384      CurveID = 2
+	CurveIDP521      CurveID = 3
+	CurveIDSecp256k1 CurveID = 4
+	CurveIDSM2       CurveID = 5
+)
+
+var (
+	ErrUnknownCurve      = errors.New("unknown curve")
+	ErrCurveRegistered   = errors.New("curve has already been registered")
+	ErrInvalidCurveParam = errors.New("invalid curve parameters")
+)
+
+type curveEntry struct {
+	id    CurveID
+	name  string
+	curve elliptic.Curve
+}
+
+var (
+	registryLock sync.RWMutex
+	curvesByID   = make(map[CurveID]*curveEntry)
+	curvesByName = make(map[string]*curveEntry)
+)
+
+func init() {
+	builtin := map[CurveID]elliptic.Curve{
+		CurveIDP256:      elliptic.P256(),
+		CurveIDP384:      elliptic.P384(),
+		CurveIDP521:      elliptic.P521(),
+		CurveIDSecp256k1: secp256k1.S256(),
+		CurveIDSM2:       sm2.P256Sm2(),
+	}
+	for id, curve := range builtin {
+		if err := RegisterCurve(id, curve); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterCurve 注册曲线，曲线名称取自curve.Params().Name，标识和名称均不能重复
+func RegisterCurve(id CurveID, curve elliptic.Curve) error {
+	if curve == nil || curve.Params() == nil || curve.Params().Name == "" {
+		return ErrInvalidCurveParam
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	name := curve.Params().Name
+	if _, ok := curvesByID[id]; ok {
+		return fmt.Errorf("%w: id %d", ErrCurveRegistered, id)
+	}
+	if _, ok := curvesByName[name]; ok {
+		return fmt.Errorf("%w: %s", ErrCurveRegistered, name)
+	}
+
+	entry := &curveEntry{id: id, name: name, curve: curve}
+	curvesByID[id] = entry
+	curvesByName[name] = entry
+
+	return nil
+}
+
+// CurveByID 根据曲线标识获取曲线
+func CurveByID(id CurveID) (elliptic.Curve, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	entry, ok := curvesByID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: id %d", ErrUnknownCurve, id)
+	}
+	return entry.curve, nil
+}
+
+// CurveByName 根据曲线名称获取曲线，例如"P-256"、"secp256k1"、"SM2-P-256"
+func CurveByName(name string) (elliptic.Curve, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	entry, ok := curvesByName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCurve, name)
+	}
+	return entry.curve, nil
+}
+
+// IDOfCurve 获取已注册曲线的标识
+func IDOfCurve(curve elliptic.Curve) (CurveID, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	entry, ok := curvesByName[curve.Params().Name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurve, curve.Params().Name)
+	}
+	return entry.id, nil
+}
+
+// CoordinateLength 曲线上点的坐标以及私钥的字节长度
+func CoordinateLength(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
+
+// CurveA 曲线方程 y^2 = x^3 + a*x + b 中的系数a，elliptic.CurveParams默认a=-3，
+// 而secp256k1等曲线的a不同，这里由基点反推：a = (Gy^2 - Gx^3 - b) / Gx mod p
+func CurveA(curve elliptic.Curve) *big.Int {
+	params := curve.Params()
+	p := params.P
+
+	a := new(big.Int).Mul(params.Gy, params.Gy)
+	gx3 := new(big.Int).Exp(params.Gx, big.NewInt(3), p)
+	a.Sub(a, gx3)
+	a.Sub(a, params.B)
+	a.Mul(a, new(big.Int).ModInverse(params.Gx, p))
+	return a.Mod(a, p)
+}
+
+// DecompressY 根据x坐标和y的奇偶性计算曲线上点的y坐标，要求p为素数
+func DecompressY(curve elliptic.Curve, x *big.Int, odd bool) (*big.Int, error) {
+	params := curve.Params()
+	p := params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 {
+		return nil, ErrInvalidCurveParam
+	}
+
+	// y^2 = x^3 + a*x + b
+	y2 := new(big.Int).Exp(x, big.NewInt(3), p)
+	y2.Add(y2, new(big.Int).Mul(CurveA(curve), x))
+	y2.Add(y2, params.B)
+	y2.Mod(y2, p)
+
+	y := new(big.Int).ModSqrt(y2, p)
+	if y == nil {
+		return nil, fmt.Errorf("%w: x is not on curve %s", ErrInvalidCurveParam, params.Name)
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(p, y)
+	}
+	return y, nil
+}
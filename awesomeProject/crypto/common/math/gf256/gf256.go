@@ -0,0 +1,179 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gf256
+
+import (
+	"errors"
+)
+
+/*
+	Arithmetic over GF(2^8) with the primitive polynomial x^8 + x^4 + x^3 + x^2 + 1 (0x11d),
+	the same field used by most Reed-Solomon erasure codes.
+	GF(2^8)有限域运算，本原多项式为 x^8 + x^4 + x^3 + x^2 + 1 (0x11d)，
+	加法和减法均为异或，乘法和除法通过对数表和指数表计算
+*/
+
+var ErrSingularMatrix = errors.New("matrix is singular")
+
+const primitivePolynomial = 0x11d
+
+var (
+	expTable [510]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(x)
+		expTable[i+255] = byte(x)
+		logTable[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= primitivePolynomial
+		}
+	}
+}
+
+// Add a + b，同时也是减法
+func Add(a, b byte) byte {
+	return a ^ b
+}
+
+// Mul a * b
+func Mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+// Div a / b, b must not be zero 除数不能为0
+func Div(a, b byte) byte {
+	if b == 0 {
+		panic("gf256: division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
+
+// Inv multiplicative inverse of a, a must not be zero 求逆元，a不能为0
+func Inv(a byte) byte {
+	return Div(1, a)
+}
+
+// Exp a^n
+func Exp(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])*n)%255]
+}
+
+// MulAdd dst[i] += c * src[i]
+func MulAdd(dst []byte, c byte, src []byte) {
+	if c == 0 {
+		return
+	}
+	logC := int(logTable[c])
+	for i, s := range src {
+		if s != 0 {
+			dst[i] ^= expTable[logC+int(logTable[s])]
+		}
+	}
+}
+
+// Matrix matrix over GF(2^8) 有限域上的矩阵
+type Matrix [][]byte
+
+// NewMatrix create a zero matrix with given rows and columns 创建指定行列数的零矩阵
+func NewMatrix(rows, cols int) Matrix {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make([]byte, cols)
+	}
+	return m
+}
+
+// Vandermonde create a rows*cols Vandermonde matrix, m[i][j] = i^j 创建范德蒙矩阵，任意cols行线性无关
+func Vandermonde(rows, cols int) Matrix {
+	m := NewMatrix(rows, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			m[i][j] = Exp(byte(i), j)
+		}
+	}
+	return m
+}
+
+// Multiply matrix multiplication m * other 矩阵乘法
+func (m Matrix) Multiply(other Matrix) Matrix {
+	res := NewMatrix(len(m), len(other[0]))
+	for i := range m {
+		for k, c := range m[i] {
+			MulAdd(res[i], c, other[k])
+		}
+	}
+	return res
+}
+
+// SubMatrix return rows [rmin, rmax) and columns [cmin, cmax) 返回子矩阵
+func (m Matrix) SubMatrix(rmin, cmin, rmax, cmax int) Matrix {
+	res := NewMatrix(rmax-rmin, cmax-cmin)
+	for i := rmin; i < rmax; i++ {
+		copy(res[i-rmin], m[i][cmin:cmax])
+	}
+	return res
+}
+
+// Invert inverse of a square matrix by Gauss-Jordan elimination 使用高斯-约当消元法求方阵的逆
+func (m Matrix) Invert() (Matrix, error) {
+	n := len(m)
+	// 增广矩阵 [m | I]
+	work := NewMatrix(n, 2*n)
+	for i := 0; i < n; i++ {
+		copy(work[i], m[i])
+		work[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for r := col; r < n; r++ {
+			if work[r][col] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			return nil, ErrSingularMatrix
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+
+		inv := Inv(work[col][col])
+		for j := range work[col] {
+			work[col][j] = Mul(work[col][j], inv)
+		}
+		for r := 0; r < n; r++ {
+			if r != col && work[r][col] != 0 {
+				MulAdd(work[r], work[r][col], work[col])
+			}
+		}
+	}
+	return work.SubMatrix(0, n, n, 2*n), nil
+}
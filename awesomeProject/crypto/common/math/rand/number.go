@@ -92,23 +92,32 @@ func generateSeedWithRandomPassword(randomPassword []byte, keyLen int) []byte {
 	return seed
 }
 
-// GenerateSeedWithStrengthAndKeyLen 生成指定强度和长度的随机熵
-func GenerateSeedWithStrengthAndKeyLen(strength int, keyLength int) ([]byte, error) {
-	var entropyBitLength = 0
+// EntropyBitSizeOfStrength 获取指定安全强度对应的熵比特长度
+func EntropyBitSizeOfStrength(strength int) (int, error) {
 	//根据强度来判断随机数长度
 	switch strength {
 	case KeyStrengthEasy: // 弱
-		entropyBitLength = 128
+		return 128, nil
 	case KeyStrengthMiddle: // 中
-		entropyBitLength = 192
+		return 192, nil
 	case KeyStrengthHard: // 高
-		entropyBitLength = 256
-	default: // 不支持的语言类型
+		return 256, nil
+	default: // 不支持的强度
+		return 0, ErrStrengthNotSupported
 	}
+}
+
+// ValidateEntropy 检查熵的长度是否符合BIP-39规范要求
+func ValidateEntropy(entropy []byte) error {
+	return validateEntropyBitSize(len(entropy) * 8)
+}
 
+// GenerateSeedWithStrengthAndKeyLen 生成指定强度和长度的随机熵
+func GenerateSeedWithStrengthAndKeyLen(strength int, keyLength int) ([]byte, error) {
 	// 判断强度是否合法
-	if entropyBitLength == 0 {
-		return nil, ErrStrengthNotSupported
+	entropyBitLength, err := EntropyBitSizeOfStrength(strength)
+	if err != nil {
+		return nil, err
 	}
 
 	// 产生随机熵
@@ -14,10 +14,20 @@
 package aes
 
 import (
+	"bytes"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
 	"testing"
 
+	bls12_381_ecc "github.com/consensys/gnark-crypto/ecc/bls12-381"
 	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/pairing"
 )
 
 func TestAES(t *testing.T) {
@@ -42,3 +52,195 @@ func TestAES(t *testing.T) {
 
 	require.Equal(t, plain, plaintext)
 }
+
+func encryptStream(t *testing.T, key, ad, plaintext []byte, chunkSize int) []byte {
+	var buf bytes.Buffer
+	w, err := NewStreamWriter(&buf, key, ad, chunkSize)
+	require.NoError(t, err)
+	// write in uneven pieces 分多次不等长写入
+	for p := plaintext; len(p) > 0; {
+		k := 7
+		if k > len(p) {
+			k = len(p)
+		}
+		_, err := w.Write(p[:k])
+		require.NoError(t, err)
+		p = p[k:]
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestStream(t *testing.T) {
+	key := sha256.Sum256([]byte("test key"))
+	ad := []byte("file id")
+	chunkSize := 64
+
+	for _, size := range []int{0, 1, 63, 64, 128, 1000} {
+		plaintext := make([]byte, size)
+		_, err := io.ReadFull(rand.Reader, plaintext)
+		require.NoError(t, err)
+
+		ciphertext := encryptStream(t, key[:], ad, plaintext, chunkSize)
+		chunkNum := (size + chunkSize - 1) / chunkSize
+		if chunkNum == 0 {
+			chunkNum = 1
+		}
+		require.Equal(t, StreamHeaderSize+size+chunkNum*StreamTagSize, len(ciphertext))
+
+		// sequential decryption 顺序解密
+		r, err := NewStreamReader(bytes.NewReader(ciphertext), key[:], ad)
+		require.NoError(t, err)
+		plain, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, plain)
+
+		// random access decryption 随机访问解密
+		ra, err := NewStreamReaderAt(bytes.NewReader(ciphertext), int64(len(ciphertext)), key[:], ad)
+		require.NoError(t, err)
+		require.Equal(t, int64(size), ra.Size())
+		if size > 150 {
+			part := make([]byte, 100)
+			n, err := ra.ReadAt(part, 50)
+			require.NoError(t, err)
+			require.Equal(t, 100, n)
+			require.Equal(t, plaintext[50:150], part)
+
+			n, err = ra.ReadAt(part, int64(size-10))
+			require.Equal(t, io.EOF, err)
+			require.Equal(t, 10, n)
+			require.Equal(t, plaintext[size-10:], part[:n])
+		}
+
+		// wrong key or additional data 错误的密钥或附加数据
+		r, err = NewStreamReader(bytes.NewReader(ciphertext), key[:], []byte("other"))
+		require.NoError(t, err)
+		_, err = ioutil.ReadAll(r)
+		require.Error(t, err)
+	}
+}
+
+func TestStreamParams(t *testing.T) {
+	key := sha256.Sum256([]byte("test key"))
+	var buf bytes.Buffer
+	for _, k := range [][]byte{nil, {}, key[:31], append(key[:], 1)} {
+		_, err := NewStreamWriter(&buf, k, nil, 0)
+		require.Equal(t, ErrInvalidStreamKey, err)
+	}
+	_, err := NewStreamWriter(&buf, key[:], nil, MaxChunkSize+1)
+	require.Error(t, err)
+
+	// a header claiming a huge chunk size is rejected before allocating 声明超大块的密文头在分配缓冲区前被拒绝
+	ciphertext := encryptStream(t, key[:], nil, []byte("plaintext"), 0)
+	binary.BigEndian.PutUint32(ciphertext[1:5], 1<<30)
+	_, err = NewStreamReader(bytes.NewReader(ciphertext), key[:], nil)
+	require.True(t, errors.Is(err, ErrInvalidStreamHeader))
+	_, err = NewStreamReader(bytes.NewReader(encryptStream(t, key[:], nil, []byte("plaintext"), 0)), nil, nil)
+	require.Equal(t, ErrInvalidStreamKey, err)
+}
+
+func TestStreamTamper(t *testing.T) {
+	key := sha256.Sum256([]byte("test key"))
+	chunkSize := 64
+	plaintext := make([]byte, 256)
+	_, err := io.ReadFull(rand.Reader, plaintext)
+	require.NoError(t, err)
+	ciphertext := encryptStream(t, key[:], nil, plaintext, chunkSize)
+	encChunk := chunkSize + StreamTagSize
+
+	decrypt := func(c []byte) error {
+		r, err := NewStreamReader(bytes.NewReader(c), key[:], nil)
+		if err != nil {
+			return err
+		}
+		_, err = ioutil.ReadAll(r)
+		return err
+	}
+	require.NoError(t, decrypt(ciphertext))
+
+	// truncation at a chunk boundary is detected 在块边界截断可被发现
+	truncated := ciphertext[:StreamHeaderSize+3*encChunk]
+	require.Error(t, decrypt(truncated))
+	require.Error(t, decrypt(ciphertext[:len(ciphertext)-5]))
+	_, err = NewStreamReaderAt(bytes.NewReader(truncated), int64(len(truncated)), key[:], nil)
+	require.NoError(t, err)
+	ra, _ := NewStreamReaderAt(bytes.NewReader(truncated), int64(len(truncated)), key[:], nil)
+	_, err = ra.ReadAt(make([]byte, 10), int64(2*chunkSize))
+	require.Error(t, err)
+
+	// reordered chunks are detected 块乱序可被发现
+	reordered := append([]byte{}, ciphertext...)
+	copy(reordered[StreamHeaderSize:], ciphertext[StreamHeaderSize+encChunk:StreamHeaderSize+2*encChunk])
+	copy(reordered[StreamHeaderSize+encChunk:], ciphertext[StreamHeaderSize:StreamHeaderSize+encChunk])
+	require.Error(t, decrypt(reordered))
+
+	// modified chunk or header is detected 篡改块或密文头可被发现
+	modified := append([]byte{}, ciphertext...)
+	modified[StreamHeaderSize+encChunk+3] ^= 1
+	require.Error(t, decrypt(modified))
+	modified = append([]byte{}, ciphertext...)
+	modified[10] ^= 1
+	require.Error(t, decrypt(modified))
+}
+
+func TestStreamSegments(t *testing.T) {
+	key := sha256.Sum256([]byte("test key"))
+	chunkSize := 256
+	plaintext := make([]byte, 10*chunkSize+100)
+	_, err := io.ReadFull(rand.Reader, plaintext)
+	require.NoError(t, err)
+	ciphertext := encryptStream(t, key[:], nil, plaintext, chunkSize)
+
+	header, segments, err := SplitStream(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, 11, len(segments))
+
+	// each encrypted segment decrypts independently 每个加密段可独立解密
+	var plain []byte
+	for i, segment := range segments {
+		p, err := DecryptStreamChunk(key[:], nil, header, i, segment, i == len(segments)-1)
+		require.NoError(t, err)
+		plain = append(plain, p...)
+	}
+	require.Equal(t, plaintext, plain)
+	_, err = DecryptStreamChunk(key[:], nil, header, 1, segments[2], false)
+	require.Error(t, err)
+
+	// encrypted segments are tagged and audited directly 直接对加密段计算标签并审计
+	sk, pk, err := pairing.GenKeyPair()
+	require.NoError(t, err)
+	randomU, _ := pairing.RandomWithinOrder()
+	randomV, _ := pairing.RandomWithinOrder()
+	var sigmas []*bls12_381_ecc.G1Affine
+	for i, segment := range segments {
+		sigma, err := pairing.CalculateAuditSigmaI(pairing.CalculateSigmaIParams{
+			Content: segment,
+			Index:   big.NewInt(int64(i)),
+			RandomV: randomV,
+			RandomU: randomU,
+			Privkey: sk,
+		})
+		require.NoError(t, err)
+		sigmas = append(sigmas, sigma)
+	}
+
+	indices, vs, err := pairing.DeriveAuditChallenge([]byte("beacon"), len(segments), 4)
+	require.NoError(t, err)
+	proofParam := pairing.AuditProofParams{Indices: indices, RandomVs: vs, RandomU: randomU}
+	for _, index := range indices {
+		proofParam.Content = append(proofParam.Content, segments[index.Int64()])
+		proofParam.Sigmas = append(proofParam.Sigmas, sigmas[index.Int64()])
+	}
+	proof, err := pairing.AuditProve(proofParam)
+	require.NoError(t, err)
+	ok, err := pairing.AuditVerify(pairing.AuditVerifyParams{
+		Proof:    proof,
+		RandomV:  randomV,
+		RandomU:  randomU,
+		Indices:  indices,
+		RandomVs: vs,
+		Pubkey:   pk,
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+}
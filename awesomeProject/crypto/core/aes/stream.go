@@ -0,0 +1,444 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aes
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+/*
+	Streaming AES-GCM encryption in the style of the STREAM construction.
+	The plaintext is split into chunks of ChunkSize bytes, each chunk is sealed independently with
+	  nonce_i = noncePrefix(7 bytes) || i(4 bytes, big endian) || last(1 byte)
+	where last is 1 only for the final chunk, so truncation and reordering are detected.
+	A fresh key is derived for every stream from a random salt, nonces are never reused under the same key.
+	流式AES-GCM加密，参考STREAM构造，明文按ChunkSize切分为块，每块独立加密，
+	块的nonce由随机前缀、块序号和是否为最后一块的标志组成，因此可以发现截断和乱序；
+	每个流使用随机盐派生独立的密钥，同一密钥下nonce不会重复
+	  密文格式: header || chunk_0 || ... || chunk_n-1
+	  header:   版本(1字节) || ChunkSize(4字节) || 盐(32字节) || nonce前缀(7字节)
+	  chunk_i:  AES-GCM(subkey, nonce_i, 明文块, header || AD)，除最后一块外明文块长度均为ChunkSize
+	  subkey:   HKDF-SHA256(key, 盐, "PaddleDTX AES-GCM stream")
+	每个加密块可独立解密，可作为副本保持证明的段直接计算标签
+*/
+
+var (
+	ErrInvalidStreamHeader = errors.New("invalid stream header")
+	ErrStreamTruncated     = errors.New("stream is truncated")
+	ErrStreamTooLong       = errors.New("stream exceeds the maximum number of chunks")
+	ErrInvalidStreamKey    = errors.New("invalid stream key, must be 16, 24 or 32 bytes")
+)
+
+const (
+	// DefaultChunkSize 默认的明文块大小
+	DefaultChunkSize = 64 * 1024
+
+	// MaxChunkSize 最大的明文块大小，块大小来自未经认证的密文头，读取时按此分配缓冲区，因此不宜过大
+	MaxChunkSize = 1 << 24
+
+	// StreamHeaderSize 流密文头的长度
+	StreamHeaderSize = 1 + 4 + streamSaltSize + streamPrefixSize
+
+	// StreamTagSize 每个加密块的认证标签长度
+	StreamTagSize = 16
+
+	streamVersion    byte = 1
+	streamSaltSize        = 32
+	streamPrefixSize      = 7
+	streamKeyInfo         = "PaddleDTX AES-GCM stream"
+)
+
+// streamCipher 流加密的公共部分，负责计算每块的nonce并加解密
+type streamCipher struct {
+	aead      cipher.AEAD
+	header    []byte
+	prefix    []byte
+	chunkSize int
+	ad        []byte // header || AD
+}
+
+func newStreamCipher(key, ad, header []byte) (*streamCipher, error) {
+	// HKDF接受任意长度的输入，这里与EncryptUsingAESGCM一致，只接受AES密钥长度
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrInvalidStreamKey
+	}
+	if len(header) != StreamHeaderSize || header[0] != streamVersion {
+		return nil, ErrInvalidStreamHeader
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[1:5]))
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("%w: invalid chunk size %d", ErrInvalidStreamHeader, chunkSize)
+	}
+	salt := header[5 : 5+streamSaltSize]
+
+	subkey := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(streamKeyInfo)), subkey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(subkey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamCipher{
+		aead:      aead,
+		header:    append([]byte{}, header...),
+		prefix:    header[5+streamSaltSize:],
+		chunkSize: chunkSize,
+		ad:        append(append([]byte{}, header...), ad...),
+	}, nil
+}
+
+func (sc *streamCipher) nonce(index uint64, last bool) ([]byte, error) {
+	if index > 0xffffffff {
+		return nil, ErrStreamTooLong
+	}
+	nonce := make([]byte, 12)
+	copy(nonce, sc.prefix)
+	binary.BigEndian.PutUint32(nonce[7:11], uint32(index))
+	if last {
+		nonce[11] = 1
+	}
+	return nonce, nil
+}
+
+func (sc *streamCipher) seal(dst []byte, index uint64, plaintext []byte, last bool) ([]byte, error) {
+	nonce, err := sc.nonce(index, last)
+	if err != nil {
+		return nil, err
+	}
+	return sc.aead.Seal(dst, nonce, plaintext, sc.ad), nil
+}
+
+func (sc *streamCipher) open(dst []byte, index uint64, ciphertext []byte, last bool) ([]byte, error) {
+	nonce, err := sc.nonce(index, last)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := sc.aead.Open(dst, nonce, ciphertext, sc.ad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt chunk %d: %w", index, err)
+	}
+	return plaintext, nil
+}
+
+// StreamWriter encrypt data written to it and write the ciphertext to the underlying writer 流加密写入器
+type StreamWriter struct {
+	dst    io.Writer
+	sc     *streamCipher
+	buf    []byte
+	index  uint64
+	closed bool
+}
+
+// NewStreamWriter create a stream encrypter, the header is written to dst immediately 创建流加密写入器，立即向dst写入密文头
+// - key 16, 24 or 32 bytes key 16、24或32字节的密钥
+// - ad optional additional data, the same ad is required to decrypt 可选的附加数据，解密时需提供相同的附加数据
+// - chunkSize plaintext chunk size, DefaultChunkSize is used if it is 0 明文块大小，为0时使用DefaultChunkSize
+func NewStreamWriter(dst io.Writer, key, ad []byte, chunkSize int) (*StreamWriter, error) {
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+	if chunkSize < 0 || chunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+
+	header := make([]byte, StreamHeaderSize)
+	header[0] = streamVersion
+	binary.BigEndian.PutUint32(header[1:5], uint32(chunkSize))
+	if _, err := io.ReadFull(rand.Reader, header[5:]); err != nil {
+		return nil, err
+	}
+	sc, err := newStreamCipher(key, ad, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &StreamWriter{
+		dst: dst,
+		sc:  sc,
+		buf: make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// Write encrypt p, full chunks are written once more data arrives 加密p，写满的块在后续数据到来时写出
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed stream")
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// 只有确认后面还有数据时才写出满块，保证最后一块带有结束标志
+		if len(w.buf) == w.sc.chunkSize {
+			if err := w.flush(false); err != nil {
+				return n, err
+			}
+		}
+		k := w.sc.chunkSize - len(w.buf)
+		if k > len(p) {
+			k = len(p)
+		}
+		w.buf = append(w.buf, p[:k]...)
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close write the last chunk, the underlying writer is not closed 写出最后一块，不关闭底层写入器
+func (w *StreamWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush(true)
+}
+
+func (w *StreamWriter) flush(last bool) error {
+	ciphertext, err := w.sc.seal(nil, w.index, w.buf, last)
+	if err != nil {
+		return err
+	}
+	if _, err := w.dst.Write(ciphertext); err != nil {
+		return err
+	}
+	w.index++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// StreamReader decrypt a stream sequentially 顺序解密流
+type StreamReader struct {
+	src   *bufio.Reader
+	sc    *streamCipher
+	buf   []byte
+	plain []byte
+	index uint64
+	done  bool
+}
+
+// NewStreamReader create a stream decrypter, the header is read from src immediately 创建流解密读取器，立即从src读取密文头
+func NewStreamReader(src io.Reader, key, ad []byte) (*StreamReader, error) {
+	header := make([]byte, StreamHeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStreamHeader, err)
+	}
+	sc, err := newStreamCipher(key, ad, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamReader{
+		src: bufio.NewReader(src),
+		sc:  sc,
+		buf: make([]byte, sc.chunkSize+StreamTagSize),
+	}, nil
+}
+
+// Read read decrypted data, an error is returned if any chunk is tampered or the stream is truncated
+// 读取解密后的数据，任意块被篡改或流被截断时返回错误
+func (r *StreamReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *StreamReader) readChunk() error {
+	n, err := io.ReadFull(r.src, r.buf)
+	last := false
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return err
+	default:
+		// 满块之后没有更多数据时即为最后一块
+		if _, err := r.src.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if n < StreamTagSize {
+		return ErrStreamTruncated
+	}
+
+	plain, err := r.sc.open(r.buf[:0], r.index, r.buf[:n], last)
+	if err != nil {
+		if !last {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrStreamTruncated, err)
+	}
+	r.plain = plain
+	r.index++
+	r.done = last
+	return nil
+}
+
+// StreamReaderAt decrypt any part of a stream randomly 随机访问解密流的任意部分
+type StreamReaderAt struct {
+	src       io.ReaderAt
+	sc        *streamCipher
+	chunkNum  int64
+	lastChunk int64 // ciphertext size of the last chunk 最后一块的密文长度
+	size      int64 // plaintext size 明文长度
+}
+
+// NewStreamReaderAt create a random access decrypter 创建随机访问解密器
+// - size ciphertext size 密文总长度
+func NewStreamReaderAt(src io.ReaderAt, size int64, key, ad []byte) (*StreamReaderAt, error) {
+	if size < StreamHeaderSize+StreamTagSize {
+		return nil, ErrStreamTruncated
+	}
+	header := make([]byte, StreamHeaderSize)
+	if _, err := src.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStreamHeader, err)
+	}
+	sc, err := newStreamCipher(key, ad, header)
+	if err != nil {
+		return nil, err
+	}
+
+	body := size - StreamHeaderSize
+	encChunk := int64(sc.chunkSize + StreamTagSize)
+	chunkNum := (body + encChunk - 1) / encChunk
+	lastChunk := body - (chunkNum-1)*encChunk
+	if lastChunk < StreamTagSize {
+		return nil, ErrStreamTruncated
+	}
+
+	return &StreamReaderAt{
+		src:       src,
+		sc:        sc,
+		chunkNum:  chunkNum,
+		lastChunk: lastChunk,
+		size:      body - chunkNum*StreamTagSize,
+	}, nil
+}
+
+// Size return the plaintext size 返回明文长度
+func (r *StreamReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt read len(p) bytes of plaintext starting at offset off, only the chunks covering the range are decrypted
+// 从明文偏移off处读取len(p)字节，只解密覆盖该范围的块
+func (r *StreamReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	chunkSize := int64(r.sc.chunkSize)
+	encChunk := chunkSize + StreamTagSize
+	buf := make([]byte, encChunk)
+	n := 0
+	for n < len(p) && off < r.size {
+		index := off / chunkSize
+		last := index == r.chunkNum-1
+		encLen := encChunk
+		if last {
+			encLen = r.lastChunk
+		}
+		if _, err := r.src.ReadAt(buf[:encLen], StreamHeaderSize+index*encChunk); err != nil && err != io.EOF {
+			return n, err
+		}
+		plain, err := r.sc.open(buf[:0], uint64(index), buf[:encLen], last)
+		if err != nil {
+			return n, err
+		}
+		k := copy(p[n:], plain[off-index*chunkSize:])
+		n += k
+		off += int64(k)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// SplitStream split an encrypted stream into its header and encrypted chunks, each chunk can be tagged
+// as a PDP segment directly and decrypted independently by DecryptStreamChunk
+// 将流密文切分为密文头和加密块，每个加密块可直接作为副本保持证明的段计算标签，并可通过DecryptStreamChunk独立解密
+func SplitStream(ciphertext []byte) ([]byte, [][]byte, error) {
+	if len(ciphertext) < StreamHeaderSize+StreamTagSize {
+		return nil, nil, ErrStreamTruncated
+	}
+	header := ciphertext[:StreamHeaderSize]
+	if header[0] != streamVersion {
+		return nil, nil, ErrInvalidStreamHeader
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[1:5]))
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return nil, nil, ErrInvalidStreamHeader
+	}
+
+	var chunks [][]byte
+	body := ciphertext[StreamHeaderSize:]
+	encChunk := chunkSize + StreamTagSize
+	for len(body) > encChunk {
+		chunks = append(chunks, body[:encChunk])
+		body = body[encChunk:]
+	}
+	if len(body) < StreamTagSize {
+		return nil, nil, ErrStreamTruncated
+	}
+	chunks = append(chunks, body)
+	return header, chunks, nil
+}
+
+// DecryptStreamChunk decrypt a single chunk of a stream 解密流中的单个加密块
+// - index chunk index 块序号
+// - last whether it is the last chunk 是否为最后一块
+func DecryptStreamChunk(key, ad, header []byte, index int, chunk []byte, last bool) ([]byte, error) {
+	if index < 0 {
+		return nil, fmt.Errorf("invalid chunk index %d", index)
+	}
+	sc, err := newStreamCipher(key, ad, header)
+	if err != nil {
+		return nil, err
+	}
+	return sc.open(nil, uint64(index), chunk, last)
+}
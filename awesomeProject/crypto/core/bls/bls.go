@@ -0,0 +1,331 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+/*
+	BLS signatures on BLS12-381, public keys in G2 and signatures in G1, the same groups as pdp/pairing.
+	基于BLS12-381的BLS签名，公钥在G2上，签名在G1上，与pdp/pairing一致
+	  Sign:   sig = sk * H(m)
+	  Verify: e(sig, g2) == e(H(m), pk)
+	多个签名可以聚合为一个G1点：
+	  同一消息的多签名：e(Σsig_i, g2) == e(H(m), Σpk_i)
+	  不同消息的聚合签名：e(Σsig_i, g2) == Πe(H(m_i), pk_i)
+	同一消息的多签名会受到恶意公钥攻击(rogue-key attack)，公钥在参与聚合前必须通过持有证明(proof of possession)的验证
+*/
+
+const (
+	PrivateKeyLength = fr.Bytes
+	PublicKeyLength  = bls12381.SizeOfG2AffineCompressed
+	SignatureLength  = bls12381.SizeOfG1AffineCompressed
+)
+
+// 哈希到曲线的域分隔标签，签名和持有证明使用不同的标签，见 draft-irtf-cfrg-bls-signature 4.2.3
+var (
+	signatureDST  = []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_")
+	possessionDST = []byte("BLS_POP_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_")
+)
+
+var (
+	ErrInvalidPrivateKey = errors.New("invalid bls private key")
+	ErrInvalidPublicKey  = errors.New("invalid bls public key")
+	ErrInvalidSignature  = errors.New("invalid bls signature")
+	ErrVerifyFailed      = errors.New("failed to verify bls signature")
+	ErrEmptyAggregate    = errors.New("nothing to aggregate")
+)
+
+type PrivateKey struct {
+	X *big.Int
+}
+
+type PublicKey struct {
+	P bls12381.G2Affine
+}
+
+type Signature struct {
+	S bls12381.G1Affine
+}
+
+var g2Gen bls12381.G2Affine
+
+func init() {
+	_, _, _, g2Gen = bls12381.Generators()
+}
+
+// GenerateKeyPair 随机生成BLS密钥对
+func GenerateKeyPair() (*PrivateKey, *PublicKey, error) {
+	x, err := rand.Int(rand.Reader, fr.Modulus())
+	if err != nil {
+		return nil, nil, err
+	}
+	if x.Sign() == 0 {
+		x.SetInt64(1)
+	}
+
+	privkey := &PrivateKey{X: x}
+	return privkey, privkey.PublicKey(), nil
+}
+
+// PublicKey 计算私钥对应的公钥 pk = sk * g2
+func (sk *PrivateKey) PublicKey() *PublicKey {
+	pk := new(PublicKey)
+	pk.P.ScalarMultiplication(&g2Gen, sk.X)
+	return pk
+}
+
+// Sign 对消息签名
+func Sign(privkey *PrivateKey, msg []byte) (*Signature, error) {
+	return signWithDST(privkey, msg, signatureDST)
+}
+
+// Verify 验证单个签名
+func Verify(pubkey *PublicKey, msg []byte, sig *Signature) error {
+	return verifyWithDST(pubkey, msg, sig, signatureDST)
+}
+
+// ProvePossession 生成持有证明，即使用私钥对自己的公钥签名，采用与普通签名不同的域分隔标签
+func ProvePossession(privkey *PrivateKey) (*Signature, error) {
+	pk := privkey.PublicKey().Bytes()
+	return signWithDST(privkey, pk, possessionDST)
+}
+
+// VerifyPossession 验证公钥的持有证明，公钥在参与多签名聚合前必须通过验证
+func VerifyPossession(pubkey *PublicKey, proof *Signature) error {
+	return verifyWithDST(pubkey, pubkey.Bytes(), proof, possessionDST)
+}
+
+// AggregateSignatures 聚合多个签名
+func AggregateSignatures(sigs []*Signature) (*Signature, error) {
+	if len(sigs) == 0 {
+		return nil, ErrEmptyAggregate
+	}
+
+	var acc bls12381.G1Jac
+	for _, sig := range sigs {
+		var p bls12381.G1Jac
+		p.FromAffine(&sig.S)
+		acc.AddAssign(&p)
+	}
+
+	agg := new(Signature)
+	agg.S.FromJacobian(&acc)
+	return agg, nil
+}
+
+// AggregatePublicKeys 聚合多个公钥，调用方须已验证每个公钥的持有证明
+func AggregatePublicKeys(pubkeys []*PublicKey) (*PublicKey, error) {
+	if len(pubkeys) == 0 {
+		return nil, ErrEmptyAggregate
+	}
+
+	var acc bls12381.G2Jac
+	for _, pk := range pubkeys {
+		var p bls12381.G2Jac
+		p.FromAffine(&pk.P)
+		acc.AddAssign(&p)
+	}
+
+	agg := new(PublicKey)
+	agg.P.FromJacobian(&acc)
+	if agg.P.IsInfinity() {
+		return nil, ErrInvalidPublicKey
+	}
+	return agg, nil
+}
+
+// VerifyMultiSignature 验证多个签名者对同一消息的聚合签名，调用方须已验证每个公钥的持有证明
+func VerifyMultiSignature(pubkeys []*PublicKey, msg []byte, aggSig *Signature) error {
+	aggPubkey, err := AggregatePublicKeys(pubkeys)
+	if err != nil {
+		return err
+	}
+	return Verify(aggPubkey, msg, aggSig)
+}
+
+// VerifyAggregate 验证多个签名者对各自消息的聚合签名，pubkeys[i]对应msgs[i]
+func VerifyAggregate(pubkeys []*PublicKey, msgs [][]byte, aggSig *Signature) error {
+	if len(pubkeys) == 0 {
+		return ErrEmptyAggregate
+	}
+	if len(pubkeys) != len(msgs) {
+		return fmt.Errorf("number of public keys %d and messages %d mismatch", len(pubkeys), len(msgs))
+	}
+	if err := checkSignature(aggSig); err != nil {
+		return err
+	}
+
+	// e(-sig, g2) * Πe(H(m_i), pk_i) == 1
+	ps := make([]bls12381.G1Affine, 0, len(pubkeys)+1)
+	qs := make([]bls12381.G2Affine, 0, len(pubkeys)+1)
+	var negSig bls12381.G1Affine
+	negSig.Neg(&aggSig.S)
+	ps = append(ps, negSig)
+	qs = append(qs, g2Gen)
+	for i, pk := range pubkeys {
+		if err := checkPublicKey(pk); err != nil {
+			return err
+		}
+		h, err := bls12381.HashToG1(msgs[i], signatureDST)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, h)
+		qs = append(qs, pk.P)
+	}
+
+	ok, err := bls12381.PairingCheck(ps, qs)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// Bytes 私钥编码为32字节
+func (sk *PrivateKey) Bytes() []byte {
+	return sk.X.FillBytes(make([]byte, PrivateKeyLength))
+}
+
+// Bytes 公钥编码为压缩格式的96字节
+func (pk *PublicKey) Bytes() []byte {
+	b := pk.P.Bytes()
+	return b[:]
+}
+
+// Bytes 签名编码为压缩格式的48字节
+func (sig *Signature) Bytes() []byte {
+	b := sig.S.Bytes()
+	return b[:]
+}
+
+func (sk *PrivateKey) String() string {
+	return hex.EncodeToString(sk.Bytes())
+}
+
+func (pk *PublicKey) String() string {
+	return hex.EncodeToString(pk.Bytes())
+}
+
+func (sig *Signature) String() string {
+	return hex.EncodeToString(sig.Bytes())
+}
+
+// ParsePrivateKey 从字节解析私钥
+func ParsePrivateKey(b []byte) (*PrivateKey, error) {
+	if len(b) != PrivateKeyLength {
+		return nil, ErrInvalidPrivateKey
+	}
+	x := new(big.Int).SetBytes(b)
+	if x.Sign() == 0 || x.Cmp(fr.Modulus()) >= 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	return &PrivateKey{X: x}, nil
+}
+
+// ParsePublicKey 从字节解析公钥，并检查是否在G2的素数阶子群中
+func ParsePublicKey(b []byte) (*PublicKey, error) {
+	if len(b) != PublicKeyLength {
+		return nil, ErrInvalidPublicKey
+	}
+	pk := new(PublicKey)
+	if _, err := pk.P.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	if err := checkPublicKey(pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// ParseSignature 从字节解析签名，并检查是否在G1的素数阶子群中
+func ParseSignature(b []byte) (*Signature, error) {
+	if len(b) != SignatureLength {
+		return nil, ErrInvalidSignature
+	}
+	sig := new(Signature)
+	if _, err := sig.S.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if err := checkSignature(sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func signWithDST(privkey *PrivateKey, msg, dst []byte) (*Signature, error) {
+	if privkey == nil || privkey.X == nil || privkey.X.Sign() == 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	h, err := bls12381.HashToG1(msg, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := new(Signature)
+	sig.S.ScalarMultiplication(&h, privkey.X)
+	return sig, nil
+}
+
+func verifyWithDST(pubkey *PublicKey, msg []byte, sig *Signature, dst []byte) error {
+	if err := checkPublicKey(pubkey); err != nil {
+		return err
+	}
+	if err := checkSignature(sig); err != nil {
+		return err
+	}
+	h, err := bls12381.HashToG1(msg, dst)
+	if err != nil {
+		return err
+	}
+
+	// e(-sig, g2) * e(H(m), pk) == 1
+	var negSig bls12381.G1Affine
+	negSig.Neg(&sig.S)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{negSig, h}, []bls12381.G2Affine{g2Gen, pubkey.P})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// checkPublicKey 公钥不能是无穷远点且必须在素数阶子群中
+func checkPublicKey(pk *PublicKey) error {
+	if pk == nil || pk.P.IsInfinity() || !pk.P.IsInSubGroup() {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
+
+// checkSignature 签名必须在素数阶子群中
+func checkSignature(sig *Signature) error {
+	if sig == nil || sig.S.IsInfinity() || !sig.S.IsInSubGroup() {
+		return ErrInvalidSignature
+	}
+	return nil
+}
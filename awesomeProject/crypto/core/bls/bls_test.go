@@ -0,0 +1,94 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBLSSign(t *testing.T) {
+	sk, pk, err := GenerateKeyPair()
+	require.NoError(t, err)
+
+	msg := []byte("training result attestation")
+	sig, err := Sign(sk, msg)
+	require.NoError(t, err)
+	require.NoError(t, Verify(pk, msg, sig))
+	require.Equal(t, ErrVerifyFailed, Verify(pk, []byte("other"), sig))
+
+	skFromBytes, err := ParsePrivateKey(sk.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, 0, sk.X.Cmp(skFromBytes.X))
+	pkFromBytes, err := ParsePublicKey(pk.Bytes())
+	require.NoError(t, err)
+	require.True(t, pk.P.Equal(&pkFromBytes.P))
+	sigFromBytes, err := ParseSignature(sig.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, Verify(pk, msg, sigFromBytes))
+
+	// 持有证明不能当作对公钥的普通签名使用
+	proof, err := ProvePossession(sk)
+	require.NoError(t, err)
+	require.NoError(t, VerifyPossession(pk, proof))
+	require.Error(t, Verify(pk, pk.Bytes(), proof))
+	_, other, err := GenerateKeyPair()
+	require.NoError(t, err)
+	require.Error(t, VerifyPossession(other, proof))
+}
+
+func TestBLSAggregate(t *testing.T) {
+	num := 4
+	sks := make([]*PrivateKey, num)
+	pks := make([]*PublicKey, num)
+	for i := range sks {
+		sk, pk, err := GenerateKeyPair()
+		require.NoError(t, err)
+		proof, err := ProvePossession(sk)
+		require.NoError(t, err)
+		require.NoError(t, VerifyPossession(pk, proof))
+		sks[i], pks[i] = sk, pk
+	}
+
+	// 同一消息的多签名
+	msg := []byte("model hash")
+	sigs := make([]*Signature, num)
+	for i, sk := range sks {
+		sig, err := Sign(sk, msg)
+		require.NoError(t, err)
+		sigs[i] = sig
+	}
+	aggSig, err := AggregateSignatures(sigs)
+	require.NoError(t, err)
+	require.NoError(t, VerifyMultiSignature(pks, msg, aggSig))
+	require.Error(t, VerifyMultiSignature(pks[:num-1], msg, aggSig))
+
+	// 不同消息的聚合签名
+	msgs := make([][]byte, num)
+	for i, sk := range sks {
+		msgs[i] = []byte{byte(i)}
+		sig, err := Sign(sk, msgs[i])
+		require.NoError(t, err)
+		sigs[i] = sig
+	}
+	aggSig, err = AggregateSignatures(sigs)
+	require.NoError(t, err)
+	require.NoError(t, VerifyAggregate(pks, msgs, aggSig))
+	msgs[0], msgs[1] = msgs[1], msgs[0]
+	require.Equal(t, ErrVerifyFailed, VerifyAggregate(pks, msgs, aggSig))
+
+	_, err = AggregateSignatures(nil)
+	require.Equal(t, ErrEmptyAggregate, err)
+}
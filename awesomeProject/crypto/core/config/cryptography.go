@@ -17,4 +17,25 @@ package config
 const (
 	// CurveNist 美国 Federal Information Processing Standards 的椭圆曲线
 	CurveNist = "P-256"
+
+	// CurveNistP384 NIST P-384 椭圆曲线
+	CurveNistP384 = "P-384"
+
+	// CurveNistP521 NIST P-521 椭圆曲线
+	CurveNistP521 = "P-521"
+
+	// CurveSecp256k1 比特币、以太坊等区块链使用的Koblitz椭圆曲线
+	CurveSecp256k1 = "secp256k1"
+
+	// CurveSM2 国密SM2推荐的椭圆曲线
+	CurveSM2 = "SM2-P-256"
+)
+
+// 定义密码套件的类型
+const (
+	// SuiteStandard 国际标准算法：SHA-256、AES-GCM、ECDSA(P-256)、ECIES
+	SuiteStandard = "standard"
+
+	// SuiteGM 国密算法：SM3、SM4-GCM、SM2签名和加密
+	SuiteGM = "gm"
 )
@@ -21,6 +21,9 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
 )
 
 /*
@@ -34,7 +37,8 @@ const (
 )
 
 var (
-	defaultCurve elliptic.Curve
+	defaultCurve   elliptic.Curve
+	defaultCurveMu sync.RWMutex
 )
 
 type PublicKey [PublicKeyLength]byte
@@ -47,6 +51,32 @@ func init() {
 	defaultCurve = elliptic.P256()
 }
 
+// SetDefaultCurve set the curve used by the fixed-size key types 设置定长密钥类型使用的曲线
+// only curves with 32-byte coordinates are allowed, e.g. P-256, secp256k1 and SM2-P-256
+// keys and signatures generated under another default curve cannot be parsed after switching
+func SetDefaultCurve(curveName string) error {
+	curve, err := ecc.CurveByName(curveName)
+	if err != nil {
+		return err
+	}
+	if ecc.CoordinateLength(curve) != PrivateKeyLength {
+		return fmt.Errorf("%w: default curve must have %d-byte coordinates", ecc.ErrInvalidCurveParam, PrivateKeyLength)
+	}
+
+	defaultCurveMu.Lock()
+	defaultCurve = curve
+	defaultCurveMu.Unlock()
+	return nil
+}
+
+// DefaultCurve return the curve used by the fixed-size key types 返回定长密钥类型使用的曲线
+// each operation reads the curve once, so a concurrent SetDefaultCurve never mixes two curves in one call
+func DefaultCurve() elliptic.Curve {
+	defaultCurveMu.RLock()
+	defer defaultCurveMu.RUnlock()
+	return defaultCurve
+}
+
 func (pk PublicKey) String() string {
 	return hex.EncodeToString(pk[:])
 }
@@ -61,7 +91,7 @@ func (s Signature) String() string {
 
 // GenerateKeyPair generate a key pair 生成密钥对
 func GenerateKeyPair() (privkey PrivateKey, pubkey PublicKey, err error) {
-	privateKey, err := ecdsa.GenerateKey(defaultCurve, rand.Reader)
+	privateKey, err := ecdsa.GenerateKey(DefaultCurve(), rand.Reader)
 	if err != nil {
 		// will never happen
 		return
@@ -76,11 +106,12 @@ func GenerateKeyPair() (privkey PrivateKey, pubkey PublicKey, err error) {
 func ParsePrivateKey(privkey PrivateKey) ecdsa.PrivateKey {
 	D := new(big.Int).SetBytes(privkey[:])
 
-	x, y := defaultCurve.ScalarBaseMult(D.Bytes())
+	curve := DefaultCurve()
+	x, y := curve.ScalarBaseMult(D.Bytes())
 
 	return ecdsa.PrivateKey{
 		PublicKey: ecdsa.PublicKey{
-			Curve: defaultCurve,
+			Curve: curve,
 			X:     x,
 			Y:     y,
 		},
@@ -93,12 +124,13 @@ func ParsePublicKey(pubkey PublicKey) (ecdsa.PublicKey, error) {
 	x := new(big.Int).SetBytes(pubkey[:32])
 	y := new(big.Int).SetBytes(pubkey[32:])
 
-	if !defaultCurve.IsOnCurve(x, y) {
+	curve := DefaultCurve()
+	if !curve.IsOnCurve(x, y) {
 		return ecdsa.PublicKey{}, errors.New("public key not on curve")
 	}
 
 	return ecdsa.PublicKey{
-		Curve: defaultCurve,
+		Curve: curve,
 		X:     x,
 		Y:     y,
 	}, nil
@@ -128,7 +160,7 @@ func PublicKeyFromPrivateKey(privkey PrivateKey) PublicKey {
 	return MarshalPublicKey(&ecPrivkey.PublicKey)
 }
 
-// Sign sign a digest
+// Sign sign a digest, s is normalized to the lower half of the order 签名，s规范化为不大于n/2的值
 func Sign(privkey PrivateKey, digest []byte) (Signature, error) {
 	privateKey := ParsePrivateKey(privkey)
 
@@ -136,15 +168,24 @@ func Sign(privkey PrivateKey, digest []byte) (Signature, error) {
 	if err != nil {
 		return Signature{}, fmt.Errorf("failed to sign digest %w", err)
 	}
+	s, _ = normalizeS(s, privateKey.Curve)
 
-	rr, ss := padStart(r.Bytes(), 32), padStart(s.Bytes(), 32)
-	var sig Signature
-	copy(sig[:], rr)
-	copy(sig[32:], ss)
-	return sig, nil
+	return marshalSignature(r, s), nil
 }
 
-// Verify verify a signature 验证签名
+// SignDeterministic sign a digest with RFC 6979 nonce 使用RFC 6979确定性随机数签名，同一私钥对同一摘要的签名总是相同
+func SignDeterministic(privkey PrivateKey, digest []byte) (Signature, error) {
+	privateKey := ParsePrivateKey(privkey)
+
+	r, s, _, err := signDeterministic(&privateKey, digest)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	return marshalSignature(r, s), nil
+}
+
+// Verify verify a signature 验证签名，s大于n/2的签名视为无效，避免签名延展性
 func Verify(pubkey PublicKey, digest []byte, signature Signature) error {
 	publicKey, err := ParsePublicKey(pubkey)
 	if err != nil {
@@ -153,6 +194,9 @@ func Verify(pubkey PublicKey, digest []byte, signature Signature) error {
 
 	rr, ss := signature[:32], signature[32:]
 	r, s := new(big.Int).SetBytes(rr), new(big.Int).SetBytes(ss)
+	if !isLowS(s, publicKey.Curve) {
+		return ErrHighS
+	}
 
 	if !ecdsa.Verify(&publicKey, digest, r, s) {
 		return errors.New("failed to verify")
@@ -14,10 +14,16 @@
 package ecdsa
 
 import (
+	"crypto/elliptic"
 	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
 )
 
 func TestEcdsa(t *testing.T) {
@@ -59,3 +65,146 @@ func TestEcdsa(t *testing.T) {
 	}
 	require.Equal(t, signFromStr, sign)
 }
+
+func TestTaggedEcdsa(t *testing.T) {
+	digest := sha256.Sum256([]byte("test"))
+	curves := []string{config.CurveNist, config.CurveNistP384, config.CurveNistP521, config.CurveSecp256k1, config.CurveSM2}
+
+	var lastPubkey TaggedPublicKey
+	for _, name := range curves {
+		privkey, pubkey, err := GenerateTaggedKeyPair(name)
+		require.NoError(t, err, name)
+
+		curve, err := ecc.CurveByName(name)
+		require.NoError(t, err)
+		id, err := ecc.IDOfCurve(curve)
+		require.NoError(t, err)
+		require.Equal(t, byte(id), pubkey[0])
+
+		pubFromPriv, err := TaggedPublicKeyFromPrivateKey(privkey)
+		require.NoError(t, err)
+		require.Equal(t, pubkey, pubFromPriv)
+
+		sign, err := SignTagged(privkey, digest[:])
+		require.NoError(t, err, name)
+		require.NoError(t, VerifyTagged(pubkey, digest[:], sign), name)
+
+		other := sha256.Sum256([]byte("other"))
+		require.Error(t, VerifyTagged(pubkey, other[:], sign))
+		if lastPubkey != nil {
+			require.ErrorIs(t, VerifyTagged(lastPubkey, digest[:], sign), ErrCurveMismatch)
+		}
+		lastPubkey = pubkey
+
+		privFromStr, err := DecodeTaggedPrivateKeyFromString(privkey.String())
+		require.NoError(t, err)
+		require.Equal(t, privkey, privFromStr)
+		pubFromStr, err := DecodeTaggedPublicKeyFromString(pubkey.String())
+		require.NoError(t, err)
+		require.Equal(t, pubkey, pubFromStr)
+		signFromStr, err := DecodeTaggedSignatureFromString(sign.String())
+		require.NoError(t, err)
+		require.Equal(t, sign, signFromStr)
+
+		_, err = ParseTaggedPublicKey(pubkey[:len(pubkey)-1])
+		require.ErrorIs(t, err, ErrInvalidTaggedKey)
+	}
+
+	_, _, err := GenerateTaggedKeyPair("unknown")
+	require.ErrorIs(t, err, ecc.ErrUnknownCurve)
+}
+
+func TestSetDefaultCurve(t *testing.T) {
+	defer func() {
+		require.NoError(t, SetDefaultCurve(config.CurveNist))
+	}()
+
+	require.Error(t, SetDefaultCurve(config.CurveNistP384))
+
+	for _, name := range []string{config.CurveSecp256k1, config.CurveSM2} {
+		require.NoError(t, SetDefaultCurve(name))
+
+		privkey, pubkey, err := GenerateKeyPair()
+		require.NoError(t, err)
+		require.Equal(t, pubkey, PublicKeyFromPrivateKey(privkey))
+
+		digest := sha256.Sum256([]byte("test"))
+		sign, err := Sign(privkey, digest[:])
+		require.NoError(t, err)
+		require.NoError(t, Verify(pubkey, digest[:], sign))
+		require.Equal(t, name, DefaultCurve().Params().Name)
+	}
+}
+
+func TestSignDeterministic(t *testing.T) {
+	// RFC 6979 A.2.5，P-256，SHA-256，消息"sample"
+	privkey, err := DecodePrivateKeyFromString("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	require.NoError(t, err)
+	pubkey := PublicKeyFromPrivateKey(privkey)
+	digest := sha256.Sum256([]byte("sample"))
+
+	sign, err := SignDeterministic(privkey, digest[:])
+	require.NoError(t, err)
+	require.Equal(t, "efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716", hex.EncodeToString(sign[:32]))
+	// 标准向量中的s大于n/2，规范化后为n-s
+	s, _ := new(big.Int).SetString("f7cb1c942d657c41d436c7a1b6e29f65f3e900dbb9aff4064dc4ab2f843acda8", 16)
+	s.Sub(elliptic.P256().Params().N, s)
+	require.Equal(t, s, new(big.Int).SetBytes(sign[32:]))
+	require.NoError(t, Verify(pubkey, digest[:], sign))
+
+	again, err := SignDeterministic(privkey, digest[:])
+	require.NoError(t, err)
+	require.Equal(t, sign, again)
+
+	// 高S签名验证失败
+	var high Signature
+	copy(high[:32], sign[:32])
+	new(big.Int).Sub(elliptic.P256().Params().N, s).FillBytes(high[32:])
+	require.Equal(t, ErrHighS, Verify(pubkey, digest[:], high))
+
+	for i := 0; i < 10; i++ {
+		privkey, pubkey, err := GenerateKeyPair()
+		require.NoError(t, err)
+		sign, err := Sign(privkey, digest[:])
+		require.NoError(t, err)
+		require.NoError(t, Verify(pubkey, digest[:], sign))
+	}
+}
+
+func TestRecoverPublicKey(t *testing.T) {
+	defer func() {
+		require.NoError(t, SetDefaultCurve(config.CurveNist))
+	}()
+
+	for _, name := range []string{config.CurveNist, config.CurveSecp256k1, config.CurveSM2} {
+		require.NoError(t, SetDefaultCurve(name))
+
+		for i := 0; i < 10; i++ {
+			privkey, pubkey, err := GenerateKeyPair()
+			require.NoError(t, err)
+			digest := sha256.Sum256([]byte{byte(i)})
+
+			sign, err := SignRecoverable(privkey, digest[:])
+			require.NoError(t, err)
+			require.NoError(t, Verify(pubkey, digest[:], sign.Signature()))
+
+			recovered, err := RecoverPublicKey(digest[:], sign)
+			require.NoError(t, err, name)
+			require.Equal(t, pubkey, recovered, name)
+
+			signFromStr, err := DecodeRecoverableSignatureFromString(sign.String())
+			require.NoError(t, err)
+			require.Equal(t, sign, signFromStr)
+
+			// 错误的恢复标识得到不同的公钥或失败
+			sign[SignatureLength] ^= 1
+			recovered, err = RecoverPublicKey(digest[:], sign)
+			if err == nil {
+				require.NotEqual(t, pubkey, recovered)
+			}
+			sign[SignatureLength] = 4
+			_, err = RecoverPublicKey(digest[:], sign)
+			require.Equal(t, ErrInvalidRecoveryID, err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+)
+
+/*
+	Low-S normalized, deterministic and recoverable signatures. 低S规范化、确定性和可恢复公钥的签名
+	(r, s)和(r, n-s)都是合法的ECDSA签名，签名时统一取s<=n/2，验签时拒绝s>n/2，使每个签名只有一种有效编码
+	RecoverableSignature: r || s || v，v为恢复标识，第0位为R点y坐标的奇偶性，第1位表示R点x坐标是否不小于n
+*/
+
+const (
+	RecoverableSignatureLength = SignatureLength + 1
+)
+
+var (
+	ErrHighS               = errors.New("signature s is not in the lower half of the order")
+	ErrInvalidRecoveryID   = errors.New("invalid recovery id")
+	ErrPublicKeyRecovering = errors.New("failed to recover public key from signature")
+)
+
+type RecoverableSignature [RecoverableSignatureLength]byte
+
+func (s RecoverableSignature) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// Signature 去掉恢复标识，得到普通签名
+func (s RecoverableSignature) Signature() Signature {
+	var sig Signature
+	copy(sig[:], s[:SignatureLength])
+	return sig
+}
+
+// SignRecoverable sign a digest with RFC 6979 nonce and append the recovery id 确定性签名并附加恢复标识
+func SignRecoverable(privkey PrivateKey, digest []byte) (RecoverableSignature, error) {
+	privateKey := ParsePrivateKey(privkey)
+
+	r, s, v, err := signDeterministic(&privateKey, digest)
+	if err != nil {
+		return RecoverableSignature{}, err
+	}
+
+	var sig RecoverableSignature
+	plain := marshalSignature(r, s)
+	copy(sig[:], plain[:])
+	sig[SignatureLength] = v
+	return sig, nil
+}
+
+// RecoverPublicKey recover the public key from a digest and recoverable signature 从摘要和可恢复签名中恢复公钥
+// Q = r^-1 * (s*R - e*G)
+func RecoverPublicKey(digest []byte, signature RecoverableSignature) (PublicKey, error) {
+	curve := DefaultCurve()
+	params := curve.Params()
+	n := params.N
+
+	v := signature[SignatureLength]
+	if v > 3 {
+		return PublicKey{}, ErrInvalidRecoveryID
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:SignatureLength])
+	if r.Sign() == 0 || r.Cmp(n) >= 0 || s.Sign() == 0 || s.Cmp(n) >= 0 {
+		return PublicKey{}, ErrPublicKeyRecovering
+	}
+	if !isLowS(s, curve) {
+		return PublicKey{}, ErrHighS
+	}
+
+	// 还原R点
+	x := new(big.Int).Set(r)
+	if v&2 != 0 {
+		x.Add(x, n)
+	}
+	y, err := ecc.DecompressY(curve, x, v&1 == 1)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("%w: %v", ErrPublicKeyRecovering, err)
+	}
+
+	rInv := new(big.Int).ModInverse(r, n)
+	e := hashToInt(digest, curve)
+	u1 := new(big.Int).Neg(e)
+	u1.Mul(u1, rInv)
+	u1.Mod(u1, n)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, n)
+
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(x, y, u2.Bytes())
+	qx, qy := curve.Add(x1, y1, x2, y2)
+	if (qx.Sign() == 0 && qy.Sign() == 0) || !curve.IsOnCurve(qx, qy) {
+		return PublicKey{}, ErrPublicKeyRecovering
+	}
+
+	publicKey := &ecdsa.PublicKey{Curve: curve, X: qx, Y: qy}
+	if !ecdsa.Verify(publicKey, digest, r, s) {
+		return PublicKey{}, ErrPublicKeyRecovering
+	}
+
+	return MarshalPublicKey(publicKey), nil
+}
+
+// DecodeRecoverableSignatureFromString decode recoverable signature from string 从字符串解码可恢复签名
+func DecodeRecoverableSignatureFromString(s string) (RecoverableSignature, error) {
+	var sig RecoverableSignature
+
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return RecoverableSignature{}, err
+	}
+	if len(bs) != RecoverableSignatureLength {
+		return RecoverableSignature{}, fmt.Errorf("invalid signature length")
+	}
+	copy(sig[:], bs)
+	return sig, nil
+}
+
+// signDeterministic 使用RFC 6979随机数签名，返回低S规范化后的签名和恢复标识
+func signDeterministic(privateKey *ecdsa.PrivateKey, digest []byte) (r, s *big.Int, v byte, err error) {
+	curve := privateKey.Curve
+	n := curve.Params().N
+	e := hashToInt(digest, curve)
+
+	nextK := nonceRFC6979(n, privateKey.D, digest)
+	for {
+		k := nextK()
+		rx, ry := curve.ScalarBaseMult(k.Bytes())
+		r = new(big.Int).Mod(rx, n)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		// s = k^-1 * (e + r*d) mod n
+		s = new(big.Int).Mul(r, privateKey.D)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		v = byte(ry.Bit(0))
+		if rx.Cmp(n) >= 0 {
+			v |= 2
+		}
+		// s取n-s时R点取反，y坐标的奇偶性翻转
+		var flipped bool
+		if s, flipped = normalizeS(s, curve); flipped {
+			v ^= 1
+		}
+		return r, s, v, nil
+	}
+}
+
+// normalizeS 将s规范化为不大于n/2的值，返回是否做了翻转
+func normalizeS(s *big.Int, curve elliptic.Curve) (*big.Int, bool) {
+	if isLowS(s, curve) {
+		return s, false
+	}
+	return new(big.Int).Sub(curve.Params().N, s), true
+}
+
+// isLowS 判断s是否不大于n/2
+func isLowS(s *big.Int, curve elliptic.Curve) bool {
+	halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+	return s.Cmp(halfOrder) <= 0
+}
+
+// hashToInt 将摘要转为整数，摘要长于阶时取最左边的比特，与crypto/ecdsa一致
+func hashToInt(digest []byte, curve elliptic.Curve) *big.Int {
+	orderBits := curve.Params().N.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+
+	ret := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - orderBits; excess > 0 {
+		ret.Rsh(ret, uint(excess))
+	}
+	return ret
+}
+
+// marshalSignature 将r、s编码为定长签名
+func marshalSignature(r, s *big.Int) Signature {
+	var sig Signature
+	copy(sig[:32], padStart(r.Bytes(), 32))
+	copy(sig[32:], padStart(s.Bytes(), 32))
+	return sig
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecdsa
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+	"math/big"
+)
+
+// RFC 6979 确定性签名随机数
+// k由私钥和消息摘要经HMAC-DRBG派生，同一私钥对同一摘要总是得到相同的签名，且不依赖签名时的随机源
+
+// nonceRFC6979 返回RFC 6979 3.2节的k生成器，每次调用返回下一个候选k，调用方在k不可用时(如r=0)继续调用
+func nonceRFC6979(q, x *big.Int, digest []byte) func() *big.Int {
+	hashFunc := sha256.New
+	qlen := q.BitLen()
+	rlen := (qlen + 7) / 8
+	hlen := hashFunc().Size()
+
+	// step b, c
+	v := make([]byte, hlen)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, hlen)
+
+	bx := append(int2octets(x, rlen), bits2octets(digest, q, qlen, rlen)...)
+
+	// step d - g
+	k = hmacSum(hashFunc, k, v, []byte{0x00}, bx)
+	v = hmacSum(hashFunc, k, v)
+	k = hmacSum(hashFunc, k, v, []byte{0x01}, bx)
+	v = hmacSum(hashFunc, k, v)
+
+	first := true
+	return func() *big.Int {
+		for {
+			if !first {
+				k = hmacSum(hashFunc, k, v, []byte{0x00})
+				v = hmacSum(hashFunc, k, v)
+			}
+			first = false
+
+			// step h
+			var t []byte
+			for len(t) < rlen {
+				v = hmacSum(hashFunc, k, v)
+				t = append(t, v...)
+			}
+
+			secret := bits2int(t, qlen)
+			if secret.Sign() > 0 && secret.Cmp(q) < 0 {
+				return secret
+			}
+		}
+	}
+}
+
+func hmacSum(hashFunc func() hash.Hash, key []byte, data ...[]byte) []byte {
+	mac := hmac.New(hashFunc, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// bits2int 取比特串最左边的qlen比特转为整数
+func bits2int(in []byte, qlen int) *big.Int {
+	v := new(big.Int).SetBytes(in)
+	if vlen := len(in) * 8; vlen > qlen {
+		v.Rsh(v, uint(vlen-qlen))
+	}
+	return v
+}
+
+// int2octets 将整数编码为rlen字节
+func int2octets(v *big.Int, rlen int) []byte {
+	out := make([]byte, rlen)
+	return v.FillBytes(out)
+}
+
+// bits2octets 将摘要转为小于q的整数后编码为rlen字节
+func bits2octets(in []byte, q *big.Int, qlen, rlen int) []byte {
+	z1 := bits2int(in, qlen)
+	z2 := new(big.Int).Sub(z1, q)
+	if z2.Sign() < 0 {
+		return int2octets(z1, rlen)
+	}
+	return int2octets(z2, rlen)
+}
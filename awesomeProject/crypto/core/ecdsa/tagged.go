@@ -0,0 +1,268 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+)
+
+/*
+	Curve-tagged keys and signatures, the first byte is the curve identifier from the ecc curve registry,
+	so that keys of different curves (P-256, P-384, secp256k1, SM2 ...) can be stored and exchanged together.
+	带曲线标识的密钥和签名，第一个字节为ecc曲线注册表中的曲线标识，
+	  TaggedPublicKey:  CurveID || X || Y
+	  TaggedPrivateKey: CurveID || D
+	  TaggedSignature:  CurveID || r || s
+	X、Y、D、r、s均按曲线的坐标长度左侧补零
+*/
+
+var (
+	ErrInvalidTaggedKey = errors.New("invalid curve-tagged key")
+	ErrCurveMismatch    = errors.New("the curves of key and signature do not match")
+)
+
+type TaggedPublicKey []byte
+
+type TaggedPrivateKey []byte
+
+type TaggedSignature []byte
+
+func (pk TaggedPublicKey) String() string {
+	return hex.EncodeToString(pk)
+}
+
+func (sk TaggedPrivateKey) String() string {
+	return hex.EncodeToString(sk)
+}
+
+func (s TaggedSignature) String() string {
+	return hex.EncodeToString(s)
+}
+
+// GenerateTaggedKeyPair generate a key pair on the given curve 在指定曲线上生成密钥对
+func GenerateTaggedKeyPair(curveName string) (TaggedPrivateKey, TaggedPublicKey, error) {
+	curve, err := ecc.CurveByName(curveName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	privkey, err := MarshalTaggedPrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubkey, err := MarshalTaggedPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privkey, pubkey, nil
+}
+
+// MarshalTaggedPrivateKey marshal private key to curve-tagged bytes 将私钥编码为带曲线标识的字节
+func MarshalTaggedPrivateKey(privkey *ecdsa.PrivateKey) (TaggedPrivateKey, error) {
+	id, err := ecc.IDOfCurve(privkey.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	size := ecc.CoordinateLength(privkey.Curve)
+	res := make([]byte, 1+size)
+	res[0] = byte(id)
+	privkey.D.FillBytes(res[1:])
+
+	return res, nil
+}
+
+// MarshalTaggedPublicKey marshal public key to curve-tagged bytes 将公钥编码为带曲线标识的字节
+func MarshalTaggedPublicKey(pubkey *ecdsa.PublicKey) (TaggedPublicKey, error) {
+	id, err := ecc.IDOfCurve(pubkey.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	size := ecc.CoordinateLength(pubkey.Curve)
+	res := make([]byte, 1+2*size)
+	res[0] = byte(id)
+	pubkey.X.FillBytes(res[1 : 1+size])
+	pubkey.Y.FillBytes(res[1+size:])
+
+	return res, nil
+}
+
+// ParseTaggedPrivateKey parse from curve-tagged bytes to EC private key 从带曲线标识的字节解析私钥
+func ParseTaggedPrivateKey(privkey TaggedPrivateKey) (*ecdsa.PrivateKey, error) {
+	curve, body, err := splitTag(privkey, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	D := new(big.Int).SetBytes(body)
+	if D.Sign() == 0 || D.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidTaggedKey
+	}
+	x, y := curve.ScalarBaseMult(D.Bytes())
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: curve,
+			X:     x,
+			Y:     y,
+		},
+		D: D,
+	}, nil
+}
+
+// ParseTaggedPublicKey parse from curve-tagged bytes to EC public key 从带曲线标识的字节解析公钥
+func ParseTaggedPublicKey(pubkey TaggedPublicKey) (*ecdsa.PublicKey, error) {
+	curve, body, err := splitTag(pubkey, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	size := ecc.CoordinateLength(curve)
+	x := new(big.Int).SetBytes(body[:size])
+	y := new(big.Int).SetBytes(body[size:])
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("public key not on curve")
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     x,
+		Y:     y,
+	}, nil
+}
+
+// TaggedPublicKeyFromPrivateKey 从带曲线标识的私钥得到对应的公钥
+func TaggedPublicKeyFromPrivateKey(privkey TaggedPrivateKey) (TaggedPublicKey, error) {
+	privateKey, err := ParseTaggedPrivateKey(privkey)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalTaggedPublicKey(&privateKey.PublicKey)
+}
+
+// SignTagged sign a digest with curve-tagged private key 使用带曲线标识的私钥签名，s规范化为不大于n/2的值
+func SignTagged(privkey TaggedPrivateKey, digest []byte) (TaggedSignature, error) {
+	privateKey, err := ParseTaggedPrivateKey(privkey)
+	if err != nil {
+		return nil, err
+	}
+
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign digest %w", err)
+	}
+	s, _ = normalizeS(s, privateKey.Curve)
+
+	size := ecc.CoordinateLength(privateKey.Curve)
+	sig := make([]byte, 1+2*size)
+	sig[0] = privkey[0]
+	r.FillBytes(sig[1 : 1+size])
+	s.FillBytes(sig[1+size:])
+
+	return sig, nil
+}
+
+// VerifyTagged verify a curve-tagged signature 验证带曲线标识的签名
+func VerifyTagged(pubkey TaggedPublicKey, digest []byte, signature TaggedSignature) error {
+	publicKey, err := ParseTaggedPublicKey(pubkey)
+	if err != nil {
+		return err
+	}
+	if len(signature) == 0 || signature[0] != pubkey[0] {
+		return ErrCurveMismatch
+	}
+	_, body, err := splitTag(signature, 2)
+	if err != nil {
+		return err
+	}
+
+	size := ecc.CoordinateLength(publicKey.Curve)
+	r, s := new(big.Int).SetBytes(body[:size]), new(big.Int).SetBytes(body[size:])
+	if !isLowS(s, publicKey.Curve) {
+		return ErrHighS
+	}
+	if !ecdsa.Verify(publicKey, digest, r, s) {
+		return errors.New("failed to verify")
+	}
+
+	return nil
+}
+
+// DecodeTaggedPrivateKeyFromString decode curve-tagged private key from string 从字符串解码带曲线标识的私钥
+func DecodeTaggedPrivateKeyFromString(s string) (TaggedPrivateKey, error) {
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key format")
+	}
+	if _, err := ParseTaggedPrivateKey(bs); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// DecodeTaggedPublicKeyFromString decode curve-tagged public key from string 从字符串解码带曲线标识的公钥
+func DecodeTaggedPublicKeyFromString(s string) (TaggedPublicKey, error) {
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key format")
+	}
+	if _, err := ParseTaggedPublicKey(bs); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// DecodeTaggedSignatureFromString decode curve-tagged signature from string 从字符串解码带曲线标识的签名
+func DecodeTaggedSignatureFromString(s string) (TaggedSignature, error) {
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := splitTag(bs, 2); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// splitTag 解析曲线标识，并检查剩余部分的长度是否为坐标长度的parts倍
+func splitTag(data []byte, parts int) (elliptic.Curve, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, ErrInvalidTaggedKey
+	}
+
+	curve, err := ecc.CurveByID(ecc.CurveID(data[0]))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data)-1 != parts*ecc.CoordinateLength(curve) {
+		return nil, nil, fmt.Errorf("%w: invalid length %d for curve %s", ErrInvalidTaggedKey, len(data), curve.Params().Name)
+	}
+
+	return curve, data[1:], nil
+}
@@ -18,15 +18,26 @@ import (
 	"crypto/rand"
 	"fmt"
 
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
 	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
 	libecies "github.com/PaddlePaddle/PaddleDTX/crypto/core/ecies/libecies"
 )
 
+func init() {
+	// libecies内置了NIST曲线的参数，为secp256k1和SM2曲线注册参数
+	for _, name := range []string{config.CurveSecp256k1, config.CurveSM2} {
+		curve, err := ecc.CurveByName(name)
+		if err != nil {
+			panic(err)
+		}
+		libecies.AddParamsForCurve(curve, libecies.ECIES_AES128_SHA256)
+	}
+}
+
 // Encrypt 非对称加密
 func Encrypt(k *ecdsa.PublicKey, msg []byte) (cypherText []byte, err error) {
-	// 判断是否是NIST标准的公钥
-	isNistCurve := checkKeyCurve(k)
-	if !isNistCurve {
+	// 判断是否是支持的曲线
+	if !checkKeyCurve(k) {
 		return nil, fmt.Errorf("this cryptography curve[%s] has not been supported yet", k.Params().Name)
 	}
 
@@ -40,25 +51,22 @@ func Encrypt(k *ecdsa.PublicKey, msg []byte) (cypherText []byte, err error) {
 	return ct, nil
 }
 
-// checkKeyCurve 判断是否是NIST标准的公钥
+// checkKeyCurve 判断公钥的曲线是否已注册且配置了ECIES参数，包括NIST P-256/P-384/P-521、secp256k1和SM2
 func checkKeyCurve(k *ecdsa.PublicKey) bool {
 	if k.X == nil || k.Y == nil {
 		return false
 	}
-
-	switch k.Params().Name {
-	case config.CurveNist: // NIST
-		return true
-	default: // 不支持的密码学类型
+	if _, err := ecc.IDOfCurve(k.Curve); err != nil {
 		return false
 	}
+
+	return libecies.ParamsFromCurve(k.Curve) != nil
 }
 
 // Decrypt 非对称解密
 func Decrypt(k *ecdsa.PrivateKey, cypherText []byte) (msg []byte, err error) {
-	// 判断是否是NIST标准的私钥
-	isNistCurve := checkKeyCurve(&k.PublicKey)
-	if !isNistCurve {
+	// 判断是否是支持的曲线
+	if !checkKeyCurve(&k.PublicKey) {
 		return nil, fmt.Errorf("this cryptography curve[%s] has not been supported yet", k.Params().Name)
 	}
 	if k.D == nil {
@@ -0,0 +1,49 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecies
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+)
+
+func TestEciesCurves(t *testing.T) {
+	msg := []byte("ecies on pluggable curves")
+
+	for _, name := range []string{config.CurveNist, config.CurveNistP384, config.CurveNistP521, config.CurveSecp256k1, config.CurveSM2} {
+		curve, err := ecc.CurveByName(name)
+		require.NoError(t, err)
+		privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		ct, err := Encrypt(&privateKey.PublicKey, msg)
+		require.NoError(t, err, name)
+		pt, err := Decrypt(privateKey, ct)
+		require.NoError(t, err, name)
+		require.Equal(t, msg, pt, name)
+	}
+
+	// 未注册的曲线
+	privateKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	_, err = Encrypt(&privateKey.PublicKey, msg)
+	require.Error(t, err)
+}
@@ -170,7 +170,8 @@ func concatKDF(hash hash.Hash, z, s1 []byte, kdLen int) (k []byte, err error) {
 		s1 = make([]byte, 0)
 	}
 
-	reps := ((kdLen + 7) * 8) / (hash.BlockSize() * 8)
+	// 按哈希输出长度计算迭代次数，SHA-384的分组长度大于输出长度，按分组长度计算会导致派生的密钥不足kdLen
+	reps := (kdLen + hash.Size() - 1) / hash.Size()
 	if big.NewInt(int64(reps)).Cmp(big2To32M1) > 0 {
 		fmt.Println(big2To32M1)
 		return nil, ErrKeyDataTooLong
@@ -179,7 +180,7 @@ func concatKDF(hash hash.Hash, z, s1 []byte, kdLen int) (k []byte, err error) {
 	counter := []byte{0, 0, 0, 1}
 	k = make([]byte, 0)
 
-	for i := 0; i <= reps; i++ {
+	for i := 0; i < reps; i++ {
 		hash.Write(counter)
 		hash.Write(z)
 		hash.Write(s1)
@@ -269,7 +270,8 @@ func Encrypt(rand io.Reader, pub *PublicKey, m, s1, s2 []byte) (ct []byte, err e
 	}
 
 	hash := params.Hash()
-	z, err := R.GenerateShared(pub, params.KeyLen, params.KeyLen)
+	// 共享秘密取完整的x坐标，再由KDF派生加密和MAC密钥，P-384、P-521的坐标长度与KeyLen无关
+	z, err := R.GenerateShared(pub, MaxSharedKeyLength(pub), 0)
 	if err != nil {
 		return
 	}
@@ -321,7 +323,8 @@ func (prv *PrivateKey) Decrypt(rand io.Reader, c, s1, s2 []byte) (m []byte, err
 
 	switch c[0] {
 	case 2, 3, 4:
-		rLen = ((prv.PublicKey.Curve.Params().BitSize + 7) / 4)
+		// 非压缩点编码的长度为1+2*坐标长度，P-521的坐标长度不是整字节数，不能按BitSize/4计算
+		rLen = 2*((prv.PublicKey.Curve.Params().BitSize+7)/8) + 1
 		if len(c) < (rLen + hLen + 1) {
 			err = ErrInvalidMessage
 			return
@@ -346,7 +349,7 @@ func (prv *PrivateKey) Decrypt(rand io.Reader, c, s1, s2 []byte) (m []byte, err
 		return
 	}
 
-	z, err := prv.GenerateShared(R, params.KeyLen, params.KeyLen)
+	z, err := prv.GenerateShared(R, MaxSharedKeyLength(R), 0)
 	if err != nil {
 		return
 	}
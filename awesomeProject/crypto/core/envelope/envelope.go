@@ -0,0 +1,301 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/ecies"
+)
+
+/*
+	Hybrid envelope encryption for multiple recipients.
+	A random data key encrypts the payload with streaming AES-GCM, and the data key is wrapped once
+	per recipient with ECIES, so one encrypted dataset can be shared with several partners.
+	多接收者的混合信封加密，随机生成的数据密钥使用流式AES-GCM加密数据，数据密钥使用每个接收者的公钥通过ECIES封装，
+	同一份加密数据可以分享给多个合作方
+	  信封格式: header || payload
+	  header:  "DTXE" || 版本(1字节) || 信封ID(16字节) || 接收者数量(2字节)
+	           || { 密钥ID长度(1字节) || 密钥ID || 封装密钥长度(2字节) || ECIES(公钥, 数据密钥) } ...
+	  payload: aes.StreamWriter(数据密钥, AD="DTXE" || 版本 || 信封ID) 输出的流密文
+	payload不绑定接收者列表，因此增加或撤销接收者只需替换header，无需重新加密数据；
+	注意撤销只是删除接收者的封装密钥，已经获得数据密钥的接收者仍能解密旧的payload，彻底撤销需要使用新的数据密钥重新加密
+*/
+
+var (
+	ErrInvalidEnvelope   = errors.New("invalid envelope header")
+	ErrRecipientNotFound = errors.New("recipient not found")
+	ErrRecipientExists   = errors.New("recipient already exists")
+)
+
+const (
+	envelopeVersion byte = 1
+	envelopeIDSize       = 16
+	dataKeySize          = 32
+	maxRecipients        = 0xffff
+)
+
+var envelopeMagic = []byte("DTXE")
+
+// Recipient a wrapped data key for one recipient 一个接收者及其封装的数据密钥
+type Recipient struct {
+	KeyID      string // recipient key ID 接收者的密钥ID
+	WrappedKey []byte // ECIES encrypted data key ECIES加密后的数据密钥
+}
+
+// Header envelope header 信封头
+type Header struct {
+	EnvelopeID []byte
+	Recipients []Recipient
+}
+
+// KeyID calculate the ID of a public key, which is the hex of the first 8 bytes of SHA-256 of the uncompressed point
+// 计算公钥的ID，为非压缩公钥SHA-256值前8字节的十六进制
+func KeyID(pubkey *ecdsa.PublicKey) string {
+	sum := sha256.Sum256(elliptic.Marshal(pubkey.Curve, pubkey.X, pubkey.Y))
+	return hex.EncodeToString(sum[:8])
+}
+
+// KeyIDs return key IDs of all recipients 返回所有接收者的密钥ID
+func (h *Header) KeyIDs() []string {
+	ids := make([]string, 0, len(h.Recipients))
+	for _, r := range h.Recipients {
+		ids = append(ids, r.KeyID)
+	}
+	return ids
+}
+
+// AddRecipient wrap the data key for a new recipient, privkey is the private key of any existing recipient
+// 为新的接收者封装数据密钥，privkey为任一已有接收者的私钥
+func (h *Header) AddRecipient(privkey *ecdsa.PrivateKey, pubkey *ecdsa.PublicKey) error {
+	if h.find(KeyID(pubkey)) >= 0 {
+		return ErrRecipientExists
+	}
+	dataKey, err := h.DataKey(privkey)
+	if err != nil {
+		return err
+	}
+	return h.addRecipient(dataKey, pubkey)
+}
+
+// RevokeRecipient remove the wrapped data key of a recipient 删除接收者的封装密钥
+func (h *Header) RevokeRecipient(keyID string) error {
+	i := h.find(keyID)
+	if i < 0 {
+		return ErrRecipientNotFound
+	}
+	if len(h.Recipients) == 1 {
+		return errors.New("cannot revoke the last recipient")
+	}
+	h.Recipients = append(h.Recipients[:i], h.Recipients[i+1:]...)
+	return nil
+}
+
+// DataKey unwrap the data key with the private key of a recipient 使用接收者私钥解封数据密钥
+func (h *Header) DataKey(privkey *ecdsa.PrivateKey) ([]byte, error) {
+	i := h.find(KeyID(&privkey.PublicKey))
+	if i < 0 {
+		return nil, ErrRecipientNotFound
+	}
+	dataKey, err := ecies.Decrypt(privkey, h.Recipients[i].WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	if len(dataKey) != dataKeySize {
+		return nil, fmt.Errorf("invalid data key length %d", len(dataKey))
+	}
+	return dataKey, nil
+}
+
+func (h *Header) addRecipient(dataKey []byte, pubkey *ecdsa.PublicKey) error {
+	if len(h.Recipients) >= maxRecipients {
+		return errors.New("too many recipients")
+	}
+	wrapped, err := ecies.Encrypt(pubkey, dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	h.Recipients = append(h.Recipients, Recipient{KeyID: KeyID(pubkey), WrappedKey: wrapped})
+	return nil
+}
+
+func (h *Header) find(keyID string) int {
+	for i, r := range h.Recipients {
+		if r.KeyID == keyID {
+			return i
+		}
+	}
+	return -1
+}
+
+// payloadAD additional data of the payload, binding it to the envelope ID 数据的附加数据，将数据与信封ID绑定
+func (h *Header) payloadAD() []byte {
+	ad := append([]byte{}, envelopeMagic...)
+	ad = append(ad, envelopeVersion)
+	return append(ad, h.EnvelopeID...)
+}
+
+// Marshal serialize the header 序列化信封头
+func (h *Header) Marshal() ([]byte, error) {
+	if len(h.EnvelopeID) != envelopeIDSize || len(h.Recipients) == 0 || len(h.Recipients) > maxRecipients {
+		return nil, ErrInvalidEnvelope
+	}
+
+	var buf bytes.Buffer
+	buf.Write(h.payloadAD())
+	binary.Write(&buf, binary.BigEndian, uint16(len(h.Recipients)))
+	for _, r := range h.Recipients {
+		if len(r.KeyID) == 0 || len(r.KeyID) > 0xff || len(r.WrappedKey) > 0xffff {
+			return nil, ErrInvalidEnvelope
+		}
+		buf.WriteByte(byte(len(r.KeyID)))
+		buf.WriteString(r.KeyID)
+		binary.Write(&buf, binary.BigEndian, uint16(len(r.WrappedKey)))
+		buf.Write(r.WrappedKey)
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadHeader read the header from the beginning of an envelope, src is left at the start of the payload
+// 从信封开头读取信封头，读取后src位于payload起始处
+func ReadHeader(src io.Reader) (*Header, error) {
+	prefix := make([]byte, len(envelopeMagic)+1+envelopeIDSize+2)
+	if _, err := io.ReadFull(src, prefix); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if !bytes.Equal(prefix[:len(envelopeMagic)], envelopeMagic) || prefix[len(envelopeMagic)] != envelopeVersion {
+		return nil, ErrInvalidEnvelope
+	}
+	h := &Header{
+		EnvelopeID: append([]byte{}, prefix[len(envelopeMagic)+1:len(envelopeMagic)+1+envelopeIDSize]...),
+	}
+	count := int(binary.BigEndian.Uint16(prefix[len(prefix)-2:]))
+	if count == 0 {
+		return nil, ErrInvalidEnvelope
+	}
+
+	for i := 0; i < count; i++ {
+		keyID, err := readField(src, 1)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := readField(src, 2)
+		if err != nil {
+			return nil, err
+		}
+		h.Recipients = append(h.Recipients, Recipient{KeyID: string(keyID), WrappedKey: wrapped})
+	}
+	return h, nil
+}
+
+// readField read a length prefixed field, lenSize is 1 or 2 bytes 读取带长度前缀的字段，长度前缀为1或2字节
+func readField(src io.Reader, lenSize int) ([]byte, error) {
+	l := make([]byte, lenSize)
+	if _, err := io.ReadFull(src, l); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	n := int(l[0])
+	if lenSize == 2 {
+		n = int(binary.BigEndian.Uint16(l))
+	}
+	field := make([]byte, n)
+	if _, err := io.ReadFull(src, field); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	return field, nil
+}
+
+// NewWriter create an envelope for recipients, the header is written to dst immediately and the payload is
+// encrypted as it is written, Close must be called to finish the envelope
+// 为接收者创建信封，立即向dst写入信封头，写入的数据被流式加密，必须调用Close完成信封
+// - chunkSize plaintext chunk size of the payload, aes.DefaultChunkSize is used if it is 0 数据的明文块大小，为0时使用默认值
+func NewWriter(dst io.Writer, recipients []*ecdsa.PublicKey, chunkSize int) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipient specified")
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	h := &Header{EnvelopeID: make([]byte, envelopeIDSize)}
+	if _, err := io.ReadFull(rand.Reader, h.EnvelopeID); err != nil {
+		return nil, err
+	}
+	for _, pubkey := range recipients {
+		if h.find(KeyID(pubkey)) >= 0 {
+			return nil, ErrRecipientExists
+		}
+		if err := h.addRecipient(dataKey, pubkey); err != nil {
+			return nil, err
+		}
+	}
+
+	header, err := h.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+	return aes.NewStreamWriter(dst, dataKey, h.payloadAD(), chunkSize)
+}
+
+// NewReader open an envelope with the private key of a recipient 使用接收者私钥打开信封
+func NewReader(src io.Reader, privkey *ecdsa.PrivateKey) (io.Reader, error) {
+	h, err := ReadHeader(src)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := h.DataKey(privkey)
+	if err != nil {
+		return nil, err
+	}
+	return aes.NewStreamReader(src, dataKey, h.payloadAD())
+}
+
+// ReplaceHeader write the new header followed by the unchanged payload of an envelope, used after
+// adding or revoking recipients, the payload is copied without re-encryption
+// 写入新的信封头和原信封中未改变的数据，用于增加或撤销接收者后更新信封，数据无需重新加密
+// - src the original envelope 原信封
+func ReplaceHeader(dst io.Writer, src io.Reader, h *Header) (int64, error) {
+	old, err := ReadHeader(src)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(old.EnvelopeID, h.EnvelopeID) {
+		return 0, errors.New("envelope ID mismatch")
+	}
+
+	header, err := h.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	n, err := dst.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := io.Copy(dst, src)
+	return int64(n) + m, err
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envelope
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func seal(t *testing.T, plaintext []byte, recipients []*ecdsa.PublicKey) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, recipients, 100)
+	require.NoError(t, err)
+	_, err = w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func open(envelope []byte, privkey *ecdsa.PrivateKey) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(envelope), privkey)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestEnvelope(t *testing.T) {
+	var privkeys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		privkey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		privkeys = append(privkeys, privkey)
+	}
+	plaintext := make([]byte, 1000)
+	_, err := io.ReadFull(rand.Reader, plaintext)
+	require.NoError(t, err)
+
+	envelope := seal(t, plaintext, []*ecdsa.PublicKey{&privkeys[0].PublicKey, &privkeys[1].PublicKey})
+
+	h, err := ReadHeader(bytes.NewReader(envelope))
+	require.NoError(t, err)
+	require.Equal(t, []string{KeyID(&privkeys[0].PublicKey), KeyID(&privkeys[1].PublicKey)}, h.KeyIDs())
+
+	for _, privkey := range privkeys[:2] {
+		plain, err := open(envelope, privkey)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, plain)
+	}
+	_, err = open(envelope, privkeys[2])
+	require.Equal(t, ErrRecipientNotFound, err)
+
+	// add a recipient without re-encrypting the payload 增加接收者，无需重新加密数据
+	require.NoError(t, h.AddRecipient(privkeys[1], &privkeys[2].PublicKey))
+	require.Equal(t, ErrRecipientExists, h.AddRecipient(privkeys[0], &privkeys[2].PublicKey))
+	require.Error(t, h.AddRecipient(privkeys[3], &privkeys[3].PublicKey))
+
+	// revoke a recipient 撤销接收者
+	require.NoError(t, h.RevokeRecipient(KeyID(&privkeys[0].PublicKey)))
+	require.Equal(t, ErrRecipientNotFound, h.RevokeRecipient(KeyID(&privkeys[3].PublicKey)))
+
+	var buf bytes.Buffer
+	_, err = ReplaceHeader(&buf, bytes.NewReader(envelope), h)
+	require.NoError(t, err)
+	updated := buf.Bytes()
+	newHeader, err := h.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, envelope[headerLen(t, envelope):], updated[len(newHeader):])
+
+	_, err = open(updated, privkeys[0])
+	require.Equal(t, ErrRecipientNotFound, err)
+	for _, privkey := range privkeys[1:3] {
+		plain, err := open(updated, privkey)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, plain)
+	}
+
+	// a header of another envelope cannot be used 不能使用其他信封的信封头
+	other := seal(t, plaintext, []*ecdsa.PublicKey{&privkeys[1].PublicKey})
+	otherHeader, err := ReadHeader(bytes.NewReader(other))
+	require.NoError(t, err)
+	_, err = ReplaceHeader(&buf, bytes.NewReader(envelope), otherHeader)
+	require.Error(t, err)
+	otherBytes, _ := otherHeader.Marshal()
+	forged := append(otherBytes, envelope[headerLen(t, envelope):]...)
+	_, err = open(forged, privkeys[1])
+	require.Error(t, err)
+
+	// a tampered payload is detected 篡改的数据可被发现
+	tampered := append([]byte{}, updated...)
+	tampered[len(tampered)-10] ^= 1
+	_, err = open(tampered, privkeys[1])
+	require.Error(t, err)
+}
+
+func headerLen(t *testing.T, envelope []byte) int {
+	h, err := ReadHeader(bytes.NewReader(envelope))
+	require.NoError(t, err)
+	header, err := h.Marshal()
+	require.NoError(t, err)
+	return len(header)
+}
@@ -15,6 +15,8 @@ package hash
 
 import (
 	"crypto/sha256"
+
+	"github.com/tjfoc/gmsm/sm3"
 )
 
 var DefaultHasher = sha256.New
@@ -32,3 +34,12 @@ func HashUsingSha256(data []byte) []byte {
 func DoubleSha256(data []byte) []byte {
 	return HashUsingSha256(HashUsingSha256(data))
 }
+
+// HashUsingSM3 使用国密SM3算法计算哈希
+func HashUsingSM3(data []byte) []byte {
+	h := sm3.New()
+	h.Write(data)
+	out := h.Sum(nil)
+
+	return out
+}
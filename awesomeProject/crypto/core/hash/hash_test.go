@@ -26,3 +26,10 @@ func TestHash(t *testing.T) {
 	hash := HashUsingSha256(msg)
 	require.Equal(t, hex.EncodeToString(hash), correctHashHex)
 }
+
+func TestHashUsingSM3(t *testing.T) {
+	// GB/T 32905-2016 附录A 示例1
+	msg := []byte("abc")
+	correctHashHex := "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"
+	require.Equal(t, correctHashHex, hex.EncodeToString(HashUsingSM3(msg)))
+}
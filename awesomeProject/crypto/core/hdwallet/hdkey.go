@@ -0,0 +1,252 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+)
+
+/*
+	SLIP-10 hierarchical deterministic key derivation, compatible with BIP-32 on secp256k1.
+	SLIP-10分层确定性密钥派生，在secp256k1上与BIP-32兼容，支持secp256k1和NIST P-256曲线
+	  主密钥:   I = HMAC-SHA512(曲线种子标识, seed)，k = IL，c = IR
+	  强化派生: I = HMAC-SHA512(c, 0x00 || k || ser32(i))，i >= 2^31
+	  普通派生: I = HMAC-SHA512(c, serP(k*G) || ser32(i))
+	  子私钥:   k_i = IL + k mod n
+	若IL >= n 或 k_i = 0，则以 0x01 || IR || ser32(i) 重新计算I
+*/
+
+var (
+	ErrInvalidSeed        = errors.New("seed length must within [16, 64] bytes")
+	ErrInvalidPath        = errors.New("invalid derivation path")
+	ErrCurveNotSupported  = errors.New("curve is not supported for hierarchical derivation")
+	ErrInvalidExtendedKey = errors.New("invalid extended key")
+)
+
+// HardenedOffset 强化派生的索引起始值
+const HardenedOffset uint32 = 0x80000000
+
+// 各类任务密钥的派生用途，任务密钥路径为 m/用途'/任务索引'
+const (
+	// KeyPurposePSI 隐私求交任务密钥
+	KeyPurposePSI uint32 = 1
+
+	// KeyPurposeOT 不经意传输任务密钥
+	KeyPurposeOT uint32 = 2
+)
+
+// curveSeeds SLIP-10 中各曲线主密钥的HMAC密钥
+var curveSeeds = map[string]string{
+	config.CurveSecp256k1: "Bitcoin seed",
+	config.CurveNist:      "Nist256p1 seed",
+}
+
+// ExtendedKey extended private key, including private key and chain code 扩展私钥，包括私钥和链码
+type ExtendedKey struct {
+	Curve     elliptic.Curve
+	Key       []byte // private key 私钥，32字节
+	ChainCode []byte // chain code 链码，32字节
+	Depth     uint8  // depth in the derivation tree 派生深度，主密钥为0
+	Index     uint32 // index of this key in its parent 在父密钥下的索引
+}
+
+// NewMasterKey generate master key from seed 从种子生成指定曲线上的主密钥
+func NewMasterKey(seed []byte, curveName string) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+	hmacKey, ok := curveSeeds[curveName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCurveNotSupported, curveName)
+	}
+	curve, err := ecc.CurveByName(curveName)
+	if err != nil {
+		return nil, err
+	}
+
+	n := curve.Params().N
+	data := seed
+	for {
+		I := hmacSHA512([]byte(hmacKey), data)
+		k := new(big.Int).SetBytes(I[:32])
+		if k.Sign() != 0 && k.Cmp(n) < 0 {
+			return &ExtendedKey{
+				Curve:     curve,
+				Key:       I[:32],
+				ChainCode: I[32:],
+			}, nil
+		}
+		data = I
+	}
+}
+
+// NewMasterKeyFromMnemonic generate master key from mnemonic 从助记词和口令生成主密钥
+func NewMasterKeyFromMnemonic(mnemonic, passphrase string, wl *WordList, curveName string) (*ExtendedKey, error) {
+	seed, err := NewSeed(mnemonic, passphrase, wl)
+	if err != nil {
+		return nil, err
+	}
+	return NewMasterKey(seed, curveName)
+}
+
+// Child derive the child key of given index 派生指定索引的子密钥，index >= HardenedOffset 时为强化派生
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if len(k.Key) != 32 || len(k.ChainCode) != 32 || k.Depth == 0xff {
+		return nil, ErrInvalidExtendedKey
+	}
+
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x00}, k.Key...)
+	} else {
+		x, y := k.Curve.ScalarBaseMult(k.Key)
+		data = elliptic.MarshalCompressed(k.Curve, x, y)
+	}
+	data = appendUint32(data, index)
+
+	n := k.Curve.Params().N
+	parent := new(big.Int).SetBytes(k.Key)
+	for {
+		I := hmacSHA512(k.ChainCode, data)
+		il := new(big.Int).SetBytes(I[:32])
+		if il.Cmp(n) < 0 {
+			il.Add(il, parent)
+			il.Mod(il, n)
+			if il.Sign() != 0 {
+				key := make([]byte, 32)
+				il.FillBytes(key)
+				return &ExtendedKey{
+					Curve:     k.Curve,
+					Key:       key,
+					ChainCode: I[32:],
+					Depth:     k.Depth + 1,
+					Index:     index,
+				}, nil
+			}
+		}
+		data = appendUint32(append([]byte{0x01}, I[32:]...), index)
+	}
+}
+
+// Derive derive the key of given path such as "m/44'/0'/0'/0/1" 按路径派生密钥，'、h或H后缀表示强化派生
+func (k *ExtendedKey) Derive(path string) (*ExtendedKey, error) {
+	indices, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := k
+	for _, index := range indices {
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// PrivateKey convert to EC private key 转换为椭圆曲线私钥
+func (k *ExtendedKey) PrivateKey() (*ecdsa.PrivateKey, error) {
+	D := new(big.Int).SetBytes(k.Key)
+	if len(k.Key) != 32 || D.Sign() == 0 || D.Cmp(k.Curve.Params().N) >= 0 {
+		return nil, ErrInvalidExtendedKey
+	}
+	x, y := k.Curve.ScalarBaseMult(k.Key)
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: k.Curve,
+			X:     x,
+			Y:     y,
+		},
+		D: D,
+	}, nil
+}
+
+// DeriveJobKey derive the private key of a job for the given purpose 派生指定用途的任务私钥，
+// 路径为 m/purpose'/jobIndex'，jobIndex 取 SHA256(jobID) 的前31比特，应在主密钥上调用
+func (k *ExtendedKey) DeriveJobKey(purpose uint32, jobID string) (*ecdsa.PrivateKey, error) {
+	if purpose >= HardenedOffset {
+		return nil, fmt.Errorf("%w: purpose %d out of range", ErrInvalidPath, purpose)
+	}
+	digest := sha256.Sum256([]byte(jobID))
+	jobIndex := binary.BigEndian.Uint32(digest[:4]) &^ HardenedOffset
+
+	purposeKey, err := k.Child(purpose + HardenedOffset)
+	if err != nil {
+		return nil, err
+	}
+	jobKey, err := purposeKey.Child(jobIndex + HardenedOffset)
+	if err != nil {
+		return nil, err
+	}
+	return jobKey.PrivateKey()
+}
+
+// DerivePSIKey 派生隐私求交任务的私钥
+func (k *ExtendedKey) DerivePSIKey(jobID string) (*ecdsa.PrivateKey, error) {
+	return k.DeriveJobKey(KeyPurposePSI, jobID)
+}
+
+// DeriveOTKey 派生不经意传输任务的私钥
+func (k *ExtendedKey) DeriveOTKey(jobID string) (*ecdsa.PrivateKey, error) {
+	return k.DeriveJobKey(KeyPurposeOT, jobID)
+}
+
+// ParsePath parse derivation path to index list 将派生路径解析为索引列表
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("%w: path must start with m", ErrInvalidPath)
+	}
+
+	var indices []uint32
+	for _, p := range parts[1:] {
+		offset := uint32(0)
+		if strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h") || strings.HasSuffix(p, "H") {
+			offset = HardenedOffset
+			p = p[:len(p)-1]
+		}
+		i, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || uint32(i) >= HardenedOffset {
+			return nil, fmt.Errorf("%w: invalid index %s", ErrInvalidPath, p)
+		}
+		indices = append(indices, uint32(i)+offset)
+	}
+	return indices, nil
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hdwallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/rand"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+)
+
+func TestWordLists(t *testing.T) {
+	// crc32 of the official word list files
+	tests := []struct {
+		wl       *WordList
+		checksum string
+	}{
+		{English, "c1dbd296"},
+		{ChineseSimplified, "e3721bbf"},
+		{ChineseTraditional, "3c20b443"},
+	}
+	for _, tt := range tests {
+		words := tt.wl.Words()
+		require.Equal(t, 2048, len(words))
+		require.Equal(t, 2048, len(tt.wl.index))
+		checksum := crc32.ChecksumIEEE([]byte(strings.Join(words, "\n") + "\n"))
+		require.Equal(t, tt.checksum, fmt.Sprintf("%x", checksum))
+	}
+}
+
+func TestMnemonic(t *testing.T) {
+	// BIP-39 test vectors, passphrase "TREZOR"
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	}
+	for _, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		mnemonic, err := NewMnemonic(entropy, English)
+		require.NoError(t, err)
+		require.Equal(t, tt.mnemonic, mnemonic)
+
+		ent, err := MnemonicToEntropy(mnemonic, English)
+		require.NoError(t, err)
+		require.Equal(t, entropy, ent)
+
+		seed, err := NewSeed(mnemonic, "TREZOR", English)
+		require.NoError(t, err)
+		require.Equal(t, tt.seed, hex.EncodeToString(seed))
+	}
+
+	// 简体中文: 全零熵对应11个索引为0的词和索引为3的词
+	mnemonic, err := NewMnemonic(make([]byte, 16), ChineseSimplified)
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("的 ", 11)+"在", mnemonic)
+
+	for _, wl := range []*WordList{English, ChineseSimplified, ChineseTraditional} {
+		for _, strength := range []int{rand.KeyStrengthEasy, rand.KeyStrengthMiddle, rand.KeyStrengthHard} {
+			mnemonic, err := GenerateMnemonic(strength, wl)
+			require.NoError(t, err)
+			require.True(t, IsMnemonicValid(mnemonic, wl))
+		}
+	}
+
+	_, err = GenerateMnemonic(3, English)
+	require.Equal(t, rand.ErrStrengthNotSupported, err)
+	_, err = NewMnemonic(make([]byte, 15), English)
+	require.Equal(t, rand.ErrInvalidEntropyLength, err)
+
+	require.False(t, IsMnemonicValid(strings.Repeat("abandon ", 12), English))
+	require.False(t, IsMnemonicValid(strings.Repeat("abandon ", 11)+"about", ChineseSimplified))
+	require.False(t, IsMnemonicValid("abandon abandon about", English))
+	_, err = MnemonicToEntropy(strings.Repeat("abandon ", 12), English)
+	require.Equal(t, ErrChecksumIncorrect, err)
+}
+
+func TestDerive(t *testing.T) {
+	// SLIP-10 test vector 1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		curve     string
+		path      string
+		chainCode string
+		key       string
+	}{
+		{config.CurveSecp256k1, "m", "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{config.CurveSecp256k1, "m/0'", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{config.CurveSecp256k1, "m/0H/1", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{config.CurveSecp256k1, "m/0'/1/2'/2/1000000000", "c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+		{config.CurveNist, "m", "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2"},
+		{config.CurveNist, "m/0'", "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11", "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c"},
+		{config.CurveNist, "m/0'/1", "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c", "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129"},
+		// IL >= n 时重新计算
+		{config.CurveNist, "m/28578'", "e94c8ebe30c2250a14713212f6449b20f3329105ea15b652ca5bdfc68f6c65c2", "06f0db126f023755d0b8d86d4591718a5210dd8d024e3e14b6159d63f53aa669"},
+		{config.CurveNist, "m/28578'/33941", "9e87fe95031f14736774cd82f25fd885065cb7c358c1edf813c72af535e83071", "092154eed4af83e078ff9b84322015aefe5769e31270f62c3f66c33888335f3a"},
+	}
+	for _, tt := range tests {
+		master, err := NewMasterKey(seed, tt.curve)
+		require.NoError(t, err)
+		key, err := master.Derive(tt.path)
+		require.NoError(t, err)
+		require.Equal(t, tt.chainCode, hex.EncodeToString(key.ChainCode), tt.path)
+		require.Equal(t, tt.key, hex.EncodeToString(key.Key), tt.path)
+	}
+
+	master, err := NewMasterKey(seed, config.CurveSecp256k1)
+	require.NoError(t, err)
+	for _, path := range []string{"", "0/1", "m/a", "m/0''", "m/2147483648", "m//1"} {
+		_, err := master.Derive(path)
+		require.Error(t, err, path)
+	}
+	_, err = NewMasterKey(seed[:8], config.CurveSecp256k1)
+	require.Equal(t, ErrInvalidSeed, err)
+	_, err = NewMasterKey(seed, config.CurveSM2)
+	require.Error(t, err)
+}
+
+func TestJobKeys(t *testing.T) {
+	mnemonic, err := GenerateMnemonic(rand.KeyStrengthHard, ChineseSimplified)
+	require.NoError(t, err)
+	master, err := NewMasterKeyFromMnemonic(mnemonic, "passphrase", ChineseSimplified, config.CurveNist)
+	require.NoError(t, err)
+
+	psiKey, err := master.DerivePSIKey("job-1")
+	require.NoError(t, err)
+	otKey, err := master.DeriveOTKey("job-1")
+	require.NoError(t, err)
+	otherKey, err := master.DerivePSIKey("job-2")
+	require.NoError(t, err)
+	require.NotEqual(t, psiKey.D, otKey.D)
+	require.NotEqual(t, psiKey.D, otherKey.D)
+	require.True(t, psiKey.Curve.IsOnCurve(psiKey.X, psiKey.Y))
+
+	// 相同助记词恢复出相同的任务密钥
+	recovered, err := NewMasterKeyFromMnemonic(mnemonic, "passphrase", ChineseSimplified, config.CurveNist)
+	require.NoError(t, err)
+	psiKey2, err := recovered.DerivePSIKey("job-1")
+	require.NoError(t, err)
+	require.True(t, psiKey.Equal(psiKey2))
+
+	// 不同口令得到不同密钥
+	other, err := NewMasterKeyFromMnemonic(mnemonic, "", ChineseSimplified, config.CurveNist)
+	require.NoError(t, err)
+	psiKey3, err := other.DerivePSIKey("job-1")
+	require.NoError(t, err)
+	require.False(t, psiKey.Equal(psiKey3))
+
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hdwallet
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/rand"
+)
+
+/*
+	BIP-39 mnemonic, the entropy ENT is appended with the first ENT/32 bits of SHA256(ENT) as checksum,
+	then split into groups of 11 bits, each group is an index of the 2048-word list.
+	BIP-39助记词，在熵ENT后追加SHA256(ENT)的前ENT/32比特作为校验和，再按11比特分组，每组对应词表中的一个词
+	种子 = PBKDF2-HMAC-SHA512(助记词, "mnemonic"+口令, 2048次迭代, 64字节)
+*/
+
+var (
+	ErrInvalidMnemonic   = errors.New("invalid mnemonic")
+	ErrChecksumIncorrect = errors.New("checksum of mnemonic is incorrect")
+)
+
+const (
+	wordBits      = 11
+	seedIterCount = 2048
+	seedLength    = 64
+)
+
+// WordList BIP-39 词表，共2048个词
+type WordList struct {
+	words []string
+	index map[string]int
+}
+
+func newWordList(s string) *WordList {
+	words := strings.Split(strings.TrimSpace(s), "\n")
+	index := make(map[string]int, len(words))
+	for i, w := range words {
+		index[w] = i
+	}
+	return &WordList{
+		words: words,
+		index: index,
+	}
+}
+
+// Words 返回词表中的所有词
+func (wl *WordList) Words() []string {
+	return append([]string(nil), wl.words...)
+}
+
+// GenerateMnemonic generate a mnemonic with the given strength 生成指定安全强度的助记词，强度取值见rand.KeyStrength*
+func GenerateMnemonic(strength int, wl *WordList) (string, error) {
+	bitSize, err := rand.EntropyBitSizeOfStrength(strength)
+	if err != nil {
+		return "", err
+	}
+	entropy, err := rand.GenerateEntropy(bitSize)
+	if err != nil {
+		return "", err
+	}
+	return NewMnemonic(entropy, wl)
+}
+
+// NewMnemonic encode entropy to mnemonic 将熵编码为助记词
+func NewMnemonic(entropy []byte, wl *WordList) (string, error) {
+	if err := rand.ValidateEntropy(entropy); err != nil {
+		return "", err
+	}
+
+	entBits := len(entropy) * 8
+	csBits := entBits / 32
+	checksum := sha256.Sum256(entropy)
+
+	// b = ENT || CS
+	b := new(big.Int).SetBytes(entropy)
+	b.Lsh(b, uint(csBits))
+	b.Or(b, big.NewInt(int64(checksum[0]>>(8-csBits))))
+
+	wordNum := (entBits + csBits) / wordBits
+	words := make([]string, wordNum)
+	mask := big.NewInt(1<<wordBits - 1)
+	idx := new(big.Int)
+	for i := wordNum - 1; i >= 0; i-- {
+		idx.And(b, mask)
+		words[i] = wl.words[idx.Int64()]
+		b.Rsh(b, wordBits)
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decode mnemonic to entropy and verify its checksum 将助记词解码为熵，并校验校验和
+func MnemonicToEntropy(mnemonic string, wl *WordList) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	wordNum := len(words)
+	if wordNum%3 != 0 || wordNum < 12 || wordNum > 24 {
+		return nil, fmt.Errorf("%w: invalid word number %d", ErrInvalidMnemonic, wordNum)
+	}
+
+	b := new(big.Int)
+	for _, w := range words {
+		i, ok := wl.index[w]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %s", ErrInvalidMnemonic, w)
+		}
+		b.Lsh(b, wordBits)
+		b.Or(b, big.NewInt(int64(i)))
+	}
+
+	csBits := wordNum * wordBits / 33
+	entBits := csBits * 32
+	cs := new(big.Int).And(b, big.NewInt(int64(1<<uint(csBits)-1)))
+	b.Rsh(b, uint(csBits))
+
+	entropy := make([]byte, entBits/8)
+	b.FillBytes(entropy)
+
+	checksum := sha256.Sum256(entropy)
+	if int64(checksum[0]>>(8-csBits)) != cs.Int64() {
+		return nil, ErrChecksumIncorrect
+	}
+
+	return entropy, nil
+}
+
+// IsMnemonicValid check whether the mnemonic is valid 检查助记词是否合法
+func IsMnemonicValid(mnemonic string, wl *WordList) bool {
+	_, err := MnemonicToEntropy(mnemonic, wl)
+	return err == nil
+}
+
+// NewSeed derive a 64-byte seed from mnemonic and passphrase 从助记词和口令派生64字节种子，
+// 助记词需先通过校验，口令应为NFKD规范化后的字符串
+func NewSeed(mnemonic, passphrase string, wl *WordList) ([]byte, error) {
+	if _, err := MnemonicToEntropy(mnemonic, wl); err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), seedIterCount, seedLength, sha512.New), nil
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+)
+
+/*
+	Encrypted key envelope. 加密的密钥信封
+	私钥用AES-256-GCM加密，AES密钥由口令经scrypt派生；密钥类型、曲线、创建时间和公钥指纹以明文保存以便列举，
+	同时作为GCM的附加数据参与认证，篡改任何一项都会导致解密失败
+*/
+
+const (
+	envelopeVersion = 1
+
+	cipherAESGCM = "aes-256-gcm"
+	kdfScrypt    = "scrypt"
+
+	idLength     = 16
+	saltLength   = 32
+	nonceLength  = 12
+	aesKeyLength = 32
+
+	// scrypt参数上限，信封可能来自不可信的导入，限制派生一次的内存(128*N*r字节，不超过1GB)和计算量
+	maxScryptN      = 1 << 20
+	maxScryptR      = 32
+	maxScryptP      = 16
+	maxScryptMemory = 1 << 30
+)
+
+var (
+	ErrDecrypt          = errors.New("could not decrypt key with given passphrase")
+	ErrUnsupportedKDF   = errors.New("unsupported key derivation function")
+	ErrInvalidEnvelope  = errors.New("invalid key envelope")
+	ErrInvalidKDFParams = errors.New("invalid key derivation parameters")
+)
+
+// ScryptParams scrypt参数，N为CPU/内存开销，须为2的幂
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+var (
+	// StandardScryptParams 默认参数，派生一次约需256MB内存
+	StandardScryptParams = ScryptParams{N: 1 << 18, R: 8, P: 1}
+	// LightScryptParams 轻量参数，派生一次约需4MB内存，适用于测试和资源受限的环境
+	LightScryptParams = ScryptParams{N: 1 << 12, R: 8, P: 6}
+)
+
+// validate 检查scrypt参数：N为不小于2的2的幂，且各参数及派生所需内存不超过上限
+func (p ScryptParams) validate() error {
+	if p.N < 2 || p.N > maxScryptN || p.N&(p.N-1) != 0 ||
+		p.R < 1 || p.R > maxScryptR || p.P < 1 || p.P > maxScryptP ||
+		128*p.N*p.R > maxScryptMemory {
+		return fmt.Errorf("%w: n=%d, r=%d, p=%d", ErrInvalidKDFParams, p.N, p.R, p.P)
+	}
+	return nil
+}
+
+// validateID 检查密钥ID为32个小写十六进制字符，ID会作为文件名的一部分
+func validateID(id string) error {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != idLength || hex.EncodeToString(b) != id {
+		return fmt.Errorf("%w: invalid id %q", ErrInvalidEnvelope, id)
+	}
+	return nil
+}
+
+// Envelope 密钥信封，以JSON格式保存
+type Envelope struct {
+	Version     int        `json:"version"`
+	ID          string     `json:"id"`
+	Type        string     `json:"type"`
+	Curve       string     `json:"curve,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Fingerprint string     `json:"fingerprint"`
+	Public      string     `json:"public"`
+	Crypto      CryptoJSON `json:"crypto"`
+}
+
+// CryptoJSON 加密参数和密文
+type CryptoJSON struct {
+	Cipher     string       `json:"cipher"`
+	CipherText string       `json:"ciphertext"`
+	Nonce      string       `json:"nonce"`
+	KDF        string       `json:"kdf"`
+	KDFParams  ScryptParams `json:"kdfparams"`
+	Salt       string       `json:"salt"`
+}
+
+// EncryptKey 使用口令加密密钥，生成密钥信封
+func EncryptKey(key *Key, passphrase []byte, params ScryptParams) (*Envelope, error) {
+	if err := key.check(); err != nil {
+		return nil, err
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, idLength)
+	salt := make([]byte, saltLength)
+	nonce := make([]byte, nonceLength)
+	for _, b := range [][]byte{id, salt, nonce} {
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return nil, err
+		}
+	}
+
+	derivedKey, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, aesKeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{
+		Version:     envelopeVersion,
+		ID:          hex.EncodeToString(id),
+		Type:        key.Type,
+		Curve:       key.Curve,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		Fingerprint: key.Fingerprint(),
+		Public:      hex.EncodeToString(key.Public),
+		Crypto: CryptoJSON{
+			Cipher:    cipherAESGCM,
+			Nonce:     hex.EncodeToString(nonce),
+			KDF:       kdfScrypt,
+			KDFParams: params,
+			Salt:      hex.EncodeToString(salt),
+		},
+	}
+
+	cipherText, err := aes.EncryptUsingAESGCM(aes.AESKey{
+		Key:   derivedKey,
+		Nonce: nonce,
+		AD:    env.additionalData(),
+	}, key.Secret, nil)
+	if err != nil {
+		return nil, err
+	}
+	env.Crypto.CipherText = hex.EncodeToString(cipherText)
+
+	return env, nil
+}
+
+// DecryptKey 使用口令解密密钥信封
+func DecryptKey(env *Envelope, passphrase []byte) (*Key, error) {
+	if env.Version != envelopeVersion || env.Crypto.Cipher != cipherAESGCM {
+		return nil, ErrInvalidEnvelope
+	}
+	if env.Crypto.KDF != kdfScrypt {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKDF, env.Crypto.KDF)
+	}
+
+	salt, err := hex.DecodeString(env.Crypto.Salt)
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+	nonce, err := hex.DecodeString(env.Crypto.Nonce)
+	if err != nil || len(nonce) != nonceLength {
+		return nil, ErrInvalidEnvelope
+	}
+	cipherText, err := hex.DecodeString(env.Crypto.CipherText)
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+	public, err := hex.DecodeString(env.Public)
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+
+	params := env.Crypto.KDFParams
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	derivedKey, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, aesKeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := aes.DecryptUsingAESGCM(aes.AESKey{
+		Key:   derivedKey,
+		Nonce: nonce,
+		AD:    env.additionalData(),
+	}, cipherText, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	key := &Key{
+		Type:   env.Type,
+		Curve:  env.Curve,
+		Secret: secret,
+		Public: public,
+	}
+	if err := key.check(); err != nil {
+		return nil, err
+	}
+	if key.Fingerprint() != env.Fingerprint {
+		return nil, ErrInvalidEnvelope
+	}
+	return key, nil
+}
+
+// additionalData 明文元数据的摘要，作为GCM的附加数据
+func (env *Envelope) additionalData() []byte {
+	h := sha256.New()
+	for _, field := range []string{
+		fmt.Sprint(env.Version), env.ID, env.Type, env.Curve,
+		env.CreatedAt.UTC().Format(time.RFC3339), env.Fingerprint, env.Public,
+	} {
+		fmt.Fprintf(h, "%d:%s|", len(field), field)
+	}
+	return h.Sum(nil)
+}
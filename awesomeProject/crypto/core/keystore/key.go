@@ -0,0 +1,176 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+	dtxecdsa "github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/pairing"
+)
+
+// 密钥类型
+const (
+	KeyTypeECDSA    = "ecdsa"
+	KeyTypePaillier = "paillier"
+	KeyTypePairing  = "pairing"
+
+	// CurveBLS12381 pdp/pairing使用的曲线
+	CurveBLS12381 = "BLS12-381"
+)
+
+var (
+	ErrUnknownKeyType  = errors.New("unknown key type")
+	ErrKeyTypeMismatch = errors.New("key type mismatch")
+)
+
+// Key 待保存的明文密钥，Secret和Public为各类型密钥的序列化结果
+type Key struct {
+	Type   string
+	Curve  string
+	Secret []byte
+	Public []byte
+}
+
+// paillierSecret Paillier私钥的序列化格式
+type paillierSecret struct {
+	N      *big.Int `json:"n"`
+	G      *big.Int `json:"g"`
+	Lambda *big.Int `json:"lambda"`
+	Mu     *big.Int `json:"mu"`
+}
+
+// NewECDSAKey 由ecdsa私钥构造密钥，曲线须已在ecc曲线注册表中注册，
+// core/ecdsa的定长私钥可先通过ecdsa.ParsePrivateKey转换
+func NewECDSAKey(privkey *ecdsa.PrivateKey) (*Key, error) {
+	secret, err := dtxecdsa.MarshalTaggedPrivateKey(privkey)
+	if err != nil {
+		return nil, err
+	}
+	public, err := dtxecdsa.MarshalTaggedPublicKey(&privkey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		Type:   KeyTypeECDSA,
+		Curve:  privkey.Curve.Params().Name,
+		Secret: secret,
+		Public: public,
+	}, nil
+}
+
+// NewPaillierKey 由Paillier私钥构造密钥
+func NewPaillierKey(privkey *paillier.PrivateKey) (*Key, error) {
+	secret, err := json.Marshal(&paillierSecret{
+		N:      privkey.N,
+		G:      privkey.G,
+		Lambda: privkey.Lambda,
+		Mu:     privkey.Mu,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		Type:   KeyTypePaillier,
+		Secret: secret,
+		Public: privkey.N.Bytes(),
+	}, nil
+}
+
+// NewPairingKey 由pdp/pairing的密钥对构造密钥
+func NewPairingKey(privkey *pairing.PrivateKey, pubkey *pairing.PublicKey) *Key {
+	return &Key{
+		Type:   KeyTypePairing,
+		Curve:  CurveBLS12381,
+		Secret: pairing.PrivateKeyToByte(privkey),
+		Public: pairing.PublicKeyToByte(pubkey),
+	}
+}
+
+// ECDSAPrivateKey 还原ecdsa私钥
+func (k *Key) ECDSAPrivateKey() (*ecdsa.PrivateKey, error) {
+	if k.Type != KeyTypeECDSA {
+		return nil, ErrKeyTypeMismatch
+	}
+	return dtxecdsa.ParseTaggedPrivateKey(k.Secret)
+}
+
+// PaillierPrivateKey 还原Paillier私钥
+func (k *Key) PaillierPrivateKey() (*paillier.PrivateKey, error) {
+	if k.Type != KeyTypePaillier {
+		return nil, ErrKeyTypeMismatch
+	}
+
+	var s paillierSecret
+	if err := json.Unmarshal(k.Secret, &s); err != nil {
+		return nil, err
+	}
+	if s.N == nil || s.G == nil || s.Lambda == nil || s.Mu == nil {
+		return nil, fmt.Errorf("incomplete paillier private key")
+	}
+
+	return &paillier.PrivateKey{
+		PublicKey: paillier.PublicKey{
+			N: s.N,
+			G: s.G,
+		},
+		Lambda: s.Lambda,
+		Mu:     s.Mu,
+	}, nil
+}
+
+// PairingKeyPair 还原pdp/pairing的密钥对
+func (k *Key) PairingKeyPair() (*pairing.PrivateKey, *pairing.PublicKey, error) {
+	if k.Type != KeyTypePairing {
+		return nil, nil, ErrKeyTypeMismatch
+	}
+
+	pubkey, err := pairing.PublicKeyFromByte(k.Public)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pairing.PrivateKeyFromByte(k.Secret), pubkey, nil
+}
+
+// Fingerprint 公钥指纹，为公钥序列化结果的SHA-256前16字节
+func (k *Key) Fingerprint() string {
+	h := sha256.Sum256(append([]byte(k.Type+"|"), k.Public...))
+	return hex.EncodeToString(h[:16])
+}
+
+// check 检查密钥类型和内容
+func (k *Key) check() error {
+	switch k.Type {
+	case KeyTypeECDSA:
+		_, err := k.ECDSAPrivateKey()
+		return err
+	case KeyTypePaillier:
+		_, err := k.PaillierPrivateKey()
+		return err
+	case KeyTypePairing:
+		_, _, err := k.PairingKeyPair()
+		return err
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownKeyType, k.Type)
+	}
+}
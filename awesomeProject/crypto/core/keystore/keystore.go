@@ -0,0 +1,216 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 基于目录的密钥库，每个密钥信封保存为一个JSON文件，文件名为 <创建时间>--<ID>.json，权限为0600
+
+const (
+	dirPerm  = 0700
+	filePerm = 0600
+	fileExt  = ".json"
+)
+
+var ErrKeyNotFound = errors.New("key not found in keystore")
+
+// KeyInfo 密钥库中密钥的元数据，不需要口令即可列举
+type KeyInfo struct {
+	ID          string
+	Type        string
+	Curve       string
+	CreatedAt   time.Time
+	Fingerprint string
+	Path        string
+}
+
+// Keystore 密钥库
+type Keystore struct {
+	dir    string
+	params ScryptParams
+}
+
+// NewKeystore 打开或创建目录dir作为密钥库，新导入的密钥使用params派生加密密钥
+func NewKeystore(dir string, params ScryptParams) (*Keystore, error) {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, err
+	}
+	return &Keystore{dir: dir, params: params}, nil
+}
+
+// Import 使用口令加密密钥并保存到密钥库
+func (ks *Keystore) Import(key *Key, passphrase []byte) (*KeyInfo, error) {
+	env, err := EncryptKey(key, passphrase, ks.params)
+	if err != nil {
+		return nil, err
+	}
+	return ks.write(env)
+}
+
+// ImportEnvelope 保存从其它密钥库导出的密钥信封，不需要口令
+func (ks *Keystore) ImportEnvelope(data []byte) (*KeyInfo, error) {
+	env := new(Envelope)
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if env.Version != envelopeVersion {
+		return nil, ErrInvalidEnvelope
+	}
+	// ID来自不可信的输入且会作为文件名的一部分，KDF参数会在解密时直接用于scrypt
+	if err := validateID(env.ID); err != nil {
+		return nil, err
+	}
+	if err := env.Crypto.KDFParams.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := ks.find(env.ID); err == nil {
+		return nil, fmt.Errorf("key %s already exists", env.ID)
+	}
+	return ks.write(env)
+}
+
+// Export 读取并解密密钥，id可以是密钥ID或公钥指纹
+func (ks *Keystore) Export(id string, passphrase []byte) (*Key, error) {
+	env, err := ks.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptKey(env, passphrase)
+}
+
+// ExportEnvelope 导出加密的密钥信封，用于迁移到其它密钥库
+func (ks *Keystore) ExportEnvelope(id string) ([]byte, error) {
+	env, err := ks.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// List 列举密钥库中的所有密钥，按创建时间排序，无法解析的文件会被跳过
+func (ks *Keystore) List() ([]*KeyInfo, error) {
+	envs, paths, err := ks.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*KeyInfo, len(envs))
+	for i, env := range envs {
+		infos[i] = keyInfo(env, paths[i])
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].CreatedAt.Equal(infos[j].CreatedAt) {
+			return infos[i].ID < infos[j].ID
+		}
+		return infos[i].CreatedAt.Before(infos[j].CreatedAt)
+	})
+	return infos, nil
+}
+
+// write 原子地写入密钥信封：先写临时文件再重命名
+func (ks *Keystore) write(env *Envelope) (*KeyInfo, error) {
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s--%s%s", env.CreatedAt.UTC().Format("2006-01-02T15-04-05Z"), env.ID, fileExt)
+	path := filepath.Join(ks.dir, name)
+
+	tmp, err := ioutil.TempFile(ks.dir, "."+name+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(filePerm); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+
+	return keyInfo(env, path), nil
+}
+
+// find 根据密钥ID或公钥指纹查找密钥信封
+func (ks *Keystore) find(id string) (*Envelope, error) {
+	envs, _, err := ks.readAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		if env.ID == id || env.Fingerprint == id {
+			return env, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+}
+
+// readAll 读取目录下所有的密钥信封
+func (ks *Keystore) readAll() ([]*Envelope, []string, error) {
+	files, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var envs []*Envelope
+	var paths []string
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") || !strings.HasSuffix(f.Name(), fileExt) {
+			continue
+		}
+		path := filepath.Join(ks.dir, f.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		env := new(Envelope)
+		if err := json.Unmarshal(data, env); err != nil || env.ID == "" {
+			continue
+		}
+		envs = append(envs, env)
+		paths = append(paths, path)
+	}
+	return envs, paths, nil
+}
+
+func keyInfo(env *Envelope, path string) *KeyInfo {
+	return &KeyInfo{
+		ID:          env.ID,
+		Type:        env.Type,
+		Curve:       env.Curve,
+		CreatedAt:   env.CreatedAt,
+		Fingerprint: env.Fingerprint,
+		Path:        path,
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/pairing"
+)
+
+func TestKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ks, err := NewKeystore(dir, LightScryptParams)
+	require.NoError(t, err)
+	passphrase := []byte("correct horse battery staple")
+
+	// ecdsa
+	curve, err := ecc.CurveByName(config.CurveSecp256k1)
+	require.NoError(t, err)
+	ecPrivkey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := NewECDSAKey(ecPrivkey)
+	require.NoError(t, err)
+	ecInfo, err := ks.Import(ecKey, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, config.CurveSecp256k1, ecInfo.Curve)
+
+	stat, err := os.Stat(ecInfo.Path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(filePerm), stat.Mode().Perm())
+
+	// paillier
+	paillierPrivkey, err := paillier.GeneratePrivateKey(256)
+	require.NoError(t, err)
+	paillierKey, err := NewPaillierKey(paillierPrivkey)
+	require.NoError(t, err)
+	paillierInfo, err := ks.Import(paillierKey, passphrase)
+	require.NoError(t, err)
+
+	// pairing
+	pairingPrivkey, pairingPubkey, err := pairing.GenKeyPair()
+	require.NoError(t, err)
+	pairingInfo, err := ks.Import(NewPairingKey(pairingPrivkey, pairingPubkey), passphrase)
+	require.NoError(t, err)
+	require.Equal(t, CurveBLS12381, pairingInfo.Curve)
+
+	infos, err := ks.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 3)
+	types := map[string]bool{}
+	for _, info := range infos {
+		types[info.Type] = true
+	}
+	require.Equal(t, map[string]bool{KeyTypeECDSA: true, KeyTypePaillier: true, KeyTypePairing: true}, types)
+
+	// 按ID和指纹导出
+	key, err := ks.Export(ecInfo.ID, passphrase)
+	require.NoError(t, err)
+	gotEC, err := key.ECDSAPrivateKey()
+	require.NoError(t, err)
+	require.Equal(t, 0, ecPrivkey.D.Cmp(gotEC.D))
+	_, err = key.PaillierPrivateKey()
+	require.Equal(t, ErrKeyTypeMismatch, err)
+
+	key, err = ks.Export(paillierInfo.Fingerprint, passphrase)
+	require.NoError(t, err)
+	gotPaillier, err := key.PaillierPrivateKey()
+	require.NoError(t, err)
+	c, err := paillierPrivkey.PublicKey.Encrypt(big.NewInt(42))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(42), gotPaillier.Decrypt(c))
+
+	key, err = ks.Export(pairingInfo.ID, passphrase)
+	require.NoError(t, err)
+	gotPairingPriv, gotPairingPub, err := key.PairingKeyPair()
+	require.NoError(t, err)
+	require.Equal(t, 0, pairingPrivkey.X.Cmp(gotPairingPriv.X))
+	require.True(t, pairingPubkey.P.Equal(gotPairingPub.P))
+
+	// 错误口令和不存在的密钥
+	_, err = ks.Export(ecInfo.ID, []byte("wrong"))
+	require.Equal(t, ErrDecrypt, err)
+	_, err = ks.Export("unknown", passphrase)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// 篡改明文元数据导致解密失败
+	data, err := ioutil.ReadFile(ecInfo.Path)
+	require.NoError(t, err)
+	env := new(Envelope)
+	require.NoError(t, json.Unmarshal(data, env))
+	env.Curve = config.CurveNist
+	_, err = DecryptKey(env, passphrase)
+	require.Equal(t, ErrDecrypt, err)
+
+	// 导出信封并导入到另一个密钥库
+	otherDir := filepath.Join(dir, "other")
+	other, err := NewKeystore(otherDir, LightScryptParams)
+	require.NoError(t, err)
+	envData, err := ks.ExportEnvelope(ecInfo.ID)
+	require.NoError(t, err)
+	_, err = other.ImportEnvelope(envData)
+	require.NoError(t, err)
+	_, err = other.ImportEnvelope(envData)
+	require.Error(t, err)
+	key, err = other.Export(ecInfo.ID, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, ecKey.Secret, key.Secret)
+
+	// 导入的信封ID必须为32个十六进制字符，KDF参数必须在上限之内
+	for _, id := range []string{"", "../../escape", "a/b", ecInfo.ID[:30], ecInfo.ID + "00", "ZZ" + ecInfo.ID[2:]} {
+		forged := *env
+		forged.ID = id
+		data, err := json.Marshal(&forged)
+		require.NoError(t, err)
+		_, err = other.ImportEnvelope(data)
+		require.ErrorIs(t, err, ErrInvalidEnvelope)
+	}
+	for _, params := range []ScryptParams{{N: 1 << 30, R: 8, P: 1}, {N: 1000, R: 8, P: 1}, {N: 1 << 20, R: 16, P: 1}, {N: 1 << 10, R: 8, P: 0}} {
+		forged := *env
+		forged.ID = "00112233445566778899aabbccddeeff"
+		forged.Crypto.KDFParams = params
+		data, err := json.Marshal(&forged)
+		require.NoError(t, err)
+		_, err = other.ImportEnvelope(data)
+		require.ErrorIs(t, err, ErrInvalidKDFParams)
+		_, err = DecryptKey(&forged, passphrase)
+		require.ErrorIs(t, err, ErrInvalidKDFParams)
+	}
+}
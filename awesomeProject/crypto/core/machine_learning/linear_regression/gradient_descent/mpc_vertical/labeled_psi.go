@@ -0,0 +1,223 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpc_vertical
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+// 带标签的加密样本对齐(Labeled PSI) - 在PSI的基础上，接收方额外获得交集内样本在发送方处关联的数据(标签)
+// 标签可以是一行特征数据，也可以是一段JSON，接收方只能解密自己也持有的样本ID对应的标签
+//
+// 标签密钥由发送方单独的标签私钥Prv'L派生，与PSI使用的私钥不同，因此PSI过程中交换的集合无法用于解密标签；
+// 样本ID通过try-and-increment映射为曲线上的点HP(ID)，任何人都不知道HP(ID)相对于G或其它点的离散对数，
+// 不能像Hash(ID)*G那样由一个元素反推出标签公钥，再对任意ID计算标签密钥；
+// 接收方通过盲化/去盲化获得己方样本ID在标签私钥下的值，发送方从不发送己方样本ID在标签私钥下的值本身
+//
+// 带标签的加密样本对齐的步骤(2方，Alice为发送方，Bob为接收方)：
+// Step 1: Alice生成标签私钥Prv'L，对自己的每个样本ID计算 Pub'Ai-L=HP(ID-Ai)^Prv'L，
+//			派生出标记和对称密钥：Tag(i)=Hash("tag"||Pub'Ai-L), Key(i)=Hash("key"||Pub'Ai-L)
+//			使用AES-GCM和Key(i)加密标签Label(i)，得到密文集合{Tag(i): Enc(Key(i), Label(i))}，发送给Bob
+// Step 2: Bob选取随机数r，对自己的样本ID盲化：Pub'Bi-r=HP(ID-Bi)^r，发送给Alice
+// Step 3: Alice检查每个点都在曲线上，使用标签私钥二次加密：Pub'Bi-r-L=HP(ID-Bi)^r^Prv'L，发送给Bob
+// Step 4: Bob去盲化：Pub'Bi-L=Pub'Bi-r-L^(1/r)=HP(ID-Bi)^Prv'L，派生出标记和对称密钥，
+//			若标记存在于密文集合中，说明该ID在交集中，使用对应的对称密钥解密得到Alice的标签
+//
+// 在离散对数相关假设下，Bob只能获得自己提交的样本ID在标签私钥下的值，无法计算交集以外ID的HP(ID)^Prv'L，也就无法解密对应的标签
+
+// domain separation prefixes for the values derived from an ID point under the label key
+var (
+	labelTagPrefix   = []byte("paddledtx/labeled-psi/tag")
+	labelKeyPrefix   = []byte("paddledtx/labeled-psi/key")
+	labelNoncePrefix = []byte("paddledtx/labeled-psi/nonce")
+	labelPointPrefix = []byte("paddledtx/labeled-psi/point")
+)
+
+var (
+	ErrInvalidLabelNumber = errors.New("the number of labels does not match the sample ID set")
+	ErrInvalidIDPoint     = errors.New("invalid point in the sample ID set")
+)
+
+// LabeledEncSet 加密的样本标签集合，key为样本ID在标签私钥下派生出的标记，value为加密的标签
+type LabeledEncSet struct {
+	EncLabels map[string][]byte
+}
+
+// EncryptSampleLabels 发送方使用标签私钥加密每个样本关联的标签
+// - sampleID 发送方的原始ID列表
+// - labels 样本标签列表，与原始ID列表顺序一致
+// - labelKey 标签私钥，必须与PSI使用的私钥不同，且只用于一次带标签的样本对齐
+func EncryptSampleLabels(sampleID []string, labels [][]byte, labelKey *ecdsa.PrivateKey) (*LabeledEncSet, error) {
+	if len(sampleID) != len(labels) {
+		return nil, ErrInvalidLabelNumber
+	}
+
+	// HP(ID-Ai)^Prv'L 只用于派生标记和密钥，不会发送给接收方
+	labelSet, err := hashSampleIDSet(sampleID, labelKey.Curve, labelKey.D)
+	if err != nil {
+		return nil, err
+	}
+	encLabels := make(map[string][]byte, len(labels))
+	for id, value := range labelSet.EncIDs {
+		tag, aesKey := deriveLabelKey([]byte(id))
+		cipher, err := aes.EncryptUsingAESGCM(aesKey, labels[value], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt label of sample %d: %v", value, err)
+		}
+		encLabels[string(tag)] = cipher
+	}
+
+	labeledEncSet := &LabeledEncSet{
+		EncLabels: encLabels,
+	}
+
+	return labeledEncSet, nil
+}
+
+// BlindSampleIDSet 接收方使用随机数r盲化己方的样本ID集合，Pub'Bi-r=HP(ID-Bi)^r
+// 返回盲化后的集合和用于去盲化的随机数r，盲化后的集合发送给发送方
+func BlindSampleIDSet(sampleID []string, curve elliptic.Curve) (*EncSet, *big.Int, error) {
+	r, err := rand.Int(rand.Reader, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	if err != nil {
+		return nil, nil, err
+	}
+	r.Add(r, big.NewInt(1))
+
+	blindedSet, err := hashSampleIDSet(sampleID, curve, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blindedSet, r, nil
+}
+
+// EvaluateBlindedIDSet 发送方使用标签私钥对接收方盲化后的集合二次加密，Pub'Bi-r-L=Pub'Bi-r^Prv'L
+// 集合中每个元素对应的样本序号保持不变，结果发送给接收方；集合来自其它方，不在曲线上的点会被拒绝
+func EvaluateBlindedIDSet(blindedSet *EncSet, labelKey *ecdsa.PrivateKey) (*EncSet, error) {
+	return multiplyIDSet(blindedSet, labelKey.Curve, labelKey.D)
+}
+
+// UnblindIDSet 接收方去盲化，Pub'Bi-L=Pub'Bi-r-L^(1/r)=HP(ID-Bi)^Prv'L
+// - evaluatedSet 发送方使用标签私钥二次加密后的集合
+// - r 盲化时使用的随机数
+func UnblindIDSet(evaluatedSet *EncSet, r *big.Int, curve elliptic.Curve) (*EncSet, error) {
+	rInv := new(big.Int).ModInverse(r, curve.Params().N)
+	if rInv == nil {
+		return nil, errors.New("invalid blinding factor")
+	}
+	return multiplyIDSet(evaluatedSet, curve, rInv)
+}
+
+// hashSampleIDSet 将每个样本ID映射为曲线上的点并乘以k，即HP(ID)^k
+func hashSampleIDSet(sampleID []string, curve elliptic.Curve, k *big.Int) (*EncSet, error) {
+	encIDs := make(map[string]int, len(sampleID))
+	for i, id := range sampleID {
+		x, y, err := hashToPoint(curve, id)
+		if err != nil {
+			return nil, err
+		}
+		newX, newY := curve.ScalarMult(x, y, k.Bytes())
+		encIDs[string(elliptic.Marshal(curve, newX, newY))] = i
+	}
+	return &EncSet{EncIDs: encIDs}, nil
+}
+
+// multiplyIDSet 将集合中的每个点乘以k，集合中的点必须能解析且在曲线上
+func multiplyIDSet(encSet *EncSet, curve elliptic.Curve, k *big.Int) (*EncSet, error) {
+	encIDs := make(map[string]int, len(encSet.EncIDs))
+	for idstr, value := range encSet.EncIDs {
+		x, y := elliptic.Unmarshal(curve, []byte(idstr))
+		if x == nil || !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("%w: sample %d", ErrInvalidIDPoint, value)
+		}
+		newX, newY := curve.ScalarMult(x, y, k.Bytes())
+		encIDs[string(elliptic.Marshal(curve, newX, newY))] = value
+	}
+	return &EncSet{EncIDs: encIDs}, nil
+}
+
+// hashToPoint 使用try-and-increment将样本ID映射为曲线上的点，结果的离散对数未知
+// x = Hash(prefix||ID||counter) mod p，取第一个在曲线上的x，y取偶数
+func hashToPoint(curve elliptic.Curve, id string) (*big.Int, *big.Int, error) {
+	params := curve.Params()
+	for counter := 0; counter < 256; counter++ {
+		data := append(append([]byte{}, labelPointPrefix...), []byte(id)...)
+		data = append(data, 0, byte(counter))
+		x := new(big.Int).SetBytes(hash.HashUsingSha256(data))
+		x.Mod(x, params.P)
+
+		y, err := ecc.DecompressY(curve, x, false)
+		if err != nil {
+			continue
+		}
+		if curve.IsOnCurve(x, y) {
+			return x, y, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("failed to hash sample ID to curve %s", params.Name)
+}
+
+// DecryptSampleLabels 接收方利用去盲化后的己方样本ID集合解密交集内样本对应的标签
+// 返回值的key为样本ID，value为发送方关联的标签，不在交集中的样本ID不会出现在结果中
+// - sampleID 原始ID列表
+// - unblindedSet 去盲化后的己方样本ID集合，即Pub'Bi-L
+// - labeledEncSet 发送方加密的标签集合
+func DecryptSampleLabels(sampleID []string, unblindedSet *EncSet, labeledEncSet *LabeledEncSet) (map[string][]byte, error) {
+	labels := make(map[string][]byte)
+
+	for id, value := range unblindedSet.EncIDs {
+		if value < 0 || value >= len(sampleID) {
+			return nil, ErrInvalidLabelNumber
+		}
+		tag, aesKey := deriveLabelKey([]byte(id))
+
+		// 标记不存在，说明样本ID不在交集中
+		cipher, isExist := labeledEncSet.EncLabels[string(tag)]
+		if !isExist {
+			continue
+		}
+
+		label, err := aes.DecryptUsingAESGCM(aesKey, cipher, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt label of sample %s: %v", sampleID[value], err)
+		}
+		labels[sampleID[value]] = label
+	}
+
+	return labels, nil
+}
+
+// deriveLabelKey 从样本ID在标签私钥下的值派生出标记和AES-GCM密钥
+// 每个密钥只加密一条标签，因此nonce同样可以由该值确定性地派生
+func deriveLabelKey(labelID []byte) ([]byte, aes.AESKey) {
+	tag := hash.HashUsingSha256(append(append([]byte{}, labelTagPrefix...), labelID...))
+	key := hash.HashUsingSha256(append(append([]byte{}, labelKeyPrefix...), labelID...))
+	nonce := hash.HashUsingSha256(append(append([]byte{}, labelNoncePrefix...), labelID...))
+
+	aesKey := aes.AESKey{
+		Key:   key,
+		Nonce: nonce[:12],
+		AD:    tag,
+	}
+
+	return tag, aesKey
+}
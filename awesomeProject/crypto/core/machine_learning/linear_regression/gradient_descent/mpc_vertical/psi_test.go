@@ -18,7 +18,14 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/json"
+	"math/big"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
 )
 
 func TestPSI(t *testing.T) {
@@ -103,3 +110,113 @@ func TestPSI(t *testing.T) {
 	}
 	t.Logf("intersection of A、B、C is %s", jsonIntersection)
 }
+
+func TestLabeledPSI(t *testing.T) {
+	sampleIDsA := []string{"10000", "10001", "10002", "10003"}
+	labelsA := [][]byte{
+		[]byte(`{"age":18,"income":1000}`),
+		[]byte(`{"age":25,"income":2500}`),
+		[]byte(`{"age":32,"income":4000}`),
+		[]byte(`{"age":47,"income":8000}`),
+	}
+	sampleIDsB := []string{"10001", "10003", "10005"}
+
+	privateKeyA, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("privateKeyA generation failed: %v", err)
+	}
+	privateKeyB, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("privateKeyB generation failed: %v", err)
+	}
+
+	labelKeyA, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("labelKeyA generation failed: %v", err)
+	}
+
+	// A为发送方，使用标签私钥加密自己的标签
+	labeledEncSet, err := EncryptSampleLabels(sampleIDsA, labelsA, labelKeyA)
+	if err != nil {
+		t.Fatalf("EncryptSampleLabels failed: %v", err)
+	}
+
+	// B盲化自己的ID，A使用标签私钥二次加密，B去盲化
+	blindedSetB, r, err := BlindSampleIDSet(sampleIDsB, elliptic.P256())
+	if err != nil {
+		t.Fatalf("BlindSampleIDSet failed: %v", err)
+	}
+	evaluatedSetB, err := EvaluateBlindedIDSet(blindedSetB, labelKeyA)
+	if err != nil {
+		t.Fatalf("EvaluateBlindedIDSet failed: %v", err)
+	}
+	unblindedSetB, err := UnblindIDSet(evaluatedSetB, r, elliptic.P256())
+	if err != nil {
+		t.Fatalf("UnblindIDSet failed: %v", err)
+	}
+
+	// B为接收方，只能解密交集内的标签
+	labels, err := DecryptSampleLabels(sampleIDsB, unblindedSetB, labeledEncSet)
+	if err != nil {
+		t.Fatalf("DecryptSampleLabels failed: %v", err)
+	}
+	require.Equal(t, 2, len(labels))
+	require.Equal(t, labelsA[1], labels["10001"])
+	require.Equal(t, labelsA[3], labels["10003"])
+
+	// B参与PSI获得的A的集合无法用于解密任何标签
+	encSetA := EncryptSampleIDSet(sampleIDsA, &privateKeyA.PublicKey)
+	for _, set := range []*EncSet{encSetA, ReEncryptIDSet(encSetA, privateKeyB)} {
+		leaked, err := DecryptSampleLabels(sampleIDsA, set, labeledEncSet)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(leaked))
+	}
+
+	// B尝试由交集内ID的值反推出标签公钥，再伪造交集以外ID的值，无法解密对应的标签
+	curve := elliptic.P256()
+	for elem, index := range unblindedSetB.EncIDs {
+		x, y := elliptic.Unmarshal(curve, []byte(elem))
+		h := new(big.Int).SetBytes(hash.HashUsingSha256([]byte(sampleIDsB[index])))
+		hInv := new(big.Int).ModInverse(h, curve.Params().N)
+		lx, ly := curve.ScalarMult(x, y, hInv.Bytes())
+		for _, pub := range []*ecdsa.PublicKey{{Curve: curve, X: lx, Y: ly}, &labelKeyA.PublicKey} {
+			forged := EncryptSampleIDSet([]string{"10000", "10002"}, pub)
+			leaked, err := DecryptSampleLabels([]string{"10000", "10002"}, forged, labeledEncSet)
+			require.NoError(t, err)
+			require.Equal(t, 0, len(leaked))
+		}
+	}
+
+	// 发送方拒绝无法解析或不在曲线上的点
+	invalidPoint := make([]byte, 65)
+	invalidPoint[0], invalidPoint[32], invalidPoint[64] = 4, 1, 1
+	for _, elem := range [][]byte{[]byte("invalid"), invalidPoint} {
+		_, err := EvaluateBlindedIDSet(&EncSet{EncIDs: map[string]int{string(elem): 0}}, labelKeyA)
+		require.ErrorIs(t, err, ErrInvalidIDPoint)
+	}
+
+	if _, err := EncryptSampleLabels(sampleIDsA, labelsA[:2], labelKeyA); err != ErrInvalidLabelNumber {
+		t.Errorf("expected ErrInvalidLabelNumber, got %v", err)
+	}
+}
+
+func TestPSICurves(t *testing.T) {
+	sampleIDsA := []string{"10000", "10001", "10002", "10003"}
+	sampleIDsB := []string{"10001", "10003", "10005"}
+
+	for _, name := range []string{config.CurveNistP384, config.CurveSecp256k1, config.CurveSM2} {
+		curve, err := ecc.CurveByName(name)
+		require.NoError(t, err)
+
+		privateKeyA, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		privateKeyB, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		reEncSetA := ReEncryptIDSet(EncryptSampleIDSet(sampleIDsA, &privateKeyA.PublicKey), privateKeyB)
+		reEncSetB := ReEncryptIDSet(EncryptSampleIDSet(sampleIDsB, &privateKeyB.PublicKey), privateKeyA)
+
+		intersection := Intersect(sampleIDsA, reEncSetA, []*EncSet{reEncSetB})
+		require.ElementsMatch(t, []string{"10001", "10003"}, intersection, name)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkle
+
+import (
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+// HashMode defines how leaves and internal nodes are hashed 默克尔树的哈希模式
+type HashMode int
+
+const (
+	// HashModeBitcoin Bitcoin-style tree, objects are used as leaves directly, internal nodes are double sha256
+	// of the concatenation and a single left child is concatenated with itself. The duplication makes trees
+	// with different leaves share the same root (CVE-2012-2459), and leaves can not be distinguished from internal nodes.
+	// 比特币风格，对象直接作为叶子，内部节点为拼接后的双重sha256，单个左子节点与自身拼接，
+	// 存在不同叶子集合得到相同根的问题，且叶子与内部节点无法区分
+	HashModeBitcoin HashMode = iota
+
+	// HashModeDomainSeparated leaves and internal nodes are hashed with different prefixes as RFC 6962,
+	// and a single left child is promoted to its parent without hashing.
+	// 按RFC 6962区分叶子和内部节点，叶子哈希为 sha256(0x00 || 对象)，内部节点哈希为 sha256(0x01 || 左 || 右)，
+	// 单个左子节点直接提升为父节点
+	HashModeDomainSeparated
+)
+
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// HashLeaf calculate the leaf hash of an object in domain separated mode 计算域分离模式下对象的叶子哈希
+func HashLeaf(object []byte) []byte {
+	return hash.HashUsingSha256(append([]byte{leafPrefix}, object...))
+}
+
+// HashNode calculate the internal node hash in domain separated mode 计算域分离模式下内部节点的哈希
+func HashNode(left, right []byte) []byte {
+	h := make([]byte, 0, 1+len(left)+len(right))
+	h = append(h, nodePrefix)
+	h = append(h, left...)
+	h = append(h, right...)
+	return hash.HashUsingSha256(h)
+}
+
+// hashBranches 按哈希模式计算两个子节点的父节点
+func hashBranches(mode HashMode, left, right []byte) []byte {
+	if mode == HashModeDomainSeparated {
+		return HashNode(left, right)
+	}
+	return HashMerkleBranches(left, right)
+}
+
+// hashSingle 按哈希模式计算只有左子节点时的父节点
+func hashSingle(mode HashMode, left []byte) []byte {
+	if mode == HashModeDomainSeparated {
+		return left
+	}
+	return HashMerkleBranches(left, left)
+}
+
+// hashObject 按哈希模式得到对象对应的叶子节点
+func hashObject(mode HashMode, object []byte) []byte {
+	if mode == HashModeDomainSeparated {
+		return HashLeaf(object)
+	}
+	return object
+}
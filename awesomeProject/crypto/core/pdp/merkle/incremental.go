@@ -0,0 +1,239 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+/*
+	Append-only incremental merkle tree as RFC 6962, leaves and internal nodes are hashed in domain separated mode,
+	so the root of the first n leaves equals GetMerkleRootWithMode(objects[:n], HashModeDomainSeparated).
+	只追加的增量默克尔树，结构与RFC 6962一致，采用域分离的哈希模式，
+	前n个叶子的根与 GetMerkleRootWithMode(objects[:n], HashModeDomainSeparated) 相同
+	  MTH({})        = SHA256()
+	  MTH({d0})      = SHA256(0x00 || d0)
+	  MTH(D[n])      = SHA256(0x01 || MTH(D[0:k]) || MTH(D[k:n]))，k为小于n的最大的2的幂
+	树中只保存完整子树的哈希，追加叶子的复杂度为O(log n)，可计算任意历史规模的根、存在性证明和一致性证明
+*/
+
+var (
+	ErrInvalidTreeSize = errors.New("invalid tree size")
+)
+
+// IncrementalTree append-only merkle tree 只追加的增量默克尔树
+type IncrementalTree struct {
+	// levels[h][i] is the hash of the complete subtree of leaves [i*2^h, (i+1)*2^h)
+	// levels[h][i] 为叶子区间 [i*2^h, (i+1)*2^h) 构成的完整子树的哈希
+	levels [][][]byte
+}
+
+// NewIncrementalTree create an empty incremental merkle tree 创建空的增量默克尔树
+func NewIncrementalTree() *IncrementalTree {
+	return &IncrementalTree{
+		levels: [][][]byte{nil},
+	}
+}
+
+// Size return the number of leaves 返回叶子数量
+func (t *IncrementalTree) Size() int {
+	return len(t.levels[0])
+}
+
+// Append append an object as a new leaf, return the index of the leaf 追加一个对象作为新叶子，返回叶子索引
+func (t *IncrementalTree) Append(object []byte) int {
+	index := t.Size()
+	t.levels[0] = append(t.levels[0], HashLeaf(object))
+
+	// 每当某层的节点数为偶数时，合并最后两个节点得到上一层的完整子树
+	for h := 0; len(t.levels[h])%2 == 0; h++ {
+		if h+1 == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		n := len(t.levels[h])
+		t.levels[h+1] = append(t.levels[h+1], HashNode(t.levels[h][n-2], t.levels[h][n-1]))
+	}
+	return index
+}
+
+// Root return the current root 返回当前的根
+func (t *IncrementalTree) Root() []byte {
+	root, _ := t.RootAt(t.Size())
+	return root
+}
+
+// RootAt return the root when the tree had size leaves 返回树中有size个叶子时的根
+func (t *IncrementalTree) RootAt(size int) ([]byte, error) {
+	if size < 0 || size > t.Size() {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidTreeSize, size)
+	}
+	if size == 0 {
+		return hash.HashUsingSha256(nil), nil
+	}
+	return t.subtreeHash(0, size), nil
+}
+
+// InclusionProof generate the inclusion proof of the leaf at index in the tree of size leaves 生成规模为size的树中指定索引叶子的存在性证明，
+// 证明按从叶子到根的顺序排列
+func (t *IncrementalTree) InclusionProof(index, size int) ([][]byte, error) {
+	if size <= 0 || size > t.Size() {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidTreeSize, size)
+	}
+	if index < 0 || index >= size {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidIndex, index)
+	}
+	return t.path(index, 0, size), nil
+}
+
+// ConsistencyProof generate the proof that the tree of oldSize leaves is a prefix of the tree of newSize leaves
+// 生成规模为oldSize的树是规模为newSize的树的前缀的一致性证明
+func (t *IncrementalTree) ConsistencyProof(oldSize, newSize int) ([][]byte, error) {
+	if newSize < 0 || newSize > t.Size() {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidTreeSize, newSize)
+	}
+	if oldSize < 0 || oldSize > newSize {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidTreeSize, oldSize)
+	}
+	if oldSize == 0 || oldSize == newSize {
+		return nil, nil
+	}
+	return t.subproof(oldSize, 0, newSize, true), nil
+}
+
+// subtreeHash 计算叶子区间 [lo, hi) 的哈希，lo总是按区间内完整子树的大小对齐
+func (t *IncrementalTree) subtreeHash(lo, hi int) []byte {
+	n := hi - lo
+	if n&(n-1) == 0 {
+		h := 0
+		for 1<<uint(h) < n {
+			h++
+		}
+		return t.levels[h][lo>>uint(h)]
+	}
+	k := largestPowerOfTwoBelow(n)
+	return HashNode(t.subtreeHash(lo, lo+k), t.subtreeHash(lo+k, hi))
+}
+
+// path RFC 6962 中的 PATH(m, D[lo:hi])
+func (t *IncrementalTree) path(m, lo, hi int) [][]byte {
+	n := hi - lo
+	if n == 1 {
+		return nil
+	}
+	k := largestPowerOfTwoBelow(n)
+	if m < k {
+		return append(t.path(m, lo, lo+k), t.subtreeHash(lo+k, hi))
+	}
+	return append(t.path(m-k, lo+k, hi), t.subtreeHash(lo, lo+k))
+}
+
+// subproof RFC 6962 中的 SUBPROOF(m, D[lo:hi], b)
+func (t *IncrementalTree) subproof(m, lo, hi int, complete bool) [][]byte {
+	n := hi - lo
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][]byte{t.subtreeHash(lo, hi)}
+	}
+	k := largestPowerOfTwoBelow(n)
+	if m <= k {
+		return append(t.subproof(m, lo, lo+k, complete), t.subtreeHash(lo+k, hi))
+	}
+	return append(t.subproof(m-k, lo+k, hi, false), t.subtreeHash(lo, lo+k))
+}
+
+// VerifyInclusion verify the inclusion proof of an object in the tree of size leaves 验证对象在规模为size的树中的存在性证明
+func VerifyInclusion(root, object []byte, index, size int, proof [][]byte) bool {
+	if index < 0 || index >= size {
+		return false
+	}
+
+	fn, sn := index, size-1
+	r := HashLeaf(object)
+	for _, p := range proof {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = HashNode(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = HashNode(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
+
+// VerifyConsistency verify that the tree of oldSize leaves is a prefix of the tree of newSize leaves 验证一致性证明，
+// 即规模为oldSize的树是规模为newSize的树的前缀，历史数据未被改写
+func VerifyConsistency(oldSize, newSize int, oldRoot, newRoot []byte, proof [][]byte) bool {
+	switch {
+	case oldSize < 0 || oldSize > newSize:
+		return false
+	case oldSize == newSize:
+		return len(proof) == 0 && bytes.Equal(oldRoot, newRoot)
+	case oldSize == 0:
+		return len(proof) == 0
+	case len(proof) == 0:
+		return false
+	}
+
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = HashNode(c, fr)
+			sr = HashNode(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = HashNode(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(fr, oldRoot) && bytes.Equal(sr, newRoot)
+}
+
+// largestPowerOfTwoBelow 返回小于n的最大的2的幂，n > 1
+func largestPowerOfTwoBelow(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
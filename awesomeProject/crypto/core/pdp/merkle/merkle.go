@@ -70,6 +70,12 @@ import (
 //还提出了一个额外的案例，其中Coinbase交易的WTXID是
 //是零哈希。
 func BuildMerkleTreeStore(objects [][]byte) [][]byte {
+	return BuildMerkleTreeStoreWithMode(objects, HashModeBitcoin)
+}
+
+// BuildMerkleTreeStoreWithMode creates a merkle tree using the given hash mode 使用指定的哈希模式构建默克尔树，
+// HashModeDomainSeparated 模式下叶子位置存储的是对象的叶子哈希
+func BuildMerkleTreeStoreWithMode(objects [][]byte, mode HashMode) [][]byte {
 	// Calculate how many entries are required to hold the binary merkle
 	// tree as a linear array and create an array of that size.
 	//计算持有二进制默克尔需要多少个条目
@@ -78,7 +84,9 @@ func BuildMerkleTreeStore(objects [][]byte) [][]byte {
 	arraySize := nextPoT*2 - 1
 	merkles := make([][]byte, arraySize)
 
-	copy(merkles, objects)
+	for i, object := range objects {
+		merkles[i] = hashObject(mode, object)
+	}
 
 	// Start the array offset after the last transaction and adjusted to the
 	// next power of two.
@@ -92,11 +100,12 @@ func BuildMerkleTreeStore(objects [][]byte) [][]byte {
 			merkles[offset] = nil
 
 		// When there is no right child, the parent is generated by
-		// hashing the concatenation of the left child with itself.
+		// hashing the concatenation of the left child with itself,
+		// or promoted from the left child in domain separated mode.
 		//当没有合适的子代时，父级由
-		//		散列左子项与自身的串联。
+		//		散列左子项与自身的串联。域分离模式下直接提升左子项。
 		case merkles[i+1] == nil:
-			newHash := HashMerkleBranches(merkles[i], merkles[i])
+			newHash := hashSingle(mode, merkles[i])
 			merkles[offset] = newHash
 
 		// The normal case sets the parent node to the double sha256
@@ -104,7 +113,7 @@ func BuildMerkleTreeStore(objects [][]byte) [][]byte {
 		//正常情况下将父节点设置为双 sha256
 		//		左右孩子的融合。
 		default:
-			newHash := HashMerkleBranches(merkles[i], merkles[i+1])
+			newHash := hashBranches(mode, merkles[i], merkles[i+1])
 			merkles[offset] = newHash
 		}
 		offset++
@@ -155,3 +164,9 @@ func GetMerkleRoot(objects [][]byte) []byte {
 	tree := BuildMerkleTreeStore(objects)
 	return tree[len(tree)-1]
 }
+
+// GetMerkleRootWithMode calculate merkle root of several objects using the given hash mode 使用指定的哈希模式计算默克尔根
+func GetMerkleRootWithMode(objects [][]byte, mode HashMode) []byte {
+	tree := BuildMerkleTreeStoreWithMode(objects, mode)
+	return tree[len(tree)-1]
+}
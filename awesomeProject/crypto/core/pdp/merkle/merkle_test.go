@@ -16,6 +16,7 @@ package merkle
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -33,3 +34,180 @@ func TestMerkle(t *testing.T) {
 	root := GetMerkleRoot(hashes)
 	require.Equal(t, hex.EncodeToString(root), correctRootHex)
 }
+
+func TestMerkleProof(t *testing.T) {
+	for _, mode := range []HashMode{HashModeBitcoin, HashModeDomainSeparated} {
+		for n := 1; n <= 9; n++ {
+			var objects [][]byte
+			for i := 0; i < n; i++ {
+				h := sha256.Sum256([]byte{byte(i)})
+				objects = append(objects, h[:])
+			}
+			store := BuildMerkleTreeStoreWithMode(objects, mode)
+			root := GetMerkleRootWithMode(objects, mode)
+
+			for i := 0; i < n; i++ {
+				proof, err := GenerateProof(store, i)
+				require.NoError(t, err)
+				ok, err := VerifyProofWithMode(root, objects[i], proof, mode)
+				require.NoError(t, err)
+				require.True(t, ok)
+
+				ok, _ = VerifyProofWithMode(root, objects[(i+1)%n], proof, mode)
+				require.Equal(t, n == 1, ok)
+			}
+			_, err := GenerateProof(store, n)
+			require.Error(t, err)
+
+			// all subsets of leaves
+			for set := 1; set < 1<<uint(n); set++ {
+				var indices []int
+				var leaves [][]byte
+				for i := 0; i < n; i++ {
+					if set&(1<<uint(i)) != 0 {
+						indices = append(indices, i)
+						leaves = append(leaves, objects[i])
+					}
+				}
+				proof, err := GenerateMultiProof(store, indices)
+				require.NoError(t, err)
+				ok, err := VerifyMultiProofWithMode(root, leaves, proof, mode)
+				require.NoError(t, err)
+				require.True(t, ok)
+
+				if len(leaves) < n {
+					leaves[0] = objects[(indices[0]+1)%n]
+					ok, _ = VerifyMultiProofWithMode(root, leaves, proof, mode)
+					require.False(t, ok)
+				}
+			}
+		}
+	}
+
+	hash0 := sha256.Sum256([]byte("0"))
+	hash1 := sha256.Sum256([]byte("1"))
+	hash2 := sha256.Sum256([]byte("2"))
+	objects := [][]byte{hash0[:], hash1[:], hash2[:]}
+	proof, err := GenerateProof(BuildMerkleTreeStore(objects), 2)
+	require.NoError(t, err)
+	ok, err := VerifyProof(GetMerkleRoot(objects), hash2[:], proof)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Bitcoin-style trees with the last leaf duplicated share the same root, domain separated trees do not
+	duplicated := append(objects, hash2[:])
+	require.Equal(t, GetMerkleRoot(objects), GetMerkleRoot(duplicated))
+	require.NotEqual(t, GetMerkleRootWithMode(objects, HashModeDomainSeparated), GetMerkleRootWithMode(duplicated, HashModeDomainSeparated))
+
+	// a leaf can not be proved as an internal node in domain separated mode
+	store := BuildMerkleTreeStoreWithMode(duplicated, HashModeDomainSeparated)
+	root := store[len(store)-1]
+	internal := append(append([]byte{}, store[4]...), store[5]...)
+	ok, err = VerifyProofWithMode(root, internal, Proof{}, HashModeDomainSeparated)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	multi, err := GenerateMultiProof(store, []int{3, 0, 3})
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 3}, multi.Indices)
+	multi.Hashes = append(multi.Hashes, hash0[:])
+	_, err = VerifyMultiProofWithMode(root, duplicated[:2], multi, HashModeDomainSeparated)
+	require.Error(t, err)
+}
+
+func TestIncrementalTree(t *testing.T) {
+	tree := NewIncrementalTree()
+	require.Equal(t, hex.EncodeToString(tree.Root()), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+
+	var objects [][]byte
+	for i := 0; i < 20; i++ {
+		objects = append(objects, []byte{byte(i)})
+		require.Equal(t, i, tree.Append(objects[i]))
+		require.Equal(t, GetMerkleRootWithMode(objects, HashModeDomainSeparated), tree.Root())
+	}
+
+	for size := 1; size <= tree.Size(); size++ {
+		root, err := tree.RootAt(size)
+		require.NoError(t, err)
+		for i := 0; i < size; i++ {
+			proof, err := tree.InclusionProof(i, size)
+			require.NoError(t, err)
+			require.True(t, VerifyInclusion(root, objects[i], i, size, proof))
+			require.False(t, VerifyInclusion(root, objects[(i+1)%20], i, size, proof))
+			if size > 1 {
+				require.False(t, VerifyInclusion(root, objects[i], (i+1)%size, size, proof))
+			}
+		}
+
+		for oldSize := 0; oldSize <= size; oldSize++ {
+			oldRoot, err := tree.RootAt(oldSize)
+			require.NoError(t, err)
+			proof, err := tree.ConsistencyProof(oldSize, size)
+			require.NoError(t, err)
+			require.True(t, VerifyConsistency(oldSize, size, oldRoot, root, proof))
+
+			if oldSize > 0 && oldSize < size {
+				// 改写历史后的根无法通过一致性验证
+				rewritten := append([][]byte{}, objects[:oldSize]...)
+				rewritten[0] = []byte("rewritten")
+				fakeRoot := GetMerkleRootWithMode(rewritten, HashModeDomainSeparated)
+				require.False(t, VerifyConsistency(oldSize, size, fakeRoot, root, proof))
+				require.False(t, VerifyConsistency(oldSize, size, oldRoot, root, proof[1:]))
+			}
+		}
+	}
+
+	_, err := tree.InclusionProof(3, 3)
+	require.Error(t, err)
+	_, err = tree.ConsistencyProof(5, 21)
+	require.Error(t, err)
+}
+
+func TestSparseTree(t *testing.T) {
+	tree := NewSparseTree()
+	emptyRoot := tree.Root()
+
+	proof := tree.Prove([]byte("a"))
+	ok, err := VerifySparseNonMembership(emptyRoot, []byte("a"), proof)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	for i := 0; i < 50; i++ {
+		tree.Set([]byte{byte(i)}, []byte(fmt.Sprintf("value %d", i)))
+	}
+	root := tree.Root()
+
+	for i := 0; i < 50; i++ {
+		key := []byte{byte(i)}
+		value, ok := tree.Get(key)
+		require.True(t, ok)
+		proof := tree.Prove(key)
+
+		ok, err := VerifySparseMembership(root, key, value, proof)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, _ = VerifySparseMembership(root, key, []byte("other"), proof)
+		require.False(t, ok)
+		ok, _ = VerifySparseNonMembership(root, key, proof)
+		require.False(t, ok)
+	}
+
+	key := []byte("absent")
+	proof = tree.Prove(key)
+	ok, err = VerifySparseNonMembership(root, key, proof)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, _ = VerifySparseMembership(root, key, []byte{}, proof)
+	require.False(t, ok)
+
+	// 删除全部键后恢复为空树
+	for i := 0; i < 50; i++ {
+		tree.Delete([]byte{byte(i)})
+	}
+	require.Equal(t, emptyRoot, tree.Root())
+	require.Empty(t, tree.nodes)
+
+	proof.Siblings = append(proof.Siblings, emptyRoot)
+	_, err = VerifySparseNonMembership(root, key, proof)
+	require.Error(t, err)
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrInvalidStore = errors.New("invalid merkle tree store")
+	ErrInvalidIndex = errors.New("invalid leaf index")
+	ErrInvalidProof = errors.New("invalid merkle proof")
+)
+
+// ProofNode a sibling on the path from leaf to root 叶子到根路径上的一个兄弟节点
+type ProofNode struct {
+	Hash []byte // sibling hash, nil if the node has no right sibling 兄弟节点哈希，节点没有右兄弟时为nil
+	Left bool   // whether the sibling is the left child 兄弟节点是否为左子节点
+}
+
+// Proof inclusion proof of a single leaf, ordered from leaf to root 单个叶子的存在性证明，按从叶子到根的顺序排列
+type Proof []ProofNode
+
+// MultiProof compact inclusion proof of several leaves, sibling hashes that can be calculated
+// from the proved leaves are omitted
+// 多个叶子的紧凑存在性证明，可由被证明叶子计算得到的兄弟节点不包含在证明中
+type MultiProof struct {
+	LeafCount int      // number of leaves in the tree 树的叶子总数
+	Indices   []int    // ascending indices of proved leaves 被证明叶子的索引，升序排列
+	Hashes    [][]byte // sibling hashes, level by level from leaves to root 兄弟节点哈希，从叶子层到根逐层排列
+}
+
+// GenerateProof generate the inclusion proof of the leaf at index 生成指定索引叶子的存在性证明
+// - store merkle tree store returned by BuildMerkleTreeStore or BuildMerkleTreeStoreWithMode
+func GenerateProof(store [][]byte, index int) (Proof, error) {
+	width, err := leafWidth(store)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= width || store[index] == nil {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidIndex, index)
+	}
+
+	var proof Proof
+	offset, pos := 0, index
+	for w := width; w > 1; w /= 2 {
+		sibling := pos ^ 1
+		proof = append(proof, ProofNode{
+			Hash: store[offset+sibling],
+			Left: sibling < pos,
+		})
+		offset += w
+		pos /= 2
+	}
+	return proof, nil
+}
+
+// VerifyProof verify the inclusion proof of a leaf in Bitcoin-style tree 验证比特币风格默克尔树中叶子的存在性证明
+func VerifyProof(root, leaf []byte, proof Proof) (bool, error) {
+	return VerifyProofWithMode(root, leaf, proof, HashModeBitcoin)
+}
+
+// VerifyProofWithMode verify the inclusion proof of an object using the given hash mode 使用指定的哈希模式验证对象的存在性证明，
+// HashModeDomainSeparated 模式下传入原始对象而非叶子哈希
+func VerifyProofWithMode(root, object []byte, proof Proof, mode HashMode) (bool, error) {
+	if len(object) == 0 {
+		return false, ErrInvalidProof
+	}
+
+	cur := hashObject(mode, object)
+	for _, node := range proof {
+		switch {
+		case node.Hash == nil && node.Left:
+			return false, fmt.Errorf("%w: missing left sibling", ErrInvalidProof)
+		case node.Hash == nil:
+			cur = hashSingle(mode, cur)
+		case node.Left:
+			cur = hashBranches(mode, node.Hash, cur)
+		default:
+			cur = hashBranches(mode, cur, node.Hash)
+		}
+	}
+	return bytes.Equal(cur, root), nil
+}
+
+// GenerateMultiProof generate the compact inclusion proof of several leaves 生成多个叶子的紧凑存在性证明
+func GenerateMultiProof(store [][]byte, indices []int) (*MultiProof, error) {
+	width, err := leafWidth(store)
+	if err != nil {
+		return nil, err
+	}
+	leafCount := 0
+	for leafCount < width && store[leafCount] != nil {
+		leafCount++
+	}
+	for i := leafCount; i < width; i++ {
+		if store[i] != nil {
+			return nil, fmt.Errorf("%w: leaves are not contiguous", ErrInvalidStore)
+		}
+	}
+
+	known, err := sortIndices(indices, leafCount)
+	if err != nil {
+		return nil, err
+	}
+	proof := &MultiProof{
+		LeafCount: leafCount,
+		Indices:   append([]int(nil), known...),
+	}
+
+	offset := 0
+	for w := width; w > 1; w /= 2 {
+		var parents []int
+		for i := 0; i < len(known); i++ {
+			pos := known[i]
+			sibling := pos ^ 1
+			if i+1 < len(known) && known[i+1] == sibling {
+				i++
+			} else if store[offset+sibling] != nil {
+				proof.Hashes = append(proof.Hashes, store[offset+sibling])
+			}
+			parents = append(parents, pos/2)
+		}
+		offset += w
+		known = parents
+	}
+	return proof, nil
+}
+
+// VerifyMultiProof verify the compact inclusion proof of several leaves in Bitcoin-style tree 验证比特币风格默克尔树中多个叶子的紧凑存在性证明
+// - leaves leaves corresponding to proof.Indices 与proof.Indices一一对应的叶子
+func VerifyMultiProof(root []byte, leaves [][]byte, proof *MultiProof) (bool, error) {
+	return VerifyMultiProofWithMode(root, leaves, proof, HashModeBitcoin)
+}
+
+// VerifyMultiProofWithMode verify the compact inclusion proof of several objects using the given hash mode 使用指定的哈希模式验证多个对象的紧凑存在性证明
+func VerifyMultiProofWithMode(root []byte, objects [][]byte, proof *MultiProof, mode HashMode) (bool, error) {
+	if proof == nil || len(objects) != len(proof.Indices) {
+		return false, ErrInvalidProof
+	}
+	known, err := sortIndices(proof.Indices, proof.LeafCount)
+	if err != nil {
+		return false, err
+	}
+	if len(known) != len(proof.Indices) {
+		return false, fmt.Errorf("%w: indices must be ascending and distinct", ErrInvalidProof)
+	}
+	for i := range known {
+		if known[i] != proof.Indices[i] {
+			return false, fmt.Errorf("%w: indices must be ascending and distinct", ErrInvalidProof)
+		}
+	}
+
+	nodes := make([][]byte, len(objects))
+	for i, object := range objects {
+		if len(object) == 0 {
+			return false, ErrInvalidProof
+		}
+		nodes[i] = hashObject(mode, object)
+	}
+
+	next := 0
+	count := proof.LeafCount
+	for w := nextPowerOfTwo(proof.LeafCount); w > 1; w /= 2 {
+		var parents []int
+		var parentNodes [][]byte
+		for i := 0; i < len(known); i++ {
+			pos := known[i]
+			sibling := pos ^ 1
+
+			var parent []byte
+			switch {
+			case i+1 < len(known) && known[i+1] == sibling:
+				parent = hashBranches(mode, nodes[i], nodes[i+1])
+				i++
+			case sibling >= count:
+				parent = hashSingle(mode, nodes[i])
+			case next >= len(proof.Hashes):
+				return false, fmt.Errorf("%w: missing sibling hashes", ErrInvalidProof)
+			case sibling < pos:
+				parent = hashBranches(mode, proof.Hashes[next], nodes[i])
+				next++
+			default:
+				parent = hashBranches(mode, nodes[i], proof.Hashes[next])
+				next++
+			}
+			parents = append(parents, pos/2)
+			parentNodes = append(parentNodes, parent)
+		}
+		known, nodes = parents, parentNodes
+		count = (count + 1) / 2
+	}
+	if next != len(proof.Hashes) {
+		return false, fmt.Errorf("%w: redundant sibling hashes", ErrInvalidProof)
+	}
+	return bytes.Equal(nodes[0], root), nil
+}
+
+// leafWidth 检查树的线性数组是否合法，并返回叶子层的宽度
+func leafWidth(store [][]byte) (int, error) {
+	width := (len(store) + 1) / 2
+	if len(store) == 0 || width&(width-1) != 0 || 2*width-1 != len(store) {
+		return 0, ErrInvalidStore
+	}
+	return width, nil
+}
+
+// sortIndices 对叶子索引去重并升序排列，并检查索引是否在叶子范围内
+func sortIndices(indices []int, leafCount int) ([]int, error) {
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("%w: empty indices", ErrInvalidIndex)
+	}
+	sorted := append([]int(nil), indices...)
+	sort.Ints(sorted)
+
+	var res []int
+	for i, index := range sorted {
+		if index < 0 || index >= leafCount {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidIndex, index)
+		}
+		if i > 0 && index == sorted[i-1] {
+			continue
+		}
+		res = append(res, index)
+	}
+	return res, nil
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+/*
+	Sparse merkle tree of depth 256, the position of a key is SHA256(key), supports membership and non-membership proofs.
+	深度为256的稀疏默克尔树，键的位置为 SHA256(key)，从根到叶子按位置的最高位到最低位选择左（0）右（1）子树
+	  叶子哈希:   SHA256(0x00 || SHA256(key) || SHA256(value))
+	  空叶子:     32字节的0
+	  内部节点:   SHA256(0x01 || 左 || 右)
+	全空子树的哈希预先计算，树中只保存非空节点
+*/
+
+// SparseTreeDepth 稀疏默克尔树的深度
+const SparseTreeDepth = 256
+
+// emptyHashes[h] is the hash of an empty subtree of height h 高度为h的全空子树的哈希
+var emptyHashes [SparseTreeDepth + 1][]byte
+
+func init() {
+	emptyHashes[0] = make([]byte, sha256.Size)
+	for h := 1; h <= SparseTreeDepth; h++ {
+		emptyHashes[h] = HashNode(emptyHashes[h-1], emptyHashes[h-1])
+	}
+}
+
+// SparseTree sparse merkle tree 稀疏默克尔树
+type SparseTree struct {
+	nodes  map[string][]byte   // non-empty nodes indexed by depth and path prefix 以深度和路径前缀为索引的非空节点
+	values map[[32]byte][]byte // values indexed by path 以路径为索引的值
+}
+
+// SparseProof membership or non-membership proof of a key 键的存在性或不存在性证明
+type SparseProof struct {
+	Bitmap   [SparseTreeDepth / 8]byte // bit h is set if the sibling at height h is not empty 高度h的兄弟节点非空时第h位为1
+	Siblings [][]byte                  // non-empty siblings ordered from leaf to root 非空的兄弟节点，按从叶子到根的顺序排列
+}
+
+// NewSparseTree create an empty sparse merkle tree 创建空的稀疏默克尔树
+func NewSparseTree() *SparseTree {
+	return &SparseTree{
+		nodes:  make(map[string][]byte),
+		values: make(map[[32]byte][]byte),
+	}
+}
+
+// Root return the root of the tree 返回树的根
+func (t *SparseTree) Root() []byte {
+	return t.node(SparseTreeDepth, [32]byte{})
+}
+
+// Get return the value of a key 获取键对应的值
+func (t *SparseTree) Get(key []byte) ([]byte, bool) {
+	value, ok := t.values[sha256.Sum256(key)]
+	return value, ok
+}
+
+// Set set the value of a key 设置键对应的值
+func (t *SparseTree) Set(key, value []byte) {
+	path := sha256.Sum256(key)
+	t.values[path] = append([]byte{}, value...)
+	t.update(path, sparseLeaf(path, value))
+}
+
+// Delete delete a key 删除键
+func (t *SparseTree) Delete(key []byte) {
+	path := sha256.Sum256(key)
+	if _, ok := t.values[path]; !ok {
+		return
+	}
+	delete(t.values, path)
+	t.update(path, emptyHashes[0])
+}
+
+// Prove generate the membership proof if the key exists, or else the non-membership proof 生成键的证明，
+// 键存在时为存在性证明，否则为不存在性证明
+func (t *SparseTree) Prove(key []byte) *SparseProof {
+	path := sha256.Sum256(key)
+	proof := new(SparseProof)
+	for h := 0; h < SparseTreeDepth; h++ {
+		sibling := t.node(h, flipBit(path, h))
+		if !bytes.Equal(sibling, emptyHashes[h]) {
+			proof.Bitmap[h/8] |= 1 << uint(h%8)
+			proof.Siblings = append(proof.Siblings, sibling)
+		}
+	}
+	return proof
+}
+
+// VerifySparseMembership verify that key is bound to value 验证键与值的绑定关系存在于树中
+func VerifySparseMembership(root, key, value []byte, proof *SparseProof) (bool, error) {
+	path := sha256.Sum256(key)
+	return verifySparseProof(root, path, sparseLeaf(path, value), proof)
+}
+
+// VerifySparseNonMembership verify that key does not exist 验证键不存在于树中
+func VerifySparseNonMembership(root, key []byte, proof *SparseProof) (bool, error) {
+	return verifySparseProof(root, sha256.Sum256(key), emptyHashes[0], proof)
+}
+
+func verifySparseProof(root []byte, path [32]byte, leaf []byte, proof *SparseProof) (bool, error) {
+	if proof == nil {
+		return false, ErrInvalidProof
+	}
+
+	cur, next := leaf, 0
+	for h := 0; h < SparseTreeDepth; h++ {
+		sibling := emptyHashes[h]
+		if proof.Bitmap[h/8]&(1<<uint(h%8)) != 0 {
+			if next >= len(proof.Siblings) {
+				return false, fmt.Errorf("%w: missing siblings", ErrInvalidProof)
+			}
+			sibling = proof.Siblings[next]
+			next++
+		}
+		cur = hashSparseNode(path, h, cur, sibling)
+	}
+	if next != len(proof.Siblings) {
+		return false, fmt.Errorf("%w: redundant siblings", ErrInvalidProof)
+	}
+	return bytes.Equal(cur, root), nil
+}
+
+// update 更新路径上的叶子，并重新计算从叶子到根的节点
+func (t *SparseTree) update(path [32]byte, leaf []byte) {
+	cur := leaf
+	for h := 0; ; h++ {
+		key := nodeKey(h, path)
+		if bytes.Equal(cur, emptyHashes[h]) {
+			delete(t.nodes, key)
+		} else {
+			t.nodes[key] = cur
+		}
+		if h == SparseTreeDepth {
+			return
+		}
+		cur = hashSparseNode(path, h, cur, t.node(h, flipBit(path, h)))
+	}
+}
+
+// node 返回高度为h、包含路径path的节点
+func (t *SparseTree) node(h int, path [32]byte) []byte {
+	if n, ok := t.nodes[nodeKey(h, path)]; ok {
+		return n
+	}
+	return emptyHashes[h]
+}
+
+// hashSparseNode 根据路径在高度h处的位，计算节点与兄弟节点的父节点
+func hashSparseNode(path [32]byte, h int, node, sibling []byte) []byte {
+	if bitAt(path, h) == 0 {
+		return HashNode(node, sibling)
+	}
+	return HashNode(sibling, node)
+}
+
+func sparseLeaf(path [32]byte, value []byte) []byte {
+	valueHash := sha256.Sum256(value)
+	return HashLeaf(append(path[:], valueHash[:]...))
+}
+
+// bitAt 返回路径中决定高度h的节点是左子节点还是右子节点的位，即从最低位起第h位
+func bitAt(path [32]byte, h int) byte {
+	return (path[31-h/8] >> uint(h%8)) & 1
+}
+
+func flipBit(path [32]byte, h int) [32]byte {
+	path[31-h/8] ^= 1 << uint(h%8)
+	return path
+}
+
+// nodeKey 高度为h的节点的索引，由高度和去掉低h位后的路径组成
+func nodeKey(h int, path [32]byte) string {
+	for i := 0; i < h/8; i++ {
+		path[31-i] = 0
+	}
+	if h < SparseTreeDepth {
+		path[31-h/8] &^= 1<<uint(h%8) - 1
+	}
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], uint16(h))
+	return string(buf[:]) + string(path[:])
+}
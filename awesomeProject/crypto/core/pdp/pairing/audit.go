@@ -0,0 +1,365 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pairing
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12_381_ecc "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+/*
+	Publicly verifiable and privacy-preserving PDP audit by a third party auditor (TPA), following Shacham–Waters
+	and Wang et al. Each segment is split into s sectors m_i1...m_is of 31 bytes, which are tagged as field elements:
+	  sigma_i = sk * ( H(v||i) + m_i1*u_1 + ... + m_is*u_s )
+	where H and u_j = H(u||j) hash onto G1, so nobody knows their discrete logarithms and the tag cannot be
+	reproduced from anything shorter than the content itself.
+	Challenges {i, v_i} are derived from public randomness such as a block hash, so anyone can recompute them.
+	The prover blinds the aggregated sectors with random rho_j:
+	  M_j = v_1*m_1j + ... + v_c*m_cj，R = rho_1*u_1 + ... + rho_s*u_s，gamma = H(R||sigma||challenge)，
+	  mu_j = rho_j + gamma*M_j mod N
+	and the auditor checks
+	  e(gamma*sigma, g2) = e(gamma*(v_1*H(v||i_1) + ... + v_c*H(v||i_c)) + mu_1*u_1 + ... + mu_s*u_s - R, pk)
+	without learning anything about M_j.
+	可公开验证且保护隐私的第三方审计副本保持证明，参考Shacham–Waters和Wang等人的方案，
+	每个段被切分为31字节的扇区，扇区作为域元素计算标签，u_j由哈希映射到G1，无人知道其离散对数，
+	因此只保存内容摘要等少于原文的数据无法通过审计；标签不依赖每轮的秘密随机数，
+	挑战由区块哈希等公开随机数派生，任何人都可以重新计算；证明者使用随机数rho_j对聚合后的扇区进行盲化，
+	审计者无需数据所有者的任何秘密即可验证，且无法获知文件内容的任何信息
+*/
+
+var (
+	ErrInvalidChallenge = errors.New("invalid audit challenge")
+)
+
+const (
+	// auditSectorSize 扇区长度，小于G1的阶，扇区可直接作为域元素
+	auditSectorSize = 31
+	// maxAuditSectors 每个段的最大扇区数，限制验证者的计算量
+	maxAuditSectors = 1 << 16
+
+	auditHashDST = "PaddleDTX-PDP-AUDIT-V01-CS01-with-BLS12381G1_XMD:SHA-256_SSWU_RO_"
+)
+
+// AuditProof blinded proof for third party audit 第三方审计的盲化证明
+type AuditProof struct {
+	Sigma *bls12_381_ecc.G1Affine // aggregated tag 聚合标签
+	R     *bls12_381_ecc.G1Affine // blinding commitment rho*u*g1 盲化承诺
+	Mu    []*big.Int              // blinded aggregated sectors 盲化后的聚合扇区
+}
+
+// AuditProofParams parameters required to generate an audit proof 生成审计证明所需的参数
+type AuditProofParams struct {
+	Content  [][]byte                  // challenged file contents 被挑战的文件内容
+	Indices  []*big.Int                // {i} index list {i} 索引列表
+	RandomVs []*big.Int                // {v_i} random challenge number list {v_i} 随机挑战数列表
+	Sigmas   []*bls12_381_ecc.G1Affine // {sigma_i} list in storage {sigma_i} 存储中的标签列表
+	RandomU  *big.Int                  // public random U 公开的随机 U
+}
+
+// AuditVerifyParams parameters required to verify an audit proof 验证审计证明所需的参数
+type AuditVerifyParams struct {
+	Proof    *AuditProof
+	RandomV  *big.Int   // public random V 公开的随机 V
+	RandomU  *big.Int   // public random U 公开的随机 U
+	Indices  []*big.Int // {i} index list {i} 索引列表
+	RandomVs []*big.Int // {v_i} random challenge number list {v_i} 随机挑战数列表
+	Pubkey   *PublicKey // client public key 客户端公钥
+}
+
+// CalculateAuditSigmaI calculate the round independent tag for third party audit, param.Round is not used
+// sigma_i = sk * ( H(v||i) + m_i1*u_1 + ... + m_is*u_s )
+// 计算用于第三方审计的标签，标签与挑战轮次无关，不使用param.Round
+func CalculateAuditSigmaI(param CalculateSigmaIParams) (*bls12_381_ecc.G1Affine, error) {
+	hvi, err := auditHashToG1("index", param.RandomV, param.Index)
+	if err != nil {
+		return nil, err
+	}
+	sectors, err := contentToSectors(param.Content)
+	if err != nil {
+		return nil, err
+	}
+	us, err := auditGenerators(param.RandomU, len(sectors))
+	if err != nil {
+		return nil, err
+	}
+
+	var sum bls12_381_ecc.G1Jac
+	sum.FromAffine(hvi)
+	for j, m := range sectors {
+		var mu bls12_381_ecc.G1Jac
+		mu.FromAffine(&us[j])
+		mu.ScalarMultiplication(&mu, m)
+		sum.AddAssign(&mu)
+	}
+	sum.ScalarMultiplication(&sum, param.Privkey.X)
+	return new(bls12_381_ecc.G1Affine).FromJacobian(&sum), nil
+}
+
+// DeriveAuditChallenge derive challengeNum distinct indices within [0, segmentNum) and their random numbers
+// from public randomness such as a block hash, no owner secret is required
+// 从区块哈希等公开随机数派生challengeNum个 [0, segmentNum) 范围内互不相同的索引及对应的随机数，无需数据所有者的秘密
+func DeriveAuditChallenge(beacon []byte, segmentNum, challengeNum int) ([]*big.Int, []*big.Int, error) {
+	if len(beacon) == 0 || challengeNum <= 0 || challengeNum > segmentNum {
+		return nil, nil, fmt.Errorf("%w: %d of %d segments", ErrInvalidChallenge, challengeNum, segmentNum)
+	}
+
+	// 基于公开随机数的部分Fisher-Yates洗牌，swapped记录被交换过的位置
+	swapped := make(map[int]int)
+	at := func(i int) int {
+		if v, ok := swapped[i]; ok {
+			return v
+		}
+		return i
+	}
+
+	indices := make([]*big.Int, 0, challengeNum)
+	randomVs := make([]*big.Int, 0, challengeNum)
+	for k := 0; k < challengeNum; k++ {
+		r := binary.BigEndian.Uint64(beaconHash(beacon, "index", k)[:8])
+		j := k + int(r%uint64(segmentNum-k))
+		vk, vj := at(k), at(j)
+		swapped[k], swapped[j] = vj, vk
+		indices = append(indices, big.NewInt(int64(vj)))
+
+		v := append(beaconHash(beacon, "v0", k), beaconHash(beacon, "v1", k)...)
+		randomVs = append(randomVs, new(big.Int).Mod(new(big.Int).SetBytes(v), order))
+	}
+	return indices, randomVs, nil
+}
+
+// AuditProve generate a blinded proof for third party audit 生成第三方审计的盲化证明
+func AuditProve(param AuditProofParams) (*AuditProof, error) {
+	if len(param.Content) != len(param.Indices) || len(param.Sigmas) != len(param.Indices) || param.RandomU == nil {
+		return nil, fmt.Errorf("%w: mismatched params", ErrInvalidChallenge)
+	}
+	if err := checkAuditChallenge(param.Indices, param.RandomVs); err != nil {
+		return nil, err
+	}
+
+	var sigmaJac bls12_381_ecc.G1Jac
+	var ms []*big.Int
+	for i := range param.Indices {
+		// sigma = v1*sigma1 + ... + vc*sigmac
+		var vs bls12_381_ecc.G1Jac
+		vs.FromAffine(param.Sigmas[i])
+		vs.ScalarMultiplication(&vs, param.RandomVs[i])
+		sigmaJac.AddAssign(&vs)
+
+		// M_j = v1*m1j + ... + vc*mcj
+		sectors, err := contentToSectors(param.Content[i])
+		if err != nil {
+			return nil, err
+		}
+		for len(ms) < len(sectors) {
+			ms = append(ms, new(big.Int))
+		}
+		for j, m := range sectors {
+			ms[j].Add(ms[j], new(big.Int).Mul(param.RandomVs[i], m))
+			ms[j].Mod(ms[j], order)
+		}
+	}
+	if len(ms) == 0 {
+		return nil, fmt.Errorf("%w: empty content", ErrInvalidChallenge)
+	}
+	sigma := new(bls12_381_ecc.G1Affine).FromJacobian(&sigmaJac)
+
+	// R = rho_1*u_1 + ... + rho_s*u_s
+	us, err := auditGenerators(param.RandomU, len(ms))
+	if err != nil {
+		return nil, err
+	}
+	rhos := make([]*big.Int, len(ms))
+	var rJac bls12_381_ecc.G1Jac
+	for j := range ms {
+		if rhos[j], err = RandomWithinOrder(); err != nil {
+			return nil, err
+		}
+		var ru bls12_381_ecc.G1Jac
+		ru.FromAffine(&us[j])
+		ru.ScalarMultiplication(&ru, rhos[j])
+		rJac.AddAssign(&ru)
+	}
+	R := new(bls12_381_ecc.G1Affine).FromJacobian(&rJac)
+
+	// mu_j = rho_j + gamma*M_j
+	gamma := auditGamma(R, sigma, param.Indices, param.RandomVs)
+	mu := make([]*big.Int, len(ms))
+	for j := range ms {
+		mu[j] = new(big.Int).Mul(gamma, ms[j])
+		mu[j].Add(mu[j], rhos[j])
+		mu[j].Mod(mu[j], order)
+	}
+
+	return &AuditProof{
+		Sigma: sigma,
+		R:     R,
+		Mu:    mu,
+	}, nil
+}
+
+// AuditVerify verify a blinded audit proof with public information only 仅使用公开信息验证盲化的审计证明
+// e(gamma*sigma, g2) = e(gamma*(v1*H(v||index_1) + ... + vc*H(v||index_c)) + mu_1*u_1 + ... + mu_s*u_s - R, pk)
+func AuditVerify(param AuditVerifyParams) (bool, error) {
+	proof := param.Proof
+	if proof == nil || proof.Sigma == nil || proof.R == nil || param.Pubkey == nil || param.Pubkey.P == nil {
+		return false, fmt.Errorf("%w: incomplete params", ErrInvalidChallenge)
+	}
+	if len(proof.Mu) == 0 || len(proof.Mu) > maxAuditSectors {
+		return false, fmt.Errorf("%w: invalid number of sectors %d", ErrInvalidChallenge, len(proof.Mu))
+	}
+	for _, mu := range proof.Mu {
+		if mu == nil || mu.Sign() < 0 || mu.Cmp(order) >= 0 {
+			return false, fmt.Errorf("%w: invalid mu %v", ErrInvalidChallenge, mu)
+		}
+	}
+	if err := checkAuditChallenge(param.Indices, param.RandomVs); err != nil {
+		return false, err
+	}
+	gamma := auditGamma(proof.R, proof.Sigma, param.Indices, param.RandomVs)
+
+	// v1*H(v||index_1) + ... + vc*H(v||index_c)
+	var vhJac bls12_381_ecc.G1Jac
+	for i := range param.Indices {
+		hvi, err := auditHashToG1("index", param.RandomV, param.Indices[i])
+		if err != nil {
+			return false, err
+		}
+		var vhi bls12_381_ecc.G1Jac
+		vhi.FromAffine(hvi)
+		vhi.ScalarMultiplication(&vhi, param.RandomVs[i])
+		vhJac.AddAssign(&vhi)
+	}
+
+	// gamma*(...) + mu_1*u_1 + ... + mu_s*u_s - R
+	vhJac.ScalarMultiplication(&vhJac, gamma)
+	us, err := auditGenerators(param.RandomU, len(proof.Mu))
+	if err != nil {
+		return false, err
+	}
+	for j, mu := range proof.Mu {
+		var muU bls12_381_ecc.G1Jac
+		muU.FromAffine(&us[j])
+		muU.ScalarMultiplication(&muU, mu)
+		vhJac.AddAssign(&muU)
+	}
+	var negR bls12_381_ecc.G1Jac
+	negR.FromAffine(proof.R)
+	negR.Neg(&negR)
+	vhJac.AddAssign(&negR)
+	right := new(bls12_381_ecc.G1Affine).FromJacobian(&vhJac)
+
+	// -gamma*sigma
+	var left bls12_381_ecc.G1Affine
+	left.ScalarMultiplication(proof.Sigma, gamma)
+	left.Neg(&left)
+
+	return bls12_381_ecc.PairingCheck(
+		[]bls12_381_ecc.G1Affine{left, *right},
+		[]bls12_381_ecc.G2Affine{g2Gen, *param.Pubkey.P},
+	)
+}
+
+// checkAuditChallenge 检查挑战的索引和随机数数量一致，且均为不超过256比特的非负整数
+func checkAuditChallenge(indices, randomVs []*big.Int) error {
+	if len(indices) != len(randomVs) || len(indices) == 0 {
+		return fmt.Errorf("%w: mismatched params", ErrInvalidChallenge)
+	}
+	for i := range indices {
+		for _, n := range []*big.Int{indices[i], randomVs[i]} {
+			if n == nil || n.Sign() < 0 || n.BitLen() > 256 {
+				return fmt.Errorf("%w: invalid number %v", ErrInvalidChallenge, n)
+			}
+		}
+	}
+	return nil
+}
+
+// contentToSectors 将段内容切分为31字节的扇区，最后一个扇区右侧补零，每个扇区作为小于阶的域元素
+func contentToSectors(content []byte) ([]*big.Int, error) {
+	n := (len(content) + auditSectorSize - 1) / auditSectorSize
+	if n > maxAuditSectors {
+		return nil, fmt.Errorf("%w: segment too large", ErrInvalidChallenge)
+	}
+	sectors := make([]*big.Int, 0, n)
+	buf := make([]byte, auditSectorSize)
+	for len(content) > 0 {
+		k := copy(buf, content)
+		for j := k; j < auditSectorSize; j++ {
+			buf[j] = 0
+		}
+		sectors = append(sectors, new(big.Int).SetBytes(buf))
+		content = content[k:]
+	}
+	return sectors, nil
+}
+
+// auditGenerators u_j = H(u||j)，j = 1...s
+func auditGenerators(u *big.Int, s int) ([]bls12_381_ecc.G1Affine, error) {
+	us := make([]bls12_381_ecc.G1Affine, s)
+	for j := range us {
+		p, err := auditHashToG1("sector", u, big.NewInt(int64(j+1)))
+		if err != nil {
+			return nil, err
+		}
+		us[j] = *p
+	}
+	return us, nil
+}
+
+// auditHashToG1 hash label||a||b onto G1, the discrete logarithm of the result is unknown
+// 将 label||a||b 哈希映射到G1，结果的离散对数未知
+func auditHashToG1(label string, a, b *big.Int) (*bls12_381_ecc.G1Affine, error) {
+	if a == nil || b == nil || a.Sign() < 0 || b.Sign() < 0 || a.BitLen() > 256 || b.BitLen() > 256 {
+		return nil, fmt.Errorf("%w: invalid number", ErrInvalidChallenge)
+	}
+	msg := []byte(label)
+	buf := make([]byte, 32)
+	msg = append(msg, a.FillBytes(buf)...)
+	msg = append(msg, b.FillBytes(buf)...)
+	p, err := bls12_381_ecc.HashToG1(msg, []byte(auditHashDST))
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// auditGamma gamma = H(R||sigma||{i}||{v_i}) mod N，将盲化承诺与证明和挑战绑定
+func auditGamma(R, sigma *bls12_381_ecc.G1Affine, indices, randomVs []*big.Int) *big.Int {
+	h := sha256.New()
+	rBytes, sigmaBytes := R.Bytes(), sigma.Bytes()
+	h.Write(rBytes[:])
+	h.Write(sigmaBytes[:])
+	buf := make([]byte, 32)
+	for i := range indices {
+		h.Write(indices[i].FillBytes(buf))
+		h.Write(randomVs[i].FillBytes(buf))
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), order)
+}
+
+// beaconHash SHA256(beacon || label || counter)
+func beaconHash(beacon []byte, label string, counter int) []byte {
+	h := sha256.New()
+	h.Write(beacon)
+	h.Write([]byte(label))
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(counter))
+	h.Write(buf)
+	return h.Sum(nil)
+}
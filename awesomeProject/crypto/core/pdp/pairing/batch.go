@@ -0,0 +1,149 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pairing
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	bls12_381_ecc "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// batchCoefficientBits bit length of the random coefficients in batch verification 批量验证中随机系数的比特长度
+const batchCoefficientBits = 128
+
+// batchItem a proof prepared for batch verification 预处理后待批量验证的证明
+type batchItem struct {
+	index  int                     // index in the input params 在输入参数中的索引
+	sigma  *bls12_381_ecc.G1Affine // sigma in proof 证明中的sigma
+	point  *bls12_381_ecc.G1Affine // v1*H(v||index_1) + ... + vc*H(v||index_c) + u*mu
+	pubkey *bls12_381_ecc.G2Affine // client public key 客户端公钥
+	pkID   string                  // compressed public key, used to group proofs of the same owner 压缩公钥，用于合并同一公钥的证明
+}
+
+// BatchVerify verify many proofs with one multi-pairing, proofs may come from different files and public keys.
+// With random coefficients r_j, all proofs pass if
+// e(-(r_1*sigma_1 + ... + r_n*sigma_n), g2) * ∏_pk e(∑_{j of pk} r_j*(v_j1*H(v_j||index_j1) + ... + u_j*mu_j), pk) = 1
+// proofs of the same public key share one pairing. If the batch fails, it is bisected to find the invalid proofs.
+// Returns whether all proofs pass and the ascending indices of invalid proofs.
+// 使用一次多重配对批量验证多个证明，证明可以来自不同文件和不同公钥，
+// 选取随机系数r_j，所有证明均有效时上式成立，同一公钥的证明合并为一次配对，
+// 批量验证失败时二分查找无效的证明，返回是否全部有效以及升序排列的无效证明索引
+func BatchVerify(params []VerifyParams) (bool, []int, error) {
+	items := make([]batchItem, 0, len(params))
+	for i, param := range params {
+		if param.Sigma == nil || param.Mu == nil || param.Pubkey == nil || param.Pubkey.P == nil {
+			return false, nil, fmt.Errorf("invalid verify params at %d", i)
+		}
+		point, err := challengePoint(param)
+		if err != nil {
+			return false, nil, fmt.Errorf("invalid verify params at %d, err: %v", i, err)
+		}
+		pkBytes := param.Pubkey.P.Bytes()
+		items = append(items, batchItem{
+			index:  i,
+			sigma:  param.Sigma,
+			point:  point,
+			pubkey: param.Pubkey.P,
+			pkID:   string(pkBytes[:]),
+		})
+	}
+
+	var invalid []int
+	if err := bisectVerify(items, &invalid); err != nil {
+		return false, nil, err
+	}
+	return len(invalid) == 0, invalid, nil
+}
+
+// bisectVerify 批量验证，失败时将证明二分后分别验证，直到定位到单个无效证明
+func bisectVerify(items []batchItem, invalid *[]int) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ok, err := batchCheck(items)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	if len(items) == 1 {
+		*invalid = append(*invalid, items[0].index)
+		return nil
+	}
+
+	mid := len(items) / 2
+	if err := bisectVerify(items[:mid], invalid); err != nil {
+		return err
+	}
+	return bisectVerify(items[mid:], invalid)
+}
+
+// batchCheck 使用随机系数将多个证明合并为一次多重配对检查
+func batchCheck(items []batchItem) (bool, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), batchCoefficientBits)
+
+	var sigmaSum bls12_381_ecc.G1Jac
+	pointSums := make(map[string]*bls12_381_ecc.G1Jac)
+	var pubkeys []*bls12_381_ecc.G2Affine
+	var pkIDs []string
+
+	for _, item := range items {
+		r, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return false, err
+		}
+		r.Add(r, big.NewInt(1))
+
+		// r_j*sigma_j
+		var rs bls12_381_ecc.G1Jac
+		rs.FromAffine(item.sigma)
+		rs.ScalarMultiplication(&rs, r)
+		sigmaSum.AddAssign(&rs)
+
+		// r_j*(v_j1*H(v_j||index_j1) + ... + u_j*mu_j), grouped by public key
+		var rp bls12_381_ecc.G1Jac
+		rp.FromAffine(item.point)
+		rp.ScalarMultiplication(&rp, r)
+		sum, ok := pointSums[item.pkID]
+		if !ok {
+			sum = new(bls12_381_ecc.G1Jac)
+			pointSums[item.pkID] = sum
+			pubkeys = append(pubkeys, item.pubkey)
+			pkIDs = append(pkIDs, item.pkID)
+		}
+		sum.AddAssign(&rp)
+	}
+
+	P := make([]bls12_381_ecc.G1Affine, 0, len(pkIDs)+1)
+	Q := make([]bls12_381_ecc.G2Affine, 0, len(pkIDs)+1)
+
+	var negSigma bls12_381_ecc.G1Affine
+	negSigma.FromJacobian(&sigmaSum)
+	negSigma.Neg(&negSigma)
+	P = append(P, negSigma)
+	Q = append(Q, g2Gen)
+
+	for i, pkID := range pkIDs {
+		var point bls12_381_ecc.G1Affine
+		point.FromJacobian(pointSums[pkID])
+		P = append(P, point)
+		Q = append(Q, *pubkeys[i])
+	}
+
+	return bls12_381_ecc.PairingCheck(P, Q)
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pairing
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12_381_ecc "github.com/consensys/gnark-crypto/ecc/bls12-381"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/merkle"
+)
+
+/*
+	Dynamic PDP with an index hash table, each segment is tagged with a unique identifier instead of its position:
+	sigma_i = sk * ( H(v||id_i) + SHA256(mi||r_j)*u*g1 )
+	The table maps positions to identifiers, identifiers are never reused, so tags of modified or deleted segments
+	can not be replayed. Modify, insert and delete only produce tags for the touched segments, and challenges are
+	generated on identifiers, so proofs are still verified by Verify or BatchVerify.
+	基于索引哈希表的动态副本保持证明，每个段的标签绑定唯一标识而非位置，
+	索引表记录位置到标识的映射，标识永不复用，因此被修改或删除的段的旧标签无法重放，
+	修改、插入和删除只需为涉及的段生成新标签，挑战基于标识生成，证明仍可通过Verify或BatchVerify验证
+*/
+
+var (
+	ErrInvalidPosition = errors.New("invalid segment position")
+)
+
+// IndexTable index hash table of a file, maintained by the data owner 文件的索引哈希表，由数据所有者维护
+type IndexTable struct {
+	IDs    []int // segment identifiers ordered by position 按位置排列的段标识
+	NextID int   // next unused identifier 下一个未使用的标识
+}
+
+// TagParams parameters required to tag updated segments 为更新的段计算标签所需的参数
+type TagParams struct {
+	RandomV *big.Int    // a random V 随机 V
+	RandomU *big.Int    // a random U 随机 U
+	Privkey *PrivateKey // client private key 客户端私钥
+	Round   int64       // challenge round 挑战轮次
+}
+
+// NewIndexTable create the index table of a file with segmentNum segments, the identifier of
+// each segment equals its position, so the tags calculated by CalculateSigmaI remain valid
+// 创建包含segmentNum个段的文件索引表，初始时段标识等于位置，因此已由CalculateSigmaI计算的标签仍然有效
+func NewIndexTable(segmentNum int) *IndexTable {
+	ids := make([]int, segmentNum)
+	for i := range ids {
+		ids[i] = i
+	}
+	return &IndexTable{
+		IDs:    ids,
+		NextID: segmentNum,
+	}
+}
+
+// Len return the number of segments 返回段的数量
+func (t *IndexTable) Len() int {
+	return len(t.IDs)
+}
+
+// ID return the identifier of the segment at position 返回指定位置的段标识
+func (t *IndexTable) ID(position int) (int, error) {
+	if position < 0 || position >= len(t.IDs) {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidPosition, position)
+	}
+	return t.IDs[position], nil
+}
+
+// ModifySegment replace the segment at position, return the tag of the new content 修改指定位置的段，返回新内容的标签
+func (t *IndexTable) ModifySegment(position int, content []byte, param TagParams) (*bls12_381_ecc.G1Affine, error) {
+	if position < 0 || position >= len(t.IDs) {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidPosition, position)
+	}
+
+	id := t.NextID
+	sigma, err := tagSegment(id, content, param)
+	if err != nil {
+		return nil, err
+	}
+	t.IDs[position] = id
+	t.NextID++
+	return sigma, nil
+}
+
+// InsertSegment insert a segment before position, position equal to Len() means append,
+// return the tag of the inserted segment
+// 在指定位置之前插入段，位置等于Len()时表示追加，返回插入段的标签
+func (t *IndexTable) InsertSegment(position int, content []byte, param TagParams) (*bls12_381_ecc.G1Affine, error) {
+	if position < 0 || position > len(t.IDs) {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidPosition, position)
+	}
+
+	id := t.NextID
+	sigma, err := tagSegment(id, content, param)
+	if err != nil {
+		return nil, err
+	}
+	t.IDs = append(t.IDs, 0)
+	copy(t.IDs[position+1:], t.IDs[position:])
+	t.IDs[position] = id
+	t.NextID++
+	return sigma, nil
+}
+
+// DeleteSegment delete the segment at position 删除指定位置的段
+func (t *IndexTable) DeleteSegment(position int) error {
+	if position < 0 || position >= len(t.IDs) {
+		return fmt.Errorf("%w: %d", ErrInvalidPosition, position)
+	}
+	t.IDs = append(t.IDs[:position], t.IDs[position+1:]...)
+	return nil
+}
+
+// GenerateChallenge generate a random challenge on segment positions for a specified round,
+// the returned indices are identifiers of the segments
+// 针对指定位置的段生成指定轮次的随机挑战，返回的索引为段标识
+func (t *IndexTable) GenerateChallenge(positions []int, round int64, privkey *PrivateKey) ([]*big.Int, []*big.Int, []byte, error) {
+	ids := make([]int, 0, len(positions))
+	for _, position := range positions {
+		id, err := t.ID(position)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ids = append(ids, id)
+	}
+	return GenerateChallenge(ids, round, privkey)
+}
+
+// Root calculate the merkle root of the table, which can be published as the commitment of segment positions
+// 计算索引表的默克尔根，可作为段位置的承诺对外公布
+func (t *IndexTable) Root() []byte {
+	leaves := make([][]byte, 0, len(t.IDs)+1)
+	// 第一个叶子为NextID，保证删除后追加的段不会复用标识
+	leaves = append(leaves, encodeID(t.NextID))
+	for _, id := range t.IDs {
+		leaves = append(leaves, encodeID(id))
+	}
+	return merkle.GetMerkleRootWithMode(leaves, merkle.HashModeDomainSeparated)
+}
+
+// tagSegment 计算绑定段标识的标签
+func tagSegment(id int, content []byte, param TagParams) (*bls12_381_ecc.G1Affine, error) {
+	if param.RandomV == nil || param.RandomU == nil || param.Privkey == nil {
+		return nil, fmt.Errorf("invalid tag params")
+	}
+	return CalculateSigmaI(CalculateSigmaIParams{
+		Content: content,
+		Index:   big.NewInt(int64(id)),
+		RandomV: param.RandomV,
+		RandomU: param.RandomU,
+		Privkey: param.Privkey,
+		Round:   param.Round,
+	})
+}
+
+func encodeID(id int) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(id))
+	return buf
+}
@@ -15,6 +15,7 @@ package pairing
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"io"
 	"io/ioutil"
 	"math/big"
@@ -142,3 +143,478 @@ func removeFiles(fileNames []string) {
 		os.Remove(fileName)
 	}
 }
+
+func TestBatchVerify(t *testing.T) {
+	challengeRound := int64(7)
+	keys := make([]*PrivateKey, 2)
+	pubkeys := make([]*PublicKey, 2)
+	for i := range keys {
+		sk, pk, err := GenKeyPair()
+		if err != nil {
+			t.Fatalf("failed to generate keypair, err: %v", err)
+		}
+		keys[i], pubkeys[i] = sk, pk
+	}
+
+	// 6 files with 4 segments each, owned by 2 public keys 6个文件，每个4段，分属2个公钥
+	var params []VerifyParams
+	for f := 0; f < 6; f++ {
+		sk, pk := keys[f%2], pubkeys[f%2]
+		randomU, _ := RandomWithinOrder()
+		randomV, _ := RandomWithinOrder()
+
+		var contents [][]byte
+		var sigmas []*bls12_381_ecc.G1Affine
+		var indexList []int
+		for i := 0; i < 4; i++ {
+			content := make([]byte, 1024)
+			if _, err := io.ReadFull(rand.Reader, content); err != nil {
+				t.Fatalf("failed to read random bytes: %v", err)
+			}
+			sigma, err := CalculateSigmaI(CalculateSigmaIParams{
+				Content: content,
+				Index:   big.NewInt(int64(i)),
+				RandomV: randomV,
+				RandomU: randomU,
+				Privkey: sk,
+				Round:   challengeRound,
+			})
+			if err != nil {
+				t.Fatalf("failed to calculate sigma, err: %v", err)
+			}
+			contents = append(contents, content)
+			sigmas = append(sigmas, sigma)
+			indexList = append(indexList, i)
+		}
+
+		indices, vs, randSeed, err := GenerateChallenge(indexList, challengeRound, sk)
+		if err != nil {
+			t.Fatalf("failed to generate challenge, err: %v", err)
+		}
+		sigma, mu, err := Prove(ProofParams{
+			Content:       contents,
+			Indices:       indices,
+			RandomVs:      vs,
+			Sigmas:        sigmas,
+			RandThisRound: randSeed,
+		})
+		if err != nil {
+			t.Fatalf("failed to generate proof, err: %v", err)
+		}
+		params = append(params, VerifyParams{
+			Sigma:    sigma,
+			Mu:       mu,
+			RandomV:  randomV,
+			RandomU:  randomU,
+			Indices:  indices,
+			RandomVs: vs,
+			Pubkey:   pk,
+		})
+	}
+
+	ok, invalid, err := BatchVerify(params)
+	if err != nil || !ok || len(invalid) != 0 {
+		t.Fatalf("batch verification failed, ok: %v, invalid: %v, err: %v", ok, invalid, err)
+	}
+
+	// tamper proofs 1 and 4 篡改第1和第4个证明
+	params[1].Mu = params[0].Mu
+	params[4].Pubkey = pubkeys[1]
+	ok, invalid, err = BatchVerify(params)
+	if err != nil || ok || len(invalid) != 2 || invalid[0] != 1 || invalid[1] != 4 {
+		t.Fatalf("failed to find invalid proofs, ok: %v, invalid: %v, err: %v", ok, invalid, err)
+	}
+	for i, param := range params {
+		v, err := Verify(param)
+		if err != nil {
+			t.Fatalf("failed to verify, err: %v", err)
+		}
+		if v != (i != 1 && i != 4) {
+			t.Errorf("unexpected verification result of proof %d", i)
+		}
+	}
+
+	params[2].RandomVs = params[2].RandomVs[1:]
+	if _, _, err := BatchVerify(params); err == nil {
+		t.Errorf("expected error for invalid params")
+	}
+}
+
+func TestDynamicPDP(t *testing.T) {
+	challengeRound := int64(3)
+	sk, pk, err := GenKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate keypair, err: %v", err)
+	}
+	randomU, _ := RandomWithinOrder()
+	randomV, _ := RandomWithinOrder()
+	param := TagParams{
+		RandomV: randomV,
+		RandomU: randomU,
+		Privkey: sk,
+		Round:   challengeRound,
+	}
+	randomContent := func() []byte {
+		content := make([]byte, 512)
+		if _, err := io.ReadFull(rand.Reader, content); err != nil {
+			t.Fatalf("failed to read random bytes: %v", err)
+		}
+		return content
+	}
+
+	// tag the original file by position 按位置为原始文件计算标签
+	var contents [][]byte
+	var sigmas []*bls12_381_ecc.G1Affine
+	for i := 0; i < 5; i++ {
+		content := randomContent()
+		sigma, err := CalculateSigmaI(CalculateSigmaIParams{
+			Content: content,
+			Index:   big.NewInt(int64(i)),
+			RandomV: randomV,
+			RandomU: randomU,
+			Privkey: sk,
+			Round:   challengeRound,
+		})
+		if err != nil {
+			t.Fatalf("failed to calculate sigma, err: %v", err)
+		}
+		contents = append(contents, content)
+		sigmas = append(sigmas, sigma)
+	}
+	table := NewIndexTable(len(contents))
+
+	prove := func(contents [][]byte, sigmas []*bls12_381_ecc.G1Affine) bool {
+		var positions []int
+		for i := range contents {
+			positions = append(positions, i)
+		}
+		indices, vs, randSeed, err := table.GenerateChallenge(positions, challengeRound, sk)
+		if err != nil {
+			t.Fatalf("failed to generate challenge, err: %v", err)
+		}
+		sigma, mu, err := Prove(ProofParams{
+			Content:       contents,
+			Indices:       indices,
+			RandomVs:      vs,
+			Sigmas:        sigmas,
+			RandThisRound: randSeed,
+		})
+		if err != nil {
+			t.Fatalf("failed to generate proof, err: %v", err)
+		}
+		v, err := Verify(VerifyParams{
+			Sigma:    sigma,
+			Mu:       mu,
+			RandomV:  randomV,
+			RandomU:  randomU,
+			Indices:  indices,
+			RandomVs: vs,
+			Pubkey:   pk,
+		})
+		if err != nil {
+			t.Fatalf("failed to verify, err: %v", err)
+		}
+		return v
+	}
+	if !prove(contents, sigmas) {
+		t.Fatalf("verification of original file failed")
+	}
+	root := table.Root()
+
+	// modify segment 1 修改第1段
+	oldContent, oldSigma := contents[1], sigmas[1]
+	contents[1] = randomContent()
+	if sigmas[1], err = table.ModifySegment(1, contents[1], param); err != nil {
+		t.Fatalf("failed to modify segment, err: %v", err)
+	}
+	// insert before segment 2 and append 在第2段之前插入，并在末尾追加
+	content := randomContent()
+	sigma, err := table.InsertSegment(2, content, param)
+	if err != nil {
+		t.Fatalf("failed to insert segment, err: %v", err)
+	}
+	contents = append(contents[:2], append([][]byte{content}, contents[2:]...)...)
+	sigmas = append(sigmas[:2], append([]*bls12_381_ecc.G1Affine{sigma}, sigmas[2:]...)...)
+	content = randomContent()
+	if sigma, err = table.InsertSegment(table.Len(), content, param); err != nil {
+		t.Fatalf("failed to append segment, err: %v", err)
+	}
+	contents, sigmas = append(contents, content), append(sigmas, sigma)
+	// delete segment 4 删除第4段
+	if err := table.DeleteSegment(4); err != nil {
+		t.Fatalf("failed to delete segment, err: %v", err)
+	}
+	contents = append(contents[:4], contents[5:]...)
+	sigmas = append(sigmas[:4], sigmas[5:]...)
+
+	if table.Len() != 6 || len(contents) != 6 {
+		t.Fatalf("unexpected segment number %d", table.Len())
+	}
+	if !prove(contents, sigmas) {
+		t.Errorf("verification of updated file failed")
+	}
+	if string(root) == string(table.Root()) {
+		t.Errorf("root of index table should change after update")
+	}
+
+	// the old version of a modified segment can not be replayed 被修改段的旧版本无法重放
+	contents[1], sigmas[1] = oldContent, oldSigma
+	if prove(contents, sigmas) {
+		t.Errorf("replayed segment should not pass verification")
+	}
+
+	if _, err := table.ModifySegment(6, content, param); err == nil {
+		t.Errorf("expected error for invalid position")
+	}
+	if err := table.DeleteSegment(-1); err == nil {
+		t.Errorf("expected error for invalid position")
+	}
+}
+
+func TestReplicaPDP(t *testing.T) {
+	challengeRound := int64(5)
+	sk, pk, err := GenKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate keypair, err: %v", err)
+	}
+	randomU, _ := RandomWithinOrder()
+	randomV, _ := RandomWithinOrder()
+
+	var contents [][]byte
+	var indexList []int
+	for i := 0; i < 4; i++ {
+		content := make([]byte, 100)
+		if _, err := io.ReadFull(rand.Reader, content); err != nil {
+			t.Fatalf("failed to read random bytes: %v", err)
+		}
+		contents = append(contents, content)
+		indexList = append(indexList, i)
+	}
+
+	// build and tag 3 replicas 生成3个副本并分别计算标签
+	replicas := make([][][]byte, 3)
+	sigmas := make([][]*bls12_381_ecc.G1Affine, 3)
+	for r := range replicas {
+		key, err := GenReplicaKey(r, 16)
+		if err != nil {
+			t.Fatalf("failed to generate replica key, err: %v", err)
+		}
+		replicas[r] = EncodeReplica(key, contents)
+		for i, content := range DecodeReplica(key, replicas[r]) {
+			if string(content) != string(contents[i]) {
+				t.Fatalf("failed to decode replica %d", r)
+			}
+		}
+		for i, segment := range replicas[r] {
+			sigma, err := CalculateReplicaSigmaI(r, CalculateSigmaIParams{
+				Content: segment,
+				Index:   big.NewInt(int64(i)),
+				RandomV: randomV,
+				RandomU: randomU,
+				Privkey: sk,
+				Round:   challengeRound,
+			})
+			if err != nil {
+				t.Fatalf("failed to calculate sigma, err: %v", err)
+			}
+			sigmas[r] = append(sigmas[r], sigma)
+		}
+	}
+	if string(replicas[0][0]) == string(replicas[1][0]) || string(replicas[1][0]) == string(replicas[2][0]) {
+		t.Fatalf("replicas should be distinct")
+	}
+
+	// masks depend on the previous encoded segment 掩码依赖前一个已编码的段
+	key, err := GenReplicaKey(0, 16)
+	if err != nil {
+		t.Fatalf("failed to generate replica key, err: %v", err)
+	}
+	changed := append([][]byte{append([]byte{}, contents[0]...)}, contents[1:]...)
+	changed[0][0] ^= 1
+	encoded, changedEncoded := EncodeReplica(key, contents), EncodeReplica(key, changed)
+	for i := 1; i < len(contents); i++ {
+		maskA := MaskSegment(key, i, encoded[i-1], make([]byte, 32))
+		maskB := MaskSegment(key, i, changedEncoded[i-1], make([]byte, 32))
+		if string(maskA) == string(maskB) {
+			t.Fatalf("mask of segment %d should depend on the previous segment", i)
+		}
+	}
+
+	// prove replica r with the data stored as replica stored 使用stored副本的数据证明副本r
+	prove := func(r, stored int) (VerifyParams, error) {
+		indices, vs, randSeed, err := GenerateReplicaChallenge(r, indexList, challengeRound, sk)
+		if err != nil {
+			return VerifyParams{}, err
+		}
+		sigma, mu, err := Prove(ProofParams{
+			Content:       replicas[stored],
+			Indices:       indices,
+			RandomVs:      vs,
+			Sigmas:        sigmas[stored],
+			RandThisRound: randSeed,
+		})
+		if err != nil {
+			return VerifyParams{}, err
+		}
+		return VerifyParams{
+			Sigma:    sigma,
+			Mu:       mu,
+			RandomV:  randomV,
+			RandomU:  randomU,
+			Indices:  indices,
+			RandomVs: vs,
+			Pubkey:   pk,
+		}, nil
+	}
+
+	for r := 0; r < 3; r++ {
+		param, err := prove(r, r)
+		if err != nil {
+			t.Fatalf("failed to prove replica %d, err: %v", r, err)
+		}
+		if v, err := VerifyReplica(r, param); err != nil || !v {
+			t.Errorf("verification of replica %d failed, err: %v", r, err)
+		}
+		if _, err := VerifyReplica((r+1)%3, param); err == nil {
+			t.Errorf("proof of replica %d should not be accepted for replica %d", r, (r+1)%3)
+		}
+		if v, _ := Verify(param); !v {
+			t.Errorf("verification of replica %d failed", r)
+		}
+	}
+
+	// a node keeping only replica 0 can not answer challenges for replica 1 只保存副本0的节点无法应答副本1的挑战
+	param, err := prove(1, 0)
+	if err != nil {
+		t.Fatalf("failed to prove, err: %v", err)
+	}
+	if v, _ := VerifyReplica(1, param); v {
+		t.Errorf("replica 1 proved with data of replica 0")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	sk, pk, err := GenKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate keypair, err: %v", err)
+	}
+	randomU, _ := RandomWithinOrder()
+	randomV, _ := RandomWithinOrder()
+
+	// the owner tags the file once 数据所有者一次性计算标签
+	segmentNum := 10
+	var contents [][]byte
+	var sigmas []*bls12_381_ecc.G1Affine
+	for i := 0; i < segmentNum; i++ {
+		content := make([]byte, 256)
+		if _, err := io.ReadFull(rand.Reader, content); err != nil {
+			t.Fatalf("failed to read random bytes: %v", err)
+		}
+		sigma, err := CalculateAuditSigmaI(CalculateSigmaIParams{
+			Content: content,
+			Index:   big.NewInt(int64(i)),
+			RandomV: randomV,
+			RandomU: randomU,
+			Privkey: sk,
+		})
+		if err != nil {
+			t.Fatalf("failed to calculate sigma, err: %v", err)
+		}
+		contents = append(contents, content)
+		sigmas = append(sigmas, sigma)
+	}
+
+	// the challenge is derived from a block hash 由区块哈希派生挑战
+	beacon := []byte("block hash of height 100")
+	indices, vs, err := DeriveAuditChallenge(beacon, segmentNum, 4)
+	if err != nil {
+		t.Fatalf("failed to derive challenge, err: %v", err)
+	}
+	seen := make(map[int64]bool)
+	for _, index := range indices {
+		if index.Int64() < 0 || index.Int64() >= int64(segmentNum) || seen[index.Int64()] {
+			t.Fatalf("invalid challenged index %v", index)
+		}
+		seen[index.Int64()] = true
+	}
+	indices2, vs2, _ := DeriveAuditChallenge(beacon, segmentNum, 4)
+	for i := range indices {
+		if indices[i].Cmp(indices2[i]) != 0 || vs[i].Cmp(vs2[i]) != 0 {
+			t.Fatalf("challenge should be deterministic")
+		}
+	}
+	if _, _, err := DeriveAuditChallenge(beacon, 3, 4); err == nil {
+		t.Errorf("expected error for too many challenged segments")
+	}
+
+	proofParam := AuditProofParams{
+		Indices:  indices,
+		RandomVs: vs,
+		RandomU:  randomU,
+	}
+	for _, index := range indices {
+		proofParam.Content = append(proofParam.Content, contents[index.Int64()])
+		proofParam.Sigmas = append(proofParam.Sigmas, sigmas[index.Int64()])
+	}
+	proof, err := AuditProve(proofParam)
+	if err != nil {
+		t.Fatalf("failed to generate audit proof, err: %v", err)
+	}
+	verifyParam := AuditVerifyParams{
+		Proof:    proof,
+		RandomV:  randomV,
+		RandomU:  randomU,
+		Indices:  indices,
+		RandomVs: vs,
+		Pubkey:   pk,
+	}
+	if v, err := AuditVerify(verifyParam); err != nil || !v {
+		t.Fatalf("audit verification failed, err: %v", err)
+	}
+
+	// proofs are blinded, proving the same challenge twice gives different mu 证明经过盲化，同一挑战的两次证明不同
+	proof2, err := AuditProve(proofParam)
+	if err != nil {
+		t.Fatalf("failed to generate audit proof, err: %v", err)
+	}
+	if proof.Mu[0].Cmp(proof2.Mu[0]) == 0 {
+		t.Errorf("audit proofs should be blinded")
+	}
+
+	// corrupted content or another challenge fails 内容损坏或挑战不同时验证失败
+	proofParam.Content[0] = contents[(indices[0].Int64()+1)%int64(segmentNum)]
+	bad, err := AuditProve(proofParam)
+	if err != nil {
+		t.Fatalf("failed to generate audit proof, err: %v", err)
+	}
+	verifyParam.Proof = bad
+	if v, _ := AuditVerify(verifyParam); v {
+		t.Errorf("corrupted content passed audit")
+	}
+	otherIndices, otherVs, _ := DeriveAuditChallenge([]byte("another block hash"), segmentNum, 4)
+	verifyParam.Proof, verifyParam.Indices, verifyParam.RandomVs = proof, otherIndices, otherVs
+	if v, _ := AuditVerify(verifyParam); v {
+		t.Errorf("proof passed audit of another challenge")
+	}
+
+	// a prover keeping only one digest per segment fails 只保存每个段摘要的证明者无法通过审计
+	verifyParam.Indices, verifyParam.RandomVs = indices, vs
+	for i, index := range indices {
+		digest := sha256.Sum256(contents[index.Int64()])
+		proofParam.Content[i] = digest[:]
+	}
+	digestProof, err := AuditProve(proofParam)
+	if err != nil {
+		t.Fatalf("failed to generate audit proof, err: %v", err)
+	}
+	verifyParam.Proof = digestProof
+	if v, _ := AuditVerify(verifyParam); v {
+		t.Errorf("digest only prover passed audit")
+	}
+
+	// a prover cannot collapse the sectors into a single value 证明者无法将扇区合并为单个值
+	verifyParam.Proof = &AuditProof{Sigma: proof.Sigma, R: proof.R, Mu: proof.Mu[:1]}
+	if v, _ := AuditVerify(verifyParam); v {
+		t.Errorf("truncated proof passed audit")
+	}
+}
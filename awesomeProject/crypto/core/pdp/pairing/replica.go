@@ -0,0 +1,169 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pairing
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12_381_ecc "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+/*
+	Multi-replica PDP, each replica is masked with its own key before tagging, so replicas are physically different:
+	  replica_r[i] = m_i XOR mask(key_r, i, replica_r[i-1])
+	  sigma_r_i    = sk * ( H(v||index_r_i) + SHA256(replica_r[i]||r_j)*u*g1 )，index_r_i = (r+1)*2^64 + i
+	The mask of segment i is derived by a sequential hash chain of Iterations rounds over the key and the encoded
+	segment i-1, so masks depend on the data and encoding is sequential: regenerating segment i of a replica requires
+	all segments before it, and masks can not be computed before the data is known.
+	The seed is the secret of the data owner and must never be sent to the storage node, a node holding the seed of a
+	replica can always rebuild it from another copy; decoding only needs the replica itself and the seed.
+	Each replica is challenged and verified with its replica ID, and a proof of one replica is never accepted for another.
+	多副本保持证明，每个副本在计算标签前使用独立的密钥掩码，使各副本在物理上互不相同，
+	第i段的掩码由密钥和已编码的第i-1段经Iterations轮的顺序哈希链生成，掩码依赖数据且编码只能顺序进行，
+	重新生成副本的第i段需要先生成其之前的所有段，在获得数据之前也无法预先计算掩码；
+	种子是数据所有者的秘密，不能发送给存储节点，持有副本种子的节点总能由其他副本重建该副本；
+	每个副本使用副本ID独立挑战和验证，一个副本的证明不会被另一个副本接受
+*/
+
+var (
+	ErrInvalidReplica = errors.New("invalid replica")
+)
+
+// DefaultMaskIterations default rounds of hash chain to derive the mask of a segment 生成段掩码的默认哈希链轮数
+const DefaultMaskIterations = 1 << 16
+
+// ReplicaKey key of a replica 副本密钥
+type ReplicaKey struct {
+	ReplicaID  int      // replica ID, starts from 0 副本ID，从0开始
+	Seed       *big.Int // random seed generated by RandomWithinOrder 随机种子
+	Iterations int      // rounds of hash chain to derive masks 生成掩码的哈希链轮数
+}
+
+// GenReplicaKey generate the key of a replica 生成副本密钥
+func GenReplicaKey(replicaID, iterations int) (*ReplicaKey, error) {
+	if replicaID < 0 || iterations <= 0 {
+		return nil, fmt.Errorf("%w: replica id %d, iterations %d", ErrInvalidReplica, replicaID, iterations)
+	}
+	seed, err := RandomWithinOrder()
+	if err != nil {
+		return nil, err
+	}
+	return &ReplicaKey{
+		ReplicaID:  replicaID,
+		Seed:       seed,
+		Iterations: iterations,
+	}, nil
+}
+
+// EncodeReplica mask all segments of a file in order to build a replica, each mask depends on the previous
+// encoded segment
+// 按顺序对文件的所有段掩码得到副本，每段的掩码依赖前一个已编码的段
+func EncodeReplica(key *ReplicaKey, contents [][]byte) [][]byte {
+	replica := make([][]byte, len(contents))
+	var prev []byte
+	for i, content := range contents {
+		replica[i] = MaskSegment(key, i, prev, content)
+		prev = replica[i]
+	}
+	return replica
+}
+
+// DecodeReplica recover the original segments from a replica 从副本恢复原始段
+func DecodeReplica(key *ReplicaKey, replica [][]byte) [][]byte {
+	// 掩码为异或，解码时前一个已编码的段直接取自副本
+	contents := make([][]byte, len(replica))
+	var prev []byte
+	for i, segment := range replica {
+		contents[i] = MaskSegment(key, i, prev, segment)
+		prev = segment
+	}
+	return contents
+}
+
+// MaskSegment mask or unmask the segment at index, prev is the encoded segment at index-1 and nil for the first one,
+// the mask is derived from prev by a hash chain of key.Iterations rounds
+// 对指定索引的段掩码或去掩码，prev为已编码的前一段，第一段为nil，掩码由prev经key.Iterations轮的哈希链生成
+func MaskSegment(key *ReplicaKey, index int, prev, content []byte) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], uint64(key.ReplicaID))
+	binary.BigEndian.PutUint64(buf[8:], uint64(index))
+	prevDigest := sha256.Sum256(prev)
+	state := sha256.Sum256(append(append(key.Seed.Bytes(), buf...), prevDigest[:]...))
+	for i := 0; i < key.Iterations; i++ {
+		state = sha256.Sum256(state[:])
+	}
+
+	res := make([]byte, len(content))
+	counter := make([]byte, 8)
+	for offset := 0; offset < len(content); offset += sha256.Size {
+		binary.BigEndian.PutUint64(counter, uint64(offset/sha256.Size))
+		block := sha256.Sum256(append(state[:], counter...))
+		for j := 0; j < sha256.Size && offset+j < len(content); j++ {
+			res[offset+j] = content[offset+j] ^ block[j]
+		}
+	}
+	return res
+}
+
+// ReplicaIndex the index of a segment in a replica, (replicaID+1)*2^64 + index 副本中段的索引，
+// 与未分副本时的段索引及其他副本的段索引均不相同
+func ReplicaIndex(replicaID int, index *big.Int) *big.Int {
+	res := new(big.Int).Lsh(big.NewInt(int64(replicaID)+1), 64)
+	return res.Add(res, index)
+}
+
+// CalculateReplicaSigmaI calculate the tag of a masked segment in a replica, param.Content is the masked
+// content and param.Index is the segment index
+// 计算副本中已掩码段的标签，param.Content为掩码后的内容，param.Index为段索引
+func CalculateReplicaSigmaI(replicaID int, param CalculateSigmaIParams) (*bls12_381_ecc.G1Affine, error) {
+	if replicaID < 0 || param.Index == nil || param.Index.Sign() < 0 || param.Index.BitLen() > 64 {
+		return nil, ErrInvalidReplica
+	}
+	param.Index = ReplicaIndex(replicaID, param.Index)
+	return CalculateSigmaI(param)
+}
+
+// GenerateReplicaChallenge generate a random challenge of a replica for a specified round, the returned indices
+// are bound to the replica
+// 针对指定副本生成指定轮次的随机挑战，返回的索引与副本绑定
+func GenerateReplicaChallenge(replicaID int, indexList []int, round int64, privkey *PrivateKey) ([]*big.Int, []*big.Int, []byte, error) {
+	if replicaID < 0 {
+		return nil, nil, nil, ErrInvalidReplica
+	}
+	indices, randomVs, randThisRound, err := GenerateChallenge(indexList, round, privkey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i, index := range indices {
+		indices[i] = ReplicaIndex(replicaID, index)
+	}
+	return indices, randomVs, randThisRound, nil
+}
+
+// VerifyReplica verify the proof of a replica, all challenged indices must belong to the replica 验证副本的证明，被挑战的索引必须属于该副本
+func VerifyReplica(replicaID int, param VerifyParams) (bool, error) {
+	if replicaID < 0 {
+		return false, ErrInvalidReplica
+	}
+	for _, index := range param.Indices {
+		if new(big.Int).Rsh(index, 64).Cmp(big.NewInt(int64(replicaID)+1)) != 0 {
+			return false, fmt.Errorf("%w: index %v does not belong to replica %d", ErrInvalidReplica, index, replicaID)
+		}
+	}
+	return Verify(param)
+}
@@ -30,11 +30,30 @@ func Verify(param VerifyParams) (bool, error) {
 		return false, err
 	}
 
+	add, err := challengePoint(param)
+	if err != nil {
+		return false, err
+	}
+
+	right, err := bls12_381_ecc.Pair([]bls12_381_ecc.G1Affine{*add}, []bls12_381_ecc.G2Affine{*param.Pubkey.P})
+	if err != nil {
+		return false, err
+	}
+
+	return left.Equal(&right), nil
+}
+
+// challengePoint calculate v1*H(v||index_1) + ... + vc*H(v||index_c) + u*mu 计算验证等式右侧的G1点
+func challengePoint(param VerifyParams) (*bls12_381_ecc.G1Affine, error) {
+	if len(param.Indices) != len(param.RandomVs) || len(param.Indices) == 0 {
+		return nil, fmt.Errorf("invalid verify params, %d indices and %d random numbers", len(param.Indices), len(param.RandomVs))
+	}
+
 	vh := new(bls12_381_ecc.G1Affine)
 	for i := 0; i < len(param.Indices); i++ {
 		vi, err := concatBigInt([]*big.Int{param.RandomV, param.Indices[i]}, order)
 		if err != nil {
-			return false, fmt.Errorf("failed to concat %v and %v, err: %v", param.RandomV, param.Indices[i], err)
+			return nil, fmt.Errorf("failed to concat %v and %v, err: %v", param.RandomV, param.Indices[i], err)
 		}
 
 		hi := hashToG1(vi)
@@ -46,12 +65,5 @@ func Verify(param VerifyParams) (bool, error) {
 		}
 	}
 	umu := new(bls12_381_ecc.G1Affine).ScalarMultiplication(param.Mu, param.RandomU)
-	add := new(bls12_381_ecc.G1Affine).Add(vh, umu)
-
-	right, err := bls12_381_ecc.Pair([]bls12_381_ecc.G1Affine{*add}, []bls12_381_ecc.G2Affine{*param.Pubkey.P})
-	if err != nil {
-		return false, err
-	}
-
-	return left.Equal(&right), nil
+	return new(bls12_381_ecc.G1Affine).Add(vh, umu), nil
 }
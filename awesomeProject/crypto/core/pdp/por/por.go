@@ -0,0 +1,215 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package por
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12_381_ecc "github.com/consensys/gnark-crypto/ecc/bls12-381"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/pairing"
+)
+
+/*
+	Proof of retrievability on top of pairing based PDP. The file is Reed-Solomon encoded into
+	DataShards+ParityShards segments before tagging, so the file can only become unrecoverable
+	if more than ParityShards segments are damaged, which is detected by a challenge of a few segments
+	with high probability. The extractor checks the segments returned by the storage node with the
+	tags and recovers the file from any DataShards valid segments.
+	基于配对副本保持证明的可恢复性证明，文件在计算标签前经Reed-Solomon编码为DataShards+ParityShards个段，
+	只有超过ParityShards个段损坏时文件才无法恢复，而这种损坏可以被少量段的挑战以很高的概率发现；
+	提取器使用标签检查存储节点返回的段，并从任意DataShards个有效段中恢复文件
+*/
+
+var (
+	ErrNotEnoughSegments  = errors.New("not enough valid segments to extract the file")
+	ErrInvalidProbability = errors.New("probability must within (0, 1]")
+)
+
+// FileMeta metadata of an encoded file 编码后文件的元数据
+type FileMeta struct {
+	Size         int // size of the original file 原始文件大小
+	DataShards   int // number of data segments 数据段数量
+	ParityShards int // number of parity segments 校验段数量
+}
+
+// EncodedFile erasure coded file 纠删码编码后的文件
+type EncodedFile struct {
+	FileMeta
+	Segments [][]byte // data segments followed by parity segments 数据段和校验段
+}
+
+// SegmentResponse a segment and its tag returned by the storage node 存储节点返回的段及其标签
+type SegmentResponse struct {
+	Index   int                     // segment index 段索引
+	Content []byte                  // segment content 段内容
+	Sigma   *bls12_381_ecc.G1Affine // tag of the segment 段的标签
+}
+
+// ExtractParams parameters required to extract a file 提取文件所需的参数
+type ExtractParams struct {
+	Meta          FileMeta
+	RandomV       *big.Int           // a random V used in tagging 计算标签时的随机 V
+	RandomU       *big.Int           // a random U used in tagging 计算标签时的随机 U
+	Pubkey        *pairing.PublicKey // client public key 客户端公钥
+	RandThisRound []byte             // random number of the tagging round 计算标签轮次的随机数
+}
+
+// EncodeFile erasure code a file into dataShards+parityShards segments 将文件纠删码编码为dataShards+parityShards个段
+func EncodeFile(content []byte, dataShards, parityShards int) (*EncodedFile, error) {
+	enc, err := NewEncoder(dataShards, parityShards)
+	if err != nil {
+		return nil, err
+	}
+	segments := enc.Split(content)
+	if err := enc.Encode(segments); err != nil {
+		return nil, err
+	}
+
+	return &EncodedFile{
+		FileMeta: FileMeta{
+			Size:         len(content),
+			DataShards:   dataShards,
+			ParityShards: parityShards,
+		},
+		Segments: segments,
+	}, nil
+}
+
+// TagSegments calculate tags of all segments, the index of each segment is its position 计算所有段的标签，段索引即位置
+func TagSegments(segments [][]byte, param pairing.TagParams) ([]*bls12_381_ecc.G1Affine, error) {
+	sigmas := make([]*bls12_381_ecc.G1Affine, 0, len(segments))
+	for i, segment := range segments {
+		sigma, err := pairing.CalculateSigmaI(pairing.CalculateSigmaIParams{
+			Content: segment,
+			Index:   big.NewInt(int64(i)),
+			RandomV: param.RandomV,
+			RandomU: param.RandomU,
+			Privkey: param.Privkey,
+			Round:   param.Round,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to tag segment %d, err: %v", i, err)
+		}
+		sigmas = append(sigmas, sigma)
+	}
+	return sigmas, nil
+}
+
+// Extract check the returned segments with their tags, and recover the file from valid segments 使用标签检查返回的段，并从有效段中恢复文件
+func Extract(param ExtractParams, responses []SegmentResponse) ([]byte, error) {
+	enc, err := NewEncoder(param.Meta.DataShards, param.Meta.ParityShards)
+	if err != nil {
+		return nil, err
+	}
+	total := param.Meta.DataShards + param.Meta.ParityShards
+
+	// 每个段单独构造 v=1 的证明，批量验证后剔除无效段
+	var candidates []SegmentResponse
+	var verifyParams []pairing.VerifyParams
+	seen := make(map[int]bool)
+	for _, resp := range responses {
+		if resp.Index < 0 || resp.Index >= total || resp.Sigma == nil || seen[resp.Index] {
+			continue
+		}
+		index := big.NewInt(int64(resp.Index))
+		sigma, mu, err := pairing.Prove(pairing.ProofParams{
+			Content:       [][]byte{resp.Content},
+			Indices:       []*big.Int{index},
+			RandomVs:      []*big.Int{big.NewInt(1)},
+			Sigmas:        []*bls12_381_ecc.G1Affine{resp.Sigma},
+			RandThisRound: param.RandThisRound,
+		})
+		if err != nil {
+			return nil, err
+		}
+		seen[resp.Index] = true
+		candidates = append(candidates, resp)
+		verifyParams = append(verifyParams, pairing.VerifyParams{
+			Sigma:    sigma,
+			Mu:       mu,
+			RandomV:  param.RandomV,
+			RandomU:  param.RandomU,
+			Indices:  []*big.Int{index},
+			RandomVs: []*big.Int{big.NewInt(1)},
+			Pubkey:   param.Pubkey,
+		})
+	}
+	if len(candidates) < param.Meta.DataShards {
+		return nil, ErrNotEnoughSegments
+	}
+
+	_, invalid, err := pairing.BatchVerify(verifyParams)
+	if err != nil {
+		return nil, err
+	}
+	bad := make(map[int]bool)
+	for _, i := range invalid {
+		bad[i] = true
+	}
+
+	shards := make([][]byte, total)
+	valid := 0
+	for i, resp := range candidates {
+		if bad[i] {
+			continue
+		}
+		shards[resp.Index] = resp.Content
+		valid++
+	}
+	if valid < param.Meta.DataShards {
+		return nil, fmt.Errorf("%w: %d valid segments, %d required", ErrNotEnoughSegments, valid, param.Meta.DataShards)
+	}
+
+	if err := enc.Reconstruct(shards); err != nil {
+		return nil, err
+	}
+	return enc.Join(shards, param.Meta.Size)
+}
+
+// ChallengeSize calculate the minimum number of segments to challenge, so that if at least corrupted of total
+// segments are corrupted, at least one corrupted segment is challenged with probability not less than probability
+// 计算最少需要挑战的段数，使得total个段中至少有corrupted个段损坏时，挑战到损坏段的概率不低于probability
+//
+//	P = 1 - ∏_{i=0}^{c-1} (total-corrupted-i)/(total-i)
+func ChallengeSize(total, corrupted int, probability float64) (int, error) {
+	if total <= 0 || corrupted <= 0 || corrupted > total {
+		return 0, fmt.Errorf("invalid segment number, total: %d, corrupted: %d", total, corrupted)
+	}
+	if probability <= 0 || probability > 1 {
+		return 0, ErrInvalidProbability
+	}
+	if probability == 1 {
+		// 只有挑战数超过未损坏段数时才能确保发现损坏
+		return total - corrupted + 1, nil
+	}
+
+	miss := 1.0
+	for c := 1; c <= total; c++ {
+		miss *= float64(total-corrupted-c+1) / float64(total-c+1)
+		if 1-miss >= probability {
+			return c, nil
+		}
+	}
+	return total, nil
+}
+
+// ChallengeSize calculate the minimum number of segments to challenge, so that the loss of the file,
+// i.e. more than ParityShards segments are damaged, is detected with probability not less than probability
+// 计算最少需要挑战的段数，使得文件无法恢复（即超过ParityShards个段损坏）时被发现的概率不低于probability
+func (m FileMeta) ChallengeSize(probability float64) (int, error) {
+	return ChallengeSize(m.DataShards+m.ParityShards, m.ParityShards+1, probability)
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package por
+
+import (
+	"crypto/rand"
+	"math"
+	"testing"
+
+	bls12_381_ecc "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/pdp/pairing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	data := make([]byte, 1000)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	enc, err := NewEncoder(5, 3)
+	require.NoError(t, err)
+	shards := enc.Split(data)
+	require.NoError(t, enc.Encode(shards))
+	ok, err := enc.Verify(shards)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// 任意丢失不超过3个分片均可恢复
+	for mask := 0; mask < 1<<8; mask++ {
+		lost := 0
+		damaged := make([][]byte, len(shards))
+		for i := range shards {
+			if mask&(1<<uint(i)) != 0 {
+				lost++
+				continue
+			}
+			damaged[i] = append([]byte{}, shards[i]...)
+		}
+		if lost > 3 {
+			require.Equal(t, ErrTooFewShards, enc.Reconstruct(damaged))
+			continue
+		}
+		require.NoError(t, enc.Reconstruct(damaged))
+		require.Equal(t, shards, damaged)
+		joined, err := enc.Join(damaged, len(data))
+		require.NoError(t, err)
+		require.Equal(t, data, joined)
+	}
+
+	shards[6][0] ^= 1
+	ok, err = enc.Verify(shards)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = NewEncoder(200, 57)
+	require.Equal(t, ErrInvalidShardNumber, err)
+}
+
+func TestPOR(t *testing.T) {
+	content := make([]byte, 10000)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+
+	file, err := EncodeFile(content, 10, 6)
+	require.NoError(t, err)
+	require.Equal(t, 16, len(file.Segments))
+
+	round := int64(1)
+	sk, pk, err := pairing.GenKeyPair()
+	require.NoError(t, err)
+	randomV, err := pairing.RandomWithinOrder()
+	require.NoError(t, err)
+	randomU, err := pairing.RandomWithinOrder()
+	require.NoError(t, err)
+	sigmas, err := TagSegments(file.Segments, pairing.TagParams{
+		RandomV: randomV,
+		RandomU: randomU,
+		Privkey: sk,
+		Round:   round,
+	})
+	require.NoError(t, err)
+
+	// challenge some segments 挑战部分段
+	challengeSize, err := file.ChallengeSize(0.9)
+	require.NoError(t, err)
+	var indexList []int
+	for i := 0; i < challengeSize; i++ {
+		indexList = append(indexList, i)
+	}
+	indices, vs, randThisRound, err := pairing.GenerateChallenge(indexList, round, sk)
+	require.NoError(t, err)
+	var contents [][]byte
+	var challenged []*bls12_381_ecc.G1Affine
+	for _, i := range indexList {
+		contents = append(contents, file.Segments[i])
+		challenged = append(challenged, sigmas[i])
+	}
+	sigma, mu, err := pairing.Prove(pairing.ProofParams{
+		Content:       contents,
+		Indices:       indices,
+		RandomVs:      vs,
+		Sigmas:        challenged,
+		RandThisRound: randThisRound,
+	})
+	require.NoError(t, err)
+	ok, err := pairing.Verify(pairing.VerifyParams{
+		Sigma:    sigma,
+		Mu:       mu,
+		RandomV:  randomV,
+		RandomU:  randomU,
+		Indices:  indices,
+		RandomVs: vs,
+		Pubkey:   pk,
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// extract with up to 6 corrupted segments 最多6个段损坏时仍可提取
+	param := ExtractParams{
+		Meta:          file.FileMeta,
+		RandomV:       randomV,
+		RandomU:       randomU,
+		Pubkey:        pk,
+		RandThisRound: randThisRound,
+	}
+	responses := make([]SegmentResponse, len(file.Segments))
+	for i, segment := range file.Segments {
+		responses[i] = SegmentResponse{
+			Index:   i,
+			Content: append([]byte{}, segment...),
+			Sigma:   sigmas[i],
+		}
+	}
+	for _, i := range []int{0, 3, 4, 9, 12, 15} {
+		responses[i].Content[0] ^= 1
+	}
+	extracted, err := Extract(param, responses)
+	require.NoError(t, err)
+	require.Equal(t, content, extracted)
+
+	responses[7].Content[0] ^= 1
+	_, err = Extract(param, responses)
+	require.ErrorIs(t, err, ErrNotEnoughSegments)
+	_, err = Extract(param, responses[:9])
+	require.ErrorIs(t, err, ErrNotEnoughSegments)
+}
+
+func TestChallengeSize(t *testing.T) {
+	c, err := ChallengeSize(100, 1, 0.5)
+	require.NoError(t, err)
+	require.Equal(t, 50, c)
+
+	c, err = ChallengeSize(100, 60, 1)
+	require.NoError(t, err)
+	require.Equal(t, 41, c)
+
+	// 大文件中1%的段损坏时，挑战约460个段即可以99%的概率发现
+	c, err = ChallengeSize(100000, 1000, 0.99)
+	require.NoError(t, err)
+	require.True(t, math.Abs(float64(c)-100000*(1-math.Pow(0.01, 1.0/1000))) < 5)
+
+	_, err = ChallengeSize(10, 11, 0.9)
+	require.Error(t, err)
+	_, err = ChallengeSize(10, 1, 0)
+	require.Equal(t, ErrInvalidProbability, err)
+}
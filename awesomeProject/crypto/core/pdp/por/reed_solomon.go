@@ -0,0 +1,203 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package por
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/gf256"
+)
+
+var (
+	ErrInvalidShardNumber = errors.New("invalid shard number, data shards must be positive, and total shards must not exceed 256")
+	ErrInvalidShards      = errors.New("invalid shards")
+	ErrTooFewShards       = errors.New("too few shards to reconstruct")
+)
+
+// Encoder systematic Reed-Solomon erasure encoder over GF(2^8), any dataShards of the
+// dataShards+parityShards shards are enough to reconstruct the data
+// GF(2^8)上的系统Reed-Solomon纠删码，总共dataShards+parityShards个分片中任意dataShards个即可恢复数据
+type Encoder struct {
+	dataShards   int
+	parityShards int
+	// matrix the encoding matrix, whose top dataShards rows are identity 编码矩阵，前dataShards行为单位矩阵
+	matrix gf256.Matrix
+}
+
+// NewEncoder create a Reed-Solomon encoder 创建Reed-Solomon编码器
+func NewEncoder(dataShards, parityShards int) (*Encoder, error) {
+	if dataShards <= 0 || parityShards < 0 || dataShards+parityShards > 256 {
+		return nil, ErrInvalidShardNumber
+	}
+
+	// 范德蒙矩阵的任意dataShards行可逆，乘以其顶部方阵的逆后仍保持该性质，且顶部变为单位矩阵
+	vm := gf256.Vandermonde(dataShards+parityShards, dataShards)
+	top, err := vm.SubMatrix(0, 0, dataShards, dataShards).Invert()
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		matrix:       vm.Multiply(top),
+	}, nil
+}
+
+// Split split data into data shards of equal size, the last shard is padded with zeros,
+// and allocate empty parity shards
+// 将数据切分为等长的数据分片，最后一个分片以0填充，并分配空的校验分片
+func (e *Encoder) Split(data []byte) [][]byte {
+	shardSize := (len(data) + e.dataShards - 1) / e.dataShards
+	if shardSize == 0 {
+		shardSize = 1
+	}
+
+	shards := make([][]byte, e.dataShards+e.parityShards)
+	for i := range shards {
+		shards[i] = make([]byte, shardSize)
+		if i < e.dataShards && i*shardSize < len(data) {
+			copy(shards[i], data[i*shardSize:])
+		}
+	}
+	return shards
+}
+
+// Encode calculate parity shards from data shards 由数据分片计算校验分片
+func (e *Encoder) Encode(shards [][]byte) error {
+	if err := e.checkShards(shards, false); err != nil {
+		return err
+	}
+
+	for i := e.dataShards; i < len(shards); i++ {
+		for j := range shards[i] {
+			shards[i][j] = 0
+		}
+		for j := 0; j < e.dataShards; j++ {
+			gf256.MulAdd(shards[i], e.matrix[i][j], shards[j])
+		}
+	}
+	return nil
+}
+
+// Verify check whether parity shards are consistent with data shards 检查校验分片与数据分片是否一致
+func (e *Encoder) Verify(shards [][]byte) (bool, error) {
+	if err := e.checkShards(shards, false); err != nil {
+		return false, err
+	}
+
+	parity := make([]byte, len(shards[0]))
+	for i := e.dataShards; i < len(shards); i++ {
+		for j := range parity {
+			parity[j] = 0
+		}
+		for j := 0; j < e.dataShards; j++ {
+			gf256.MulAdd(parity, e.matrix[i][j], shards[j])
+		}
+		if !bytes.Equal(parity, shards[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Reconstruct recover missing shards, which are set to nil 恢复缺失的分片，缺失的分片以nil表示
+func (e *Encoder) Reconstruct(shards [][]byte) error {
+	if err := e.checkShards(shards, true); err != nil {
+		return err
+	}
+
+	// 选取前dataShards个存在的分片，求对应编码矩阵行的逆，得到数据分片
+	var rows []int
+	for i := 0; i < len(shards) && len(rows) < e.dataShards; i++ {
+		if shards[i] != nil {
+			rows = append(rows, i)
+		}
+	}
+	if len(rows) < e.dataShards {
+		return ErrTooFewShards
+	}
+
+	sub := gf256.NewMatrix(e.dataShards, e.dataShards)
+	for i, r := range rows {
+		copy(sub[i], e.matrix[r])
+	}
+	decode, err := sub.Invert()
+	if err != nil {
+		return err
+	}
+
+	shardSize := len(shards[rows[0]])
+	for i := 0; i < e.dataShards; i++ {
+		if shards[i] != nil {
+			continue
+		}
+		shard := make([]byte, shardSize)
+		for j, r := range rows {
+			gf256.MulAdd(shard, decode[i][j], shards[r])
+		}
+		shards[i] = shard
+	}
+
+	for i := e.dataShards; i < len(shards); i++ {
+		if shards[i] != nil {
+			continue
+		}
+		shard := make([]byte, shardSize)
+		for j := 0; j < e.dataShards; j++ {
+			gf256.MulAdd(shard, e.matrix[i][j], shards[j])
+		}
+		shards[i] = shard
+	}
+	return nil
+}
+
+// Join join data shards and trim the padding 拼接数据分片并去除填充
+func (e *Encoder) Join(shards [][]byte, size int) ([]byte, error) {
+	if len(shards) < e.dataShards {
+		return nil, ErrTooFewShards
+	}
+
+	data := make([]byte, 0, size)
+	for i := 0; i < e.dataShards && len(data) < size; i++ {
+		if shards[i] == nil {
+			return nil, ErrTooFewShards
+		}
+		data = append(data, shards[i]...)
+	}
+	if len(data) < size {
+		return nil, fmt.Errorf("%w: size %d exceeds shards", ErrInvalidShards, size)
+	}
+	return data[:size], nil
+}
+
+// checkShards 检查分片数量和长度，allowMissing为true时允许分片为nil
+func (e *Encoder) checkShards(shards [][]byte, allowMissing bool) error {
+	if len(shards) != e.dataShards+e.parityShards {
+		return fmt.Errorf("%w: expect %d shards, got %d", ErrInvalidShards, e.dataShards+e.parityShards, len(shards))
+	}
+
+	size := -1
+	for _, shard := range shards {
+		if shard == nil && allowMissing {
+			continue
+		}
+		if len(shard) == 0 || (size >= 0 && len(shard) != size) {
+			return fmt.Errorf("%w: shards must be non-empty and of equal size", ErrInvalidShards)
+		}
+		size = len(shard)
+	}
+	return nil
+}
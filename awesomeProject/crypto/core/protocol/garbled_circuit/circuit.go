@@ -0,0 +1,364 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garbled_circuit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 布尔电路描述
+//
+// 电路由导线(wire)和门(gate)组成，导线按整数编号：
+//	[0, GarblerInputNum) 为混淆方(Garbler)的输入导线
+//	[GarblerInputNum, GarblerInputNum+EvaluatorInputNum) 为计算方(Evaluator)的输入导线
+//	其余导线为门的输出导线，门按拓扑顺序排列，每个门的输入导线必须在之前已被赋值
+//
+// 电路的文本格式与Bristol Fashion类似：
+//	<门数量> <导线数量>
+//	<混淆方输入数量> <计算方输入数量>
+//	<输出数量> <输出导线1> <输出导线2> ...
+//	2 1 <输入导线1> <输入导线2> <输出导线> XOR
+//	2 1 <输入导线1> <输入导线2> <输出导线> AND
+//	1 1 <输入导线> <输出导线> INV
+//
+// 多比特整数均按低位在前(LSB first)的顺序映射到导线上
+
+// GateType 门的类型
+type GateType string
+
+const (
+	// GateXOR 异或门，使用Free-XOR技术，无需混淆表
+	GateXOR GateType = "XOR"
+
+	// GateAND 与门，使用Half-Gates技术，每个门需要2个密文
+	GateAND GateType = "AND"
+
+	// GateINV 非门，无需混淆表
+	GateINV GateType = "INV"
+)
+
+// MaxWireNum 电路允许的最大导线数量，在分配导线相关的内存之前检查
+const MaxWireNum = 1 << 26
+
+var (
+	ErrInvalidCircuit    = errors.New("invalid circuit")
+	ErrInvalidInputCount = errors.New("the number of input bits does not match the circuit")
+)
+
+// Gate 电路中的门
+type Gate struct {
+	Type   GateType `json:"type"`
+	Inputs []int    `json:"inputs"` // 输入导线，XOR/AND为2个，INV为1个
+	Output int      `json:"output"` // 输出导线
+}
+
+// Circuit 布尔电路
+type Circuit struct {
+	WireNum           int    `json:"wire_num"`
+	GarblerInputNum   int    `json:"garbler_input_num"`
+	EvaluatorInputNum int    `json:"evaluator_input_num"`
+	Outputs           []int  `json:"outputs"`
+	Gates             []Gate `json:"gates"`
+}
+
+// ANDGateNum 电路中与门的数量，决定了混淆电路的大小
+func (c *Circuit) ANDGateNum() int {
+	num := 0
+	for _, gate := range c.Gates {
+		if gate.Type == GateAND {
+			num++
+		}
+	}
+	return num
+}
+
+// Validate 检查电路是否合法：导线编号在范围内，门按拓扑顺序排列，每条导线只被赋值一次
+// 导线数量来自不可信的输入，分配内存前先检查其不超过MaxWireNum且不超过输入数量与门数量之和
+func (c *Circuit) Validate() error {
+	if c.GarblerInputNum < 0 || c.EvaluatorInputNum < 0 || c.WireNum > MaxWireNum ||
+		c.GarblerInputNum > MaxWireNum || c.EvaluatorInputNum > MaxWireNum || len(c.Gates) > MaxWireNum {
+		return fmt.Errorf("%w: wire number %d exceeds the limit %d", ErrInvalidCircuit, c.WireNum, MaxWireNum)
+	}
+	inputNum := c.GarblerInputNum + c.EvaluatorInputNum
+	if inputNum > c.WireNum {
+		return fmt.Errorf("%w: wire number %d is smaller than input number %d", ErrInvalidCircuit, c.WireNum, inputNum)
+	}
+	// 除输入导线外，每条导线都是某个门的输出
+	if c.WireNum > inputNum+len(c.Gates) {
+		return fmt.Errorf("%w: wire number %d is larger than input number %d plus gate number %d",
+			ErrInvalidCircuit, c.WireNum, inputNum, len(c.Gates))
+	}
+
+	assigned := make([]bool, c.WireNum)
+	for i := 0; i < inputNum; i++ {
+		assigned[i] = true
+	}
+
+	for i, gate := range c.Gates {
+		expectedInputs := 2
+		switch gate.Type {
+		case GateXOR, GateAND:
+		case GateINV:
+			expectedInputs = 1
+		default:
+			return fmt.Errorf("%w: gate %d has unknown type %s", ErrInvalidCircuit, i, gate.Type)
+		}
+		if len(gate.Inputs) != expectedInputs {
+			return fmt.Errorf("%w: gate %d expects %d inputs, got %d", ErrInvalidCircuit, i, expectedInputs, len(gate.Inputs))
+		}
+
+		for _, in := range gate.Inputs {
+			if in < 0 || in >= c.WireNum || !assigned[in] {
+				return fmt.Errorf("%w: gate %d reads unassigned wire %d", ErrInvalidCircuit, i, in)
+			}
+		}
+		if gate.Output < 0 || gate.Output >= c.WireNum || assigned[gate.Output] {
+			return fmt.Errorf("%w: gate %d writes invalid wire %d", ErrInvalidCircuit, i, gate.Output)
+		}
+		assigned[gate.Output] = true
+	}
+
+	for _, out := range c.Outputs {
+		if out < 0 || out >= c.WireNum || !assigned[out] {
+			return fmt.Errorf("%w: output wire %d is not assigned", ErrInvalidCircuit, out)
+		}
+	}
+
+	return nil
+}
+
+// EvaluatePlain 使用明文输入计算电路，用于测试和调试
+func (c *Circuit) EvaluatePlain(garblerInputs, evaluatorInputs []bool) ([]bool, error) {
+	if len(garblerInputs) != c.GarblerInputNum || len(evaluatorInputs) != c.EvaluatorInputNum {
+		return nil, ErrInvalidInputCount
+	}
+
+	wires := make([]bool, c.WireNum)
+	copy(wires, garblerInputs)
+	copy(wires[c.GarblerInputNum:], evaluatorInputs)
+
+	for _, gate := range c.Gates {
+		switch gate.Type {
+		case GateXOR:
+			wires[gate.Output] = wires[gate.Inputs[0]] != wires[gate.Inputs[1]]
+		case GateAND:
+			wires[gate.Output] = wires[gate.Inputs[0]] && wires[gate.Inputs[1]]
+		case GateINV:
+			wires[gate.Output] = !wires[gate.Inputs[0]]
+		}
+	}
+
+	outputs := make([]bool, len(c.Outputs))
+	for i, out := range c.Outputs {
+		outputs[i] = wires[out]
+	}
+
+	return outputs, nil
+}
+
+// String 将电路转换为文本格式
+func (c *Circuit) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%d %d\n", len(c.Gates), c.WireNum)
+	fmt.Fprintf(&sb, "%d %d\n", c.GarblerInputNum, c.EvaluatorInputNum)
+	fmt.Fprintf(&sb, "%d", len(c.Outputs))
+	for _, out := range c.Outputs {
+		fmt.Fprintf(&sb, " %d", out)
+	}
+	sb.WriteString("\n")
+
+	for _, gate := range c.Gates {
+		fmt.Fprintf(&sb, "%d 1", len(gate.Inputs))
+		for _, in := range gate.Inputs {
+			fmt.Fprintf(&sb, " %d", in)
+		}
+		fmt.Fprintf(&sb, " %d %s\n", gate.Output, gate.Type)
+	}
+
+	return sb.String()
+}
+
+// ParseCircuit 从文本格式解析电路
+func ParseCircuit(s string) (*Circuit, error) {
+	var lines [][]int
+	var types []GateType
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		// 门描述的最后一个字段为门的类型
+		var gateType GateType
+		if len(lines) >= 3 {
+			gateType = GateType(fields[len(fields)-1])
+			fields = fields[:len(fields)-1]
+		}
+
+		var nums []int
+		for _, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidCircuit, err)
+			}
+			nums = append(nums, n)
+		}
+		lines = append(lines, nums)
+		types = append(types, gateType)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) < 3 || len(lines[0]) != 2 || len(lines[1]) != 2 || len(lines[2]) < 1 || len(lines[2]) != lines[2][0]+1 {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidCircuit)
+	}
+	if len(lines)-3 != lines[0][0] {
+		return nil, fmt.Errorf("%w: expect %d gates, got %d", ErrInvalidCircuit, lines[0][0], len(lines)-3)
+	}
+
+	c := &Circuit{
+		WireNum:           lines[0][1],
+		GarblerInputNum:   lines[1][0],
+		EvaluatorInputNum: lines[1][1],
+		Outputs:           lines[2][1:],
+	}
+
+	for i, line := range lines[3:] {
+		// <输入数量> <输出数量> <输入导线...> <输出导线>，输入数量必须为正，否则切片越界
+		if len(line) < 2 || line[0] < 1 || line[1] != 1 || len(line) != line[0]+3 {
+			return nil, fmt.Errorf("%w: malformed gate %d", ErrInvalidCircuit, i)
+		}
+		c.Gates = append(c.Gates, Gate{
+			Type:   types[i+3],
+			Inputs: line[2 : 2+line[0]],
+			Output: line[len(line)-1],
+		})
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Builder 用于逐个添加门来构造电路
+type Builder struct {
+	circuit *Circuit
+}
+
+// NewBuilder 根据双方的输入比特数创建电路构造器
+func NewBuilder(garblerInputNum, evaluatorInputNum int) *Builder {
+	return &Builder{
+		circuit: &Circuit{
+			WireNum:           garblerInputNum + evaluatorInputNum,
+			GarblerInputNum:   garblerInputNum,
+			EvaluatorInputNum: evaluatorInputNum,
+		},
+	}
+}
+
+// GarblerInputs 返回混淆方的输入导线
+func (b *Builder) GarblerInputs() []int {
+	return wireRange(0, b.circuit.GarblerInputNum)
+}
+
+// EvaluatorInputs 返回计算方的输入导线
+func (b *Builder) EvaluatorInputs() []int {
+	return wireRange(b.circuit.GarblerInputNum, b.circuit.EvaluatorInputNum)
+}
+
+// XOR 添加异或门，返回输出导线
+func (b *Builder) XOR(x, y int) int {
+	return b.addGate(GateXOR, x, y)
+}
+
+// AND 添加与门，返回输出导线
+func (b *Builder) AND(x, y int) int {
+	return b.addGate(GateAND, x, y)
+}
+
+// INV 添加非门，返回输出导线
+func (b *Builder) INV(x int) int {
+	return b.addGate(GateINV, x)
+}
+
+// Build 指定输出导线，返回构造好的电路
+func (b *Builder) Build(outputs []int) *Circuit {
+	b.circuit.Outputs = outputs
+	return b.circuit
+}
+
+func (b *Builder) addGate(gateType GateType, inputs ...int) int {
+	out := b.circuit.WireNum
+	b.circuit.WireNum++
+	b.circuit.Gates = append(b.circuit.Gates, Gate{
+		Type:   gateType,
+		Inputs: inputs,
+		Output: out,
+	})
+	return out
+}
+
+func wireRange(start, num int) []int {
+	wires := make([]int, num)
+	for i := range wires {
+		wires[i] = start + i
+	}
+	return wires
+}
+
+// Uint64ToBits 将整数的低bitNum位转换为比特列表，低位在前
+func Uint64ToBits(v uint64, bitNum int) []bool {
+	bits := make([]bool, bitNum)
+	for i := range bits {
+		bits[i] = (v>>uint(i))&1 == 1
+	}
+	return bits
+}
+
+// BitsToUint64 将低位在前的比特列表转换为整数
+func BitsToUint64(bits []bool) uint64 {
+	var v uint64
+	for i, bit := range bits {
+		if bit {
+			v |= 1 << uint(i)
+		}
+	}
+	return v
+}
+
+// Int64ToBits 将有符号整数按补码转换为bitNum位的比特列表，低位在前
+func Int64ToBits(v int64, bitNum int) []bool {
+	return Uint64ToBits(uint64(v), bitNum)
+}
+
+// BitsToInt64 将低位在前的补码比特列表转换为有符号整数
+func BitsToInt64(bits []bool) int64 {
+	v := BitsToUint64(bits)
+	n := uint(len(bits))
+	if n > 0 && n < 64 && bits[n-1] {
+		// 符号位扩展
+		v |= ^uint64(0) << n
+	}
+	return int64(v)
+}
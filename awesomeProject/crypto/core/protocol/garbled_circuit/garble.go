@@ -0,0 +1,275 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garbled_circuit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+// Yao混淆电路 - Free-XOR + Half-Gates + Point-and-Permute
+//
+// 标签：每条导线w有两个128比特的标签W(w,0)和W(w,1)，分别代表比特0和1，
+//		全局偏移量Δ的最低位为1，W(w,1)=W(w,0)⊕Δ，标签的最低位作为置换位(permute bit)
+// 哈希：H(X, j) = π(K)⊕K，K = 2X⊕j，π为固定密钥的AES，2X为GF(2^128)上的倍乘
+//
+// XOR门(Free-XOR)：W(c,0) = W(a,0)⊕W(b,0)，计算方直接计算C = A⊕B
+// INV门：W(c,0) = W(a,0)⊕Δ，计算方直接令C = A
+// AND门(Half-Gates)，j=2k，j'=2k+1，k为与门的编号，pa=lsb(W(a,0))，pb=lsb(W(b,0))：
+//	混淆方：
+//		TG = H(W(a,0),j)⊕H(W(a,1),j)⊕pb·Δ
+//		WG = H(W(a,0),j)⊕pa·TG
+//		TE = H(W(b,0),j')⊕H(W(b,1),j')⊕W(a,0)
+//		WE = H(W(b,0),j')⊕pb·(TE⊕W(a,0))
+//		W(c,0) = WG⊕WE，混淆表为(TG, TE)
+//	计算方，持有标签A和B，sa=lsb(A)，sb=lsb(B)：
+//		C = H(A,j)⊕sa·TG⊕H(B,j')⊕sb·(TE⊕A)
+//
+// 输出解码：混淆方公开每条输出导线的解码位d=lsb(W(w,0))，计算方得到输出比特lsb(C)⊕d
+
+const (
+	// LabelLength 导线标签的长度，即计算安全参数κ/8
+	LabelLength = 16
+)
+
+var (
+	ErrInvalidLabel        = errors.New("invalid wire label")
+	ErrInvalidGarbledTable = errors.New("the garbled tables do not match the circuit")
+)
+
+// Label 导线标签
+type Label [LabelLength]byte
+
+// GarbledCircuit 混淆电路，由混淆方发送给计算方
+type GarbledCircuit struct {
+	Tables     [][2]Label `json:"tables"`      // 每个与门的混淆表(TG, TE)，按与门在电路中的顺序排列
+	DecodeBits []bool     `json:"decode_bits"` // 每条输出导线的解码位
+}
+
+// GarbleSecret 混淆方保存的秘密信息
+type GarbleSecret struct {
+	Delta      Label   // 全局偏移量Δ
+	ZeroLabels []Label // 每条导线代表比特0的标签W(w,0)
+}
+
+// fixedKeyCipher 哈希函数使用的固定密钥AES
+var fixedKeyCipher cipher.Block
+
+func init() {
+	key := hash.HashUsingSha256([]byte("paddledtx/garbled-circuit/fixed-key"))
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		panic(err)
+	}
+	fixedKeyCipher = block
+}
+
+// Garble 混淆电路，返回需要发送给计算方的混淆电路和混淆方保存的秘密信息
+func Garble(c *Circuit) (*GarbledCircuit, *GarbleSecret, error) {
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	var delta Label
+	if _, err := rand.Read(delta[:]); err != nil {
+		return nil, nil, err
+	}
+	delta[LabelLength-1] |= 1
+
+	zeroLabels := make([]Label, c.WireNum)
+	for i := 0; i < c.GarblerInputNum+c.EvaluatorInputNum; i++ {
+		if _, err := rand.Read(zeroLabels[i][:]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	gc := &GarbledCircuit{}
+	andIndex := 0
+	for _, gate := range c.Gates {
+		switch gate.Type {
+		case GateXOR:
+			zeroLabels[gate.Output] = zeroLabels[gate.Inputs[0]].xor(zeroLabels[gate.Inputs[1]])
+		case GateINV:
+			zeroLabels[gate.Output] = zeroLabels[gate.Inputs[0]].xor(delta)
+		case GateAND:
+			a0 := zeroLabels[gate.Inputs[0]]
+			b0 := zeroLabels[gate.Inputs[1]]
+			a1 := a0.xor(delta)
+			b1 := b0.xor(delta)
+			pa := a0.permuteBit()
+			pb := b0.permuteBit()
+			j, j2 := uint64(2*andIndex), uint64(2*andIndex+1)
+
+			ha0, ha1 := hashLabel(a0, j), hashLabel(a1, j)
+			hb0, hb1 := hashLabel(b0, j2), hashLabel(b1, j2)
+
+			// 混淆方的半门
+			tg := ha0.xor(ha1)
+			if pb {
+				tg = tg.xor(delta)
+			}
+			wg := ha0
+			if pa {
+				wg = wg.xor(tg)
+			}
+
+			// 计算方的半门
+			te := hb0.xor(hb1).xor(a0)
+			we := hb0
+			if pb {
+				we = we.xor(te).xor(a0)
+			}
+
+			zeroLabels[gate.Output] = wg.xor(we)
+			gc.Tables = append(gc.Tables, [2]Label{tg, te})
+			andIndex++
+		}
+	}
+
+	for _, out := range c.Outputs {
+		gc.DecodeBits = append(gc.DecodeBits, zeroLabels[out].permuteBit())
+	}
+
+	secret := &GarbleSecret{
+		Delta:      delta,
+		ZeroLabels: zeroLabels,
+	}
+
+	return gc, secret, nil
+}
+
+// InputLabel 获取输入导线wire上代表比特bit的标签
+func (gs *GarbleSecret) InputLabel(wire int, bit bool) Label {
+	if bit {
+		return gs.ZeroLabels[wire].xor(gs.Delta)
+	}
+	return gs.ZeroLabels[wire]
+}
+
+// GarblerInputLabels 混淆方根据自己的输入比特获取对应的标签，直接发送给计算方
+func (gs *GarbleSecret) GarblerInputLabels(c *Circuit, inputs []bool) ([]Label, error) {
+	if len(inputs) != c.GarblerInputNum {
+		return nil, ErrInvalidInputCount
+	}
+
+	labels := make([]Label, len(inputs))
+	for i, bit := range inputs {
+		labels[i] = gs.InputLabel(i, bit)
+	}
+
+	return labels, nil
+}
+
+// EvaluateGarbled 计算方使用双方的输入标签计算混淆电路，返回明文输出
+func EvaluateGarbled(c *Circuit, gc *GarbledCircuit, garblerLabels, evaluatorLabels []Label) ([]bool, error) {
+	if len(garblerLabels) != c.GarblerInputNum || len(evaluatorLabels) != c.EvaluatorInputNum {
+		return nil, ErrInvalidInputCount
+	}
+	if len(gc.Tables) != c.ANDGateNum() || len(gc.DecodeBits) != len(c.Outputs) {
+		return nil, ErrInvalidGarbledTable
+	}
+
+	wires := make([]Label, c.WireNum)
+	copy(wires, garblerLabels)
+	copy(wires[c.GarblerInputNum:], evaluatorLabels)
+
+	andIndex := 0
+	for _, gate := range c.Gates {
+		switch gate.Type {
+		case GateXOR:
+			wires[gate.Output] = wires[gate.Inputs[0]].xor(wires[gate.Inputs[1]])
+		case GateINV:
+			wires[gate.Output] = wires[gate.Inputs[0]]
+		case GateAND:
+			a := wires[gate.Inputs[0]]
+			b := wires[gate.Inputs[1]]
+			tg, te := gc.Tables[andIndex][0], gc.Tables[andIndex][1]
+			j, j2 := uint64(2*andIndex), uint64(2*andIndex+1)
+
+			wg := hashLabel(a, j)
+			if a.permuteBit() {
+				wg = wg.xor(tg)
+			}
+			we := hashLabel(b, j2)
+			if b.permuteBit() {
+				we = we.xor(te).xor(a)
+			}
+
+			wires[gate.Output] = wg.xor(we)
+			andIndex++
+		}
+	}
+
+	outputs := make([]bool, len(c.Outputs))
+	for i, out := range c.Outputs {
+		outputs[i] = wires[out].permuteBit() != gc.DecodeBits[i]
+	}
+
+	return outputs, nil
+}
+
+// LabelFromBytes 从字节恢复标签
+func LabelFromBytes(b []byte) (Label, error) {
+	var l Label
+	if len(b) != LabelLength {
+		return l, fmt.Errorf("%w: expect %d bytes, got %d", ErrInvalidLabel, LabelLength, len(b))
+	}
+	copy(l[:], b)
+	return l, nil
+}
+
+func (l Label) xor(o Label) Label {
+	var r Label
+	for i := range r {
+		r[i] = l[i] ^ o[i]
+	}
+	return r
+}
+
+// permuteBit 标签的置换位，即最低位
+func (l Label) permuteBit() bool {
+	return l[LabelLength-1]&1 == 1
+}
+
+// double 计算GF(2^128)上的2X，不可约多项式为x^128+x^7+x^2+x+1
+func (l Label) double() Label {
+	var r Label
+	carry := l[0] >> 7
+	for i := 0; i < LabelLength-1; i++ {
+		r[i] = l[i]<<1 | l[i+1]>>7
+	}
+	r[LabelLength-1] = l[LabelLength-1] << 1
+	if carry == 1 {
+		r[LabelLength-1] ^= 0x87
+	}
+	return r
+}
+
+// hashLabel 计算H(X, j) = π(K)⊕K，K = 2X⊕j
+func hashLabel(l Label, tweak uint64) Label {
+	k := l.double()
+	var t Label
+	binary.BigEndian.PutUint64(t[LabelLength-8:], tweak)
+	k = k.xor(t)
+
+	var out Label
+	fixedKeyCipher.Encrypt(out[:], k[:])
+	return out.xor(k)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garbled_circuit
+
+import (
+	"crypto/elliptic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// runProtocol 在本地模拟混淆方和计算方完成一次安全两方计算
+func runProtocol(t *testing.T, c *Circuit, garblerInputs, evaluatorInputs []bool) []bool {
+	garbler, err := NewGarbler(c, elliptic.P256())
+	if err != nil {
+		t.Fatalf("NewGarbler err is %v", err)
+	}
+	evaluator, err := NewEvaluator(c, evaluatorInputs)
+	if err != nil {
+		t.Fatalf("NewEvaluator err is %v", err)
+	}
+
+	msg, err := garbler.Start(garblerInputs)
+	if err != nil {
+		t.Fatalf("Start err is %v", err)
+	}
+	choicePublicKeys, err := evaluator.ChooseInputLabels(msg.OTPublicKey)
+	if err != nil {
+		t.Fatalf("ChooseInputLabels err is %v", err)
+	}
+	cts, err := garbler.TransferInputLabels(choicePublicKeys)
+	if err != nil {
+		t.Fatalf("TransferInputLabels err is %v", err)
+	}
+	outputs, err := evaluator.Evaluate(msg, cts)
+	if err != nil {
+		t.Fatalf("Evaluate err is %v", err)
+	}
+
+	return outputs
+}
+
+func TestComparison(t *testing.T) {
+	bitNum := 16
+	cases := [][2]int64{{100, 99}, {99, 100}, {-5, 3}, {3, -5}, {-7, -7}, {0, 0}}
+
+	gt, err := GreaterThanCircuit(bitNum, true)
+	if err != nil {
+		t.Fatalf("GreaterThanCircuit err is %v", err)
+	}
+	lt, err := LessThanCircuit(bitNum, true)
+	if err != nil {
+		t.Fatalf("LessThanCircuit err is %v", err)
+	}
+	eq, err := EqualityCircuit(bitNum)
+	if err != nil {
+		t.Fatalf("EqualityCircuit err is %v", err)
+	}
+
+	for _, cs := range cases {
+		x, y := Int64ToBits(cs[0], bitNum), Int64ToBits(cs[1], bitNum)
+		require.Equal(t, []bool{cs[0] > cs[1]}, runProtocol(t, gt, x, y), "%d > %d", cs[0], cs[1])
+		require.Equal(t, []bool{cs[0] < cs[1]}, runProtocol(t, lt, x, y), "%d < %d", cs[0], cs[1])
+		require.Equal(t, []bool{cs[0] == cs[1]}, runProtocol(t, eq, x, y), "%d == %d", cs[0], cs[1])
+	}
+
+	// 无符号比较
+	ugt, err := GreaterThanCircuit(8, false)
+	if err != nil {
+		t.Fatalf("GreaterThanCircuit err is %v", err)
+	}
+	require.Equal(t, []bool{true}, runProtocol(t, ugt, Uint64ToBits(200, 8), Uint64ToBits(100, 8)))
+	require.Equal(t, []bool{false}, runProtocol(t, ugt, Uint64ToBits(100, 8), Uint64ToBits(200, 8)))
+}
+
+func TestAdditionAndReLU(t *testing.T) {
+	bitNum := 32
+	add, err := AdditionCircuit(bitNum)
+	if err != nil {
+		t.Fatalf("AdditionCircuit err is %v", err)
+	}
+	relu, err := ReLUCircuit(bitNum)
+	if err != nil {
+		t.Fatalf("ReLUCircuit err is %v", err)
+	}
+
+	cases := [][2]int64{{12345, 678}, {-1000, 999}, {-1000, 1001}, {-3, -4}}
+	for _, cs := range cases {
+		x, y := Int64ToBits(cs[0], bitNum), Int64ToBits(cs[1], bitNum)
+		sum := cs[0] + cs[1]
+		require.Equal(t, sum, BitsToInt64(runProtocol(t, add, x, y)))
+
+		if sum < 0 {
+			sum = 0
+		}
+		require.Equal(t, sum, BitsToInt64(runProtocol(t, relu, x, y)))
+	}
+}
+
+func TestCircuitFormat(t *testing.T) {
+	c, err := AdditionCircuit(4)
+	if err != nil {
+		t.Fatalf("AdditionCircuit err is %v", err)
+	}
+
+	parsed, err := ParseCircuit(c.String())
+	if err != nil {
+		t.Fatalf("ParseCircuit err is %v", err)
+	}
+	require.Equal(t, c, parsed)
+
+	// 明文计算与混淆电路计算结果一致
+	x, y := Uint64ToBits(9, 4), Uint64ToBits(5, 4)
+	plain, err := parsed.EvaluatePlain(x, y)
+	if err != nil {
+		t.Fatalf("EvaluatePlain err is %v", err)
+	}
+	require.Equal(t, uint64(14), BitsToUint64(plain))
+	require.Equal(t, plain, runProtocol(t, parsed, x, y))
+
+	if _, err := ParseCircuit("1 3\n1 1\n1 2\n2 1 0 5 2 AND\n"); err == nil {
+		t.Errorf("circuit reading an unassigned wire should be rejected")
+	}
+	if _, err := ParseCircuit("1 3\n1 1\n1 2\n-1 1 XOR\n"); err == nil {
+		t.Errorf("gate with a negative input number should be rejected")
+	}
+	if _, err := ParseCircuit("1 4611686018427387904\n1 1\n1 2\n2 1 0 1 2 AND\n"); err == nil {
+		t.Errorf("circuit with a huge wire number should be rejected")
+	}
+	if _, err := ParseCircuit("1 1000\n1 1\n1 2\n2 1 0 1 2 AND\n"); err == nil {
+		t.Errorf("circuit with more wires than inputs and gates should be rejected")
+	}
+}
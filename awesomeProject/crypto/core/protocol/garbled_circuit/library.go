@@ -0,0 +1,147 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garbled_circuit
+
+import (
+	"errors"
+)
+
+// 常用电路库，混淆方输入x，计算方输入y，均为bitNum位整数，低位在前
+// 例如判断预测分数是否超过阈值：计算方输入分数y，混淆方输入阈值x，使用LessThanCircuit计算x < y
+
+var (
+	ErrInvalidBitNum = errors.New("bit number must within [1, 64]")
+)
+
+// AdditionCircuit 加法电路，输出x+y mod 2^bitNum，共bitNum位
+func AdditionCircuit(bitNum int) (*Circuit, error) {
+	if err := checkBitNum(bitNum); err != nil {
+		return nil, err
+	}
+
+	b := NewBuilder(bitNum, bitNum)
+	sum := b.add(b.GarblerInputs(), b.EvaluatorInputs())
+	return b.Build(sum), nil
+}
+
+// GreaterThanCircuit 比较电路，输出1位[x > y]
+// - signed 为true时按补码比较有符号整数，否则按无符号整数比较
+func GreaterThanCircuit(bitNum int, signed bool) (*Circuit, error) {
+	if err := checkBitNum(bitNum); err != nil {
+		return nil, err
+	}
+
+	b := NewBuilder(bitNum, bitNum)
+	gt := b.greaterThan(b.GarblerInputs(), b.EvaluatorInputs(), signed)
+	return b.Build([]int{gt}), nil
+}
+
+// LessThanCircuit 比较电路，输出1位[x < y]
+// - signed 为true时按补码比较有符号整数，否则按无符号整数比较
+func LessThanCircuit(bitNum int, signed bool) (*Circuit, error) {
+	if err := checkBitNum(bitNum); err != nil {
+		return nil, err
+	}
+
+	b := NewBuilder(bitNum, bitNum)
+	lt := b.greaterThan(b.EvaluatorInputs(), b.GarblerInputs(), signed)
+	return b.Build([]int{lt}), nil
+}
+
+// EqualityCircuit 相等电路，输出1位[x == y]
+func EqualityCircuit(bitNum int) (*Circuit, error) {
+	if err := checkBitNum(bitNum); err != nil {
+		return nil, err
+	}
+
+	b := NewBuilder(bitNum, bitNum)
+	eq := b.equal(b.GarblerInputs(), b.EvaluatorInputs())
+	return b.Build([]int{eq}), nil
+}
+
+// ReLUCircuit ReLU电路，x和y为有符号整数z的加法秘密分享，z = x+y mod 2^bitNum
+// 输出max(z, 0)，共bitNum位，双方均无法得知z的明文
+func ReLUCircuit(bitNum int) (*Circuit, error) {
+	if err := checkBitNum(bitNum); err != nil {
+		return nil, err
+	}
+
+	b := NewBuilder(bitNum, bitNum)
+	sum := b.add(b.GarblerInputs(), b.EvaluatorInputs())
+
+	// 符号位为1时输出0，否则输出z
+	positive := b.INV(sum[bitNum-1])
+	outputs := make([]int, bitNum)
+	for i, s := range sum {
+		outputs[i] = b.AND(s, positive)
+	}
+
+	return b.Build(outputs), nil
+}
+
+// add 行波进位加法器，每位只需1个与门
+// s(i) = x(i)⊕y(i)⊕c(i)，c(i+1) = c(i)⊕((x(i)⊕c(i))∧(y(i)⊕c(i)))
+func (b *Builder) add(x, y []int) []int {
+	sum := make([]int, len(x))
+
+	sum[0] = b.XOR(x[0], y[0])
+	if len(x) == 1 {
+		return sum
+	}
+	carry := b.AND(x[0], y[0])
+
+	for i := 1; i < len(x); i++ {
+		sum[i] = b.XOR(b.XOR(x[i], y[i]), carry)
+		if i < len(x)-1 {
+			carry = b.XOR(carry, b.AND(b.XOR(x[i], carry), b.XOR(y[i], carry)))
+		}
+	}
+
+	return sum
+}
+
+// greaterThan 比较器，从低位到高位计算，最高的不同位决定结果
+// c(i+1) = x(i)⊕((x(i)⊕c(i))∧(y(i)⊕c(i)))，c(0) = 0，输出c(n) = [x > y]
+// 有符号比较时将双方的符号位取反，转换为无符号比较
+func (b *Builder) greaterThan(x, y []int, signed bool) int {
+	n := len(x)
+	if signed {
+		x = append(append([]int{}, x[:n-1]...), b.INV(x[n-1]))
+		y = append(append([]int{}, y[:n-1]...), b.INV(y[n-1]))
+	}
+
+	// c(1) = x(0)∧¬y(0)
+	c := b.AND(x[0], b.INV(y[0]))
+	for i := 1; i < n; i++ {
+		c = b.XOR(x[i], b.AND(b.XOR(x[i], c), b.XOR(y[i], c)))
+	}
+
+	return c
+}
+
+// equal 相等比较器，所有位同或后相与
+func (b *Builder) equal(x, y []int) int {
+	eq := b.INV(b.XOR(x[0], y[0]))
+	for i := 1; i < len(x); i++ {
+		eq = b.AND(eq, b.INV(b.XOR(x[i], y[i])))
+	}
+	return eq
+}
+
+func checkBitNum(bitNum int) error {
+	if bitNum < 1 || bitNum > 64 {
+		return ErrInvalidBitNum
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garbled_circuit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+
+	ot "github.com/PaddlePaddle/PaddleDTX/crypto/core/protocol/oblivious_transfer"
+)
+
+// 基于混淆电路的安全两方计算(Yao's protocol, semi-honest)
+//
+// 参与方：混淆方Alice(Garbler)和计算方Bob(Evaluator)，双方事先约定好同一个电路
+//
+// Step 1：Alice混淆电路，将混淆电路、自己输入对应的标签以及OT公钥发送给Bob。
+// Step 2：Bob对自己的每个输入比特做一次1 of 2选择，将选择公钥发送给Alice。
+// Step 3：Alice用批量OT加密Bob每条输入导线的两个标签，将密文发送给Bob。
+// Step 4：Bob解密得到自己输入对应的标签，计算混淆电路得到输出。
+//			Bob只看到随机标签，无法得知Alice的输入；Alice无法得知Bob在OT中的选择。
+//			如需Alice也获得结果，由Bob将输出发送给Alice。
+
+// Garbler 混淆方
+type Garbler struct {
+	circuit *Circuit
+	gc      *GarbledCircuit
+	secret  *GarbleSecret
+	otKey   *ecdsa.PrivateKey
+}
+
+// Evaluator 计算方
+type Evaluator struct {
+	circuit *Circuit
+	inputs  []bool
+	otKeys  []*ecdsa.PrivateKey
+}
+
+// GarblerMessage 混淆方在Step 1发送给计算方的消息
+type GarblerMessage struct {
+	GarbledCircuit *GarbledCircuit
+	InputLabels    []Label          // 混淆方输入对应的标签
+	OTPublicKey    *ecdsa.PublicKey // 混淆方作为OT发送方的公钥
+}
+
+// NewGarbler 创建混淆方，混淆电路并生成OT公私钥对
+// - c 双方约定的电路
+// - curve OT使用的椭圆曲线
+func NewGarbler(c *Circuit, curve elliptic.Curve) (*Garbler, error) {
+	gc, secret, err := Garble(c)
+	if err != nil {
+		return nil, err
+	}
+
+	otKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Garbler{
+		circuit: c,
+		gc:      gc,
+		secret:  secret,
+		otKey:   otKey,
+	}, nil
+}
+
+// Start 混淆方根据自己的输入比特生成Step 1的消息
+func (g *Garbler) Start(inputs []bool) (*GarblerMessage, error) {
+	labels, err := g.secret.GarblerInputLabels(g.circuit, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &GarblerMessage{
+		GarbledCircuit: g.gc,
+		InputLabels:    labels,
+		OTPublicKey:    &g.otKey.PublicKey,
+	}
+
+	return msg, nil
+}
+
+// TransferInputLabels 混淆方用批量OT加密计算方每条输入导线的两个标签
+// - choicePublicKeys 计算方在Step 2发来的选择公钥
+func (g *Garbler) TransferInputLabels(choicePublicKeys []*ecdsa.PublicKey) ([][][]byte, error) {
+	if len(choicePublicKeys) != g.circuit.EvaluatorInputNum {
+		return nil, ErrInvalidInputCount
+	}
+
+	msgs := make([][][]byte, g.circuit.EvaluatorInputNum)
+	for i := range msgs {
+		wire := g.circuit.GarblerInputNum + i
+		label0 := g.secret.InputLabel(wire, false)
+		label1 := g.secret.InputLabel(wire, true)
+		msgs[i] = [][]byte{label0[:], label1[:]}
+	}
+
+	return ot.SenderBatchEncryptMsg(g.otKey, choicePublicKeys, msgs)
+}
+
+// NewEvaluator 创建计算方
+// - c 双方约定的电路
+// - inputs 计算方的输入比特
+func NewEvaluator(c *Circuit, inputs []bool) (*Evaluator, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if len(inputs) != c.EvaluatorInputNum {
+		return nil, ErrInvalidInputCount
+	}
+
+	return &Evaluator{
+		circuit: c,
+		inputs:  inputs,
+	}, nil
+}
+
+// ChooseInputLabels 计算方根据自己的输入比特做批量OT选择，将返回的公钥发送给混淆方
+func (e *Evaluator) ChooseInputLabels(garblerOTPublicKey *ecdsa.PublicKey) ([]*ecdsa.PublicKey, error) {
+	privateKeys, publicKeys, err := ot.ReceiverBatchChoose(garblerOTPublicKey, e.choices(), 2)
+	if err != nil {
+		return nil, err
+	}
+	e.otKeys = privateKeys
+
+	return publicKeys, nil
+}
+
+// Evaluate 计算方解密OT密文得到自己的输入标签，然后计算混淆电路
+// - msg 混淆方在Step 1发送的消息
+// - cts 混淆方在Step 3发送的OT密文
+func (e *Evaluator) Evaluate(msg *GarblerMessage, cts [][][]byte) ([]bool, error) {
+	labelBytes, err := ot.ReceiverBatchRetrieveMsg(e.otKeys, msg.OTPublicKey, cts, e.choices())
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]Label, len(labelBytes))
+	for i, b := range labelBytes {
+		if labels[i], err = LabelFromBytes(b); err != nil {
+			return nil, err
+		}
+	}
+
+	return EvaluateGarbled(e.circuit, msg.GarbledCircuit, msg.InputLabels, labels)
+}
+
+// choices 将输入比特转换为OT的选择
+func (e *Evaluator) choices() []int {
+	choices := make([]int, len(e.inputs))
+	for i, bit := range e.inputs {
+		if bit {
+			choices[i] = ot.IndexTwo
+		} else {
+			choices[i] = ot.IndexOne
+		}
+	}
+	return choices
+}
@@ -27,7 +27,7 @@ import (
 // 1 of 2 Oblivious Transfer Protocol - based on the CDH assumption
 // 用于联邦学习或多方隐私计算等方案的机密数据传输
 //
-// TODO: 实现 1 of N Oblivious Transfer Protocol
+// 1 of N Oblivious Transfer Protocol 及批量传输参见 ot_n.go
 //
 // Computational Diffie-Hellman assumption(CDH assumption):
 // An algorithm that solves the computational Diffie-Hellman problem
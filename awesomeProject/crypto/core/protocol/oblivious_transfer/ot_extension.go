@@ -0,0 +1,471 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oblivious_transfer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+// OT扩展协议(IKNP) - 以少量基础OT为种子，只用对称密码运算完成海量的1 of 2不经意传输
+// OT Extension (IKNP03, semi-honest)
+//
+// 每次基础OT都需要多次P-256标量乘法和两次ECIES加密，无法支撑需要百万次OT的协议。
+// IKNP协议通过κ=128次基础OT，把后续m次OT的开销降低为AES与SHA-256运算。
+//
+// 符号说明：
+//	扩展发送方Alice持有m对数据(x(j,0), x(j,1))；扩展接收方Bob持有m个选择位r(j)
+//	G(k) 为以k为密钥的AES-CTR伪随机数生成器，输出m比特
+//	H(j, q) 为SHA-256，j为OT编号
+//
+// 协议步骤：
+// Step 1：基础OT，角色互换。Alice随机选择κ比特的s，作为基础OT的接收方；
+//			Bob为每个i∈[0,κ)随机产生一对种子(k(i,0), k(i,1))，作为基础OT的发送方；
+//			通过ReceiverChoose/SenderEncryptMsg/ReceiverRetrieveMsg，Alice获得k(i,s(i))。
+// Step 2：Bob计算t(i)=G(k(i,0))，u(i)=t(i)⊕G(k(i,1))⊕r，将u(0),...,u(κ-1)发送给Alice。
+// Step 3：Alice计算q(i)=G(k(i,s(i)))⊕(s(i)·u(i))=t(i)⊕(s(i)·r)。
+//			将矩阵按行转置后，第j行满足q(j)=t(j)⊕(r(j)·s)。
+// Step 4：Alice发送y(j,0)=x(j,0)⊕H(j,q(j))，y(j,1)=x(j,1)⊕H(j,q(j)⊕s)。
+// Step 5：Bob计算x(j,r(j))=y(j,r(j))⊕H(j,t(j))。由于不知道s，Bob无法计算另一个掩码。
+//
+// 变体：
+//	关联OT(Correlated OT)：Alice为每次OT指定关联值Δ(j)，x(j,0)=H(j,q(j))为随机值，x(j,1)=x(j,0)⊕Δ(j)，
+//		只需发送y(j)=x(j,0)⊕Δ(j)⊕H(j,q(j)⊕s)，通信量减半；
+//	随机OT(Random OT)：x(j,0)=H(j,q(j))，x(j,1)=H(j,q(j)⊕s)，无需第4步的通信。
+
+const (
+	// BaseOTNumber 基础OT的数量，即计算安全参数κ
+	BaseOTNumber = 128
+
+	// ExtSeedLength 基础OT传输的种子长度，即AES-128的密钥长度
+	ExtSeedLength = 16
+)
+
+var (
+	ExtStateError   = errors.New("ot extension: protocol step is called out of order")
+	ExtMatrixError  = errors.New("ot extension: invalid extension matrix")
+	ExtMsgSizeError = errors.New("ot extension: the number of messages does not match the number of OTs")
+)
+
+// ExtSender OT扩展协议的发送方，在基础OT中作为接收方
+type ExtSender struct {
+	s         []byte              // 基础OT的选择位，κ比特
+	baseKeys  []*ecdsa.PrivateKey // 每次基础OT的接收方私钥
+	senderKey *ecdsa.PublicKey    // 基础OT的发送方公钥
+	seeds     [][]byte            // 基础OT获得的种子k(i,s(i))
+	q         [][]byte            // 转置后的矩阵，第j行为q(j)
+	otNumber  int                 // OT的数量m
+}
+
+// ExtReceiver OT扩展协议的接收方，在基础OT中作为发送方
+type ExtReceiver struct {
+	choices []int             // 每次OT的选择
+	baseKey *ecdsa.PrivateKey // 基础OT的发送方私钥
+	seeds   [2][][]byte       // 基础OT的种子对(k(i,0), k(i,1))
+	t       [][]byte          // 转置后的矩阵，第j行为t(j)
+}
+
+// NewExtSender 创建OT扩展协议的发送方，并随机产生κ比特的基础OT选择位
+func NewExtSender() (*ExtSender, error) {
+	s := make([]byte, BaseOTNumber/8)
+	if _, err := rand.Read(s); err != nil {
+		return nil, err
+	}
+
+	return &ExtSender{s: s}, nil
+}
+
+// NewExtReceiver 创建OT扩展协议的接收方
+// - curve 基础OT使用的椭圆曲线
+// - choices 每次OT的选择，取值为IndexOne或IndexTwo
+func NewExtReceiver(curve elliptic.Curve, choices []int) (*ExtReceiver, error) {
+	for _, choice := range choices {
+		if choice != IndexOne && choice != IndexTwo {
+			return nil, IndexError
+		}
+	}
+
+	baseKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	er := &ExtReceiver{
+		choices: choices,
+		baseKey: baseKey,
+	}
+	for b := 0; b < 2; b++ {
+		for i := 0; i < BaseOTNumber; i++ {
+			seed := make([]byte, ExtSeedLength)
+			if _, err := rand.Read(seed); err != nil {
+				return nil, err
+			}
+			er.seeds[b] = append(er.seeds[b], seed)
+		}
+	}
+
+	return er, nil
+}
+
+// RandomChoices 产生m个随机选择，可用于随机OT
+func RandomChoices(otNumber int) ([]int, error) {
+	bits := make([]byte, (otNumber+7)/8)
+	if _, err := rand.Read(bits); err != nil {
+		return nil, err
+	}
+
+	choices := make([]int, otNumber)
+	for j := range choices {
+		choices[j] = int(getBit(bits, j))
+	}
+
+	return choices, nil
+}
+
+// BaseSenderPublicKey 接收方Bob获取基础OT的发送方公钥，发送给Alice
+func (er *ExtReceiver) BaseSenderPublicKey() *ecdsa.PublicKey {
+	return &er.baseKey.PublicKey
+}
+
+// BaseChoose 发送方Alice根据选择位s执行κ次基础OT的选择，将返回的公钥列表发送给Bob
+func (es *ExtSender) BaseChoose(baseSenderPublicKey *ecdsa.PublicKey) ([]*ecdsa.PublicKey, error) {
+	var publicKeys []*ecdsa.PublicKey
+	var baseKeys []*ecdsa.PrivateKey
+
+	for i := 0; i < BaseOTNumber; i++ {
+		// 每次基础OT使用独立的接收方私钥
+		baseKey, err := ecdsa.GenerateKey(baseSenderPublicKey.Curve, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey, err := ReceiverChoose(baseKey, baseSenderPublicKey, int(getBit(es.s, i)))
+		if err != nil {
+			return nil, err
+		}
+
+		baseKeys = append(baseKeys, baseKey)
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	es.baseKeys = baseKeys
+	es.senderKey = baseSenderPublicKey
+
+	return publicKeys, nil
+}
+
+// BaseEncrypt 接收方Bob加密κ对种子，将密文发送给Alice
+func (er *ExtReceiver) BaseEncrypt(baseReceiverPublicKeys []*ecdsa.PublicKey) ([][]string, error) {
+	if len(baseReceiverPublicKeys) != BaseOTNumber {
+		return nil, fmt.Errorf("ot extension: expect %d base OT public keys, got %d", BaseOTNumber, len(baseReceiverPublicKeys))
+	}
+
+	var cts [][]string
+	for i, publicKey := range baseReceiverPublicKeys {
+		msgs := []string{string(er.seeds[0][i]), string(er.seeds[1][i])}
+		ct, err := SenderEncryptMsg(er.baseKey, publicKey, msgs)
+		if err != nil {
+			return nil, err
+		}
+		cts = append(cts, ct)
+	}
+
+	return cts, nil
+}
+
+// BaseRetrieve 发送方Alice解密基础OT的密文，获得种子k(i,s(i))
+func (es *ExtSender) BaseRetrieve(cts [][]string) error {
+	if es.baseKeys == nil {
+		return ExtStateError
+	}
+	if len(cts) != BaseOTNumber {
+		return fmt.Errorf("ot extension: expect %d base OT ciphertexts, got %d", BaseOTNumber, len(cts))
+	}
+
+	var seeds [][]byte
+	for i, ct := range cts {
+		seed, err := ReceiverRetrieveMsg(es.baseKeys[i], es.senderKey, ct, int(getBit(es.s, i)))
+		if err != nil {
+			return err
+		}
+		if len(seed) != ExtSeedLength {
+			return fmt.Errorf("ot extension: invalid base OT seed length %d", len(seed))
+		}
+		seeds = append(seeds, []byte(seed))
+	}
+
+	es.seeds = seeds
+	es.baseKeys = nil
+
+	return nil
+}
+
+// Extend 接收方Bob计算t(i)和u(i)，将u(0),...,u(κ-1)发送给Alice
+func (er *ExtReceiver) Extend() [][]byte {
+	otNumber := len(er.choices)
+
+	// 选择位r，按比特打包
+	r := make([]byte, (otNumber+7)/8)
+	for j, choice := range er.choices {
+		if choice == IndexTwo {
+			setBit(r, j)
+		}
+	}
+
+	t := make([][]byte, BaseOTNumber)
+	u := make([][]byte, BaseOTNumber)
+	for i := 0; i < BaseOTNumber; i++ {
+		t[i] = prg(er.seeds[0][i], len(r))
+		u[i] = prg(er.seeds[1][i], len(r))
+		xorBytes(u[i], u[i], t[i])
+		xorBytes(u[i], u[i], r)
+	}
+
+	er.t = transpose(t, otNumber)
+
+	return u
+}
+
+// Receive 发送方Alice收到u(0),...,u(κ-1)后计算矩阵q
+func (es *ExtSender) Receive(u [][]byte) error {
+	if es.seeds == nil {
+		return ExtStateError
+	}
+	if len(u) != BaseOTNumber {
+		return ExtMatrixError
+	}
+
+	columnLen := len(u[0])
+	q := make([][]byte, BaseOTNumber)
+	for i := 0; i < BaseOTNumber; i++ {
+		if len(u[i]) != columnLen {
+			return ExtMatrixError
+		}
+
+		// q(i) = G(k(i,s(i))) ⊕ (s(i)·u(i))
+		q[i] = prg(es.seeds[i], columnLen)
+		if getBit(es.s, i) == 1 {
+			xorBytes(q[i], q[i], u[i])
+		}
+	}
+
+	es.otNumber = columnLen * 8
+	es.q = transpose(q, es.otNumber)
+
+	return nil
+}
+
+// Encrypt 发送方Alice加密每次OT的两份数据，得到y(j,0)和y(j,1)
+// 由于u按字节传输，Alice获得的OT数量会按8对齐，msgs的数量需要与接收方的选择数量一致
+// - msgs msgs[j]为第j次OT的两份数据
+func (es *ExtSender) Encrypt(msgs [][2][]byte) ([][2][]byte, error) {
+	if es.q == nil {
+		return nil, ExtStateError
+	}
+	if len(msgs) > es.otNumber || len(msgs) <= es.otNumber-8 {
+		return nil, ExtMsgSizeError
+	}
+
+	ys := make([][2][]byte, len(msgs))
+	qs := make([]byte, ExtSeedLength)
+	for j, msg := range msgs {
+		// y(j,0) = x(j,0) ⊕ H(j,q(j))
+		ys[j][0] = make([]byte, len(msg[0]))
+		xorBytes(ys[j][0], msg[0], hashPad(j, es.q[j], len(msg[0])))
+
+		// y(j,1) = x(j,1) ⊕ H(j,q(j)⊕s)
+		xorBytes(qs, es.q[j], es.s)
+		ys[j][1] = make([]byte, len(msg[1]))
+		xorBytes(ys[j][1], msg[1], hashPad(j, qs, len(msg[1])))
+	}
+
+	return ys, nil
+}
+
+// Retrieve 接收方Bob根据选择解密y(j,r(j))，获得x(j,r(j))
+func (er *ExtReceiver) Retrieve(ys [][2][]byte) ([][]byte, error) {
+	if er.t == nil {
+		return nil, ExtStateError
+	}
+	if len(ys) != len(er.choices) {
+		return nil, ExtMsgSizeError
+	}
+
+	msgs := make([][]byte, len(ys))
+	for j, choice := range er.choices {
+		y := ys[j][choice]
+		msgs[j] = make([]byte, len(y))
+		xorBytes(msgs[j], y, hashPad(j, er.t[j], len(y)))
+	}
+
+	return msgs, nil
+}
+
+// CorrelatedEncrypt 关联OT，发送方Alice指定每次OT的关联值Δ(j)
+// 返回值ys发送给Bob，x0为Alice本地保存的x(j,0)，x(j,1)=x(j,0)⊕Δ(j)
+func (es *ExtSender) CorrelatedEncrypt(deltas [][]byte) (ys [][]byte, x0 [][]byte, err error) {
+	if es.q == nil {
+		return nil, nil, ExtStateError
+	}
+	if len(deltas) > es.otNumber || len(deltas) <= es.otNumber-8 {
+		return nil, nil, ExtMsgSizeError
+	}
+
+	ys = make([][]byte, len(deltas))
+	x0 = make([][]byte, len(deltas))
+	qs := make([]byte, ExtSeedLength)
+	for j, delta := range deltas {
+		// x(j,0) = H(j,q(j))
+		x0[j] = hashPad(j, es.q[j], len(delta))
+
+		// y(j) = x(j,0) ⊕ Δ(j) ⊕ H(j,q(j)⊕s)
+		xorBytes(qs, es.q[j], es.s)
+		ys[j] = hashPad(j, qs, len(delta))
+		xorBytes(ys[j], ys[j], x0[j])
+		xorBytes(ys[j], ys[j], delta)
+	}
+
+	return ys, x0, nil
+}
+
+// CorrelatedRetrieve 关联OT，接收方Bob获得x(j,r(j))
+// r(j)=0时为H(j,t(j))，r(j)=1时为y(j)⊕H(j,t(j))
+func (er *ExtReceiver) CorrelatedRetrieve(ys [][]byte) ([][]byte, error) {
+	if er.t == nil {
+		return nil, ExtStateError
+	}
+	if len(ys) != len(er.choices) {
+		return nil, ExtMsgSizeError
+	}
+
+	msgs := make([][]byte, len(ys))
+	for j, choice := range er.choices {
+		msgs[j] = hashPad(j, er.t[j], len(ys[j]))
+		if choice == IndexTwo {
+			xorBytes(msgs[j], msgs[j], ys[j])
+		}
+	}
+
+	return msgs, nil
+}
+
+// RandomOTSender 随机OT，发送方Alice获得otNumber对长度为msgLen的随机数据，无需额外通信
+func (es *ExtSender) RandomOTSender(otNumber, msgLen int) ([][2][]byte, error) {
+	if es.q == nil {
+		return nil, ExtStateError
+	}
+	if otNumber > es.otNumber || otNumber <= es.otNumber-8 {
+		return nil, ExtMsgSizeError
+	}
+
+	msgs := make([][2][]byte, otNumber)
+	qs := make([]byte, ExtSeedLength)
+	for j := 0; j < otNumber; j++ {
+		xorBytes(qs, es.q[j], es.s)
+		msgs[j][0] = hashPad(j, es.q[j], msgLen)
+		msgs[j][1] = hashPad(j, qs, msgLen)
+	}
+
+	return msgs, nil
+}
+
+// RandomOTReceiver 随机OT，接收方Bob获得每次OT中选择的随机数据
+func (er *ExtReceiver) RandomOTReceiver(msgLen int) ([][]byte, error) {
+	if er.t == nil {
+		return nil, ExtStateError
+	}
+
+	msgs := make([][]byte, len(er.choices))
+	for j := range er.choices {
+		msgs[j] = hashPad(j, er.t[j], msgLen)
+	}
+
+	return msgs, nil
+}
+
+// prg 以seed为AES-128密钥，CTR模式产生length字节的伪随机数
+func prg(seed []byte, length int) []byte {
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		// seed的长度固定为ExtSeedLength, will never happen
+		panic(err)
+	}
+
+	out := make([]byte, length)
+	iv := make([]byte, aes.BlockSize)
+	cipher.NewCTR(block, iv).XORKeyStream(out, out)
+
+	return out
+}
+
+// hashPad 计算H(j,q)并扩展到length字节
+// length不超过32字节时直接截取SHA-256的结果，否则以结果为密钥和IV，用AES-CTR扩展
+func hashPad(j int, q []byte, length int) []byte {
+	buf := make([]byte, 8, 8+len(q))
+	binary.BigEndian.PutUint64(buf, uint64(j))
+	digest := hash.HashUsingSha256(append(buf, q...))
+
+	if length <= len(digest) {
+		return digest[:length]
+	}
+
+	block, _ := aes.NewCipher(digest[:16])
+	out := make([]byte, length)
+	cipher.NewCTR(block, digest[16:]).XORKeyStream(out, out)
+
+	return out
+}
+
+// transpose 将κ列、每列rows比特的矩阵转置为rows行、每行κ比特
+func transpose(columns [][]byte, rows int) [][]byte {
+	result := make([][]byte, rows)
+	for j := range result {
+		result[j] = make([]byte, len(columns)/8)
+	}
+
+	for i, column := range columns {
+		for j := 0; j < rows; j++ {
+			if getBit(column, j) == 1 {
+				setBit(result[j], i)
+			}
+		}
+	}
+
+	return result
+}
+
+// getBit 获取第i个比特，低位在前
+func getBit(b []byte, i int) byte {
+	return (b[i/8] >> (uint(i) % 8)) & 1
+}
+
+// setBit 将第i个比特置为1，低位在前
+func setBit(b []byte, i int) {
+	b[i/8] |= 1 << (uint(i) % 8)
+}
+
+// xorBytes dst = a ⊕ b，长度以dst为准
+func xorBytes(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oblivious_transfer
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupExtension 完成基础OT和扩展矩阵的计算
+func setupExtension(t *testing.T, choices []int) (*ExtSender, *ExtReceiver) {
+	sender, err := NewExtSender()
+	if err != nil {
+		t.Fatalf("NewExtSender err is %v", err)
+	}
+	receiver, err := NewExtReceiver(elliptic.P256(), choices)
+	if err != nil {
+		t.Fatalf("NewExtReceiver err is %v", err)
+	}
+
+	publicKeys, err := sender.BaseChoose(receiver.BaseSenderPublicKey())
+	if err != nil {
+		t.Fatalf("BaseChoose err is %v", err)
+	}
+	cts, err := receiver.BaseEncrypt(publicKeys)
+	if err != nil {
+		t.Fatalf("BaseEncrypt err is %v", err)
+	}
+	if err := sender.BaseRetrieve(cts); err != nil {
+		t.Fatalf("BaseRetrieve err is %v", err)
+	}
+
+	u := receiver.Extend()
+	if err := sender.Receive(u); err != nil {
+		t.Fatalf("Receive err is %v", err)
+	}
+
+	return sender, receiver
+}
+
+func TestOTExtension(t *testing.T) {
+	otNumber := 1001
+	choices, err := RandomChoices(otNumber)
+	if err != nil {
+		t.Fatalf("RandomChoices err is %v", err)
+	}
+	sender, receiver := setupExtension(t, choices)
+
+	// 1 of 2 OT
+	msgs := make([][2][]byte, otNumber)
+	for j := range msgs {
+		msgs[j][0] = []byte(fmt.Sprintf("ot %d msg 0, long enough to exceed one sha256 digest", j))
+		msgs[j][1] = []byte(fmt.Sprintf("ot %d msg 1", j))
+	}
+	ys, err := sender.Encrypt(msgs)
+	if err != nil {
+		t.Fatalf("Encrypt err is %v", err)
+	}
+	msgsChosen, err := receiver.Retrieve(ys)
+	if err != nil {
+		t.Fatalf("Retrieve err is %v", err)
+	}
+	for j, choice := range choices {
+		require.Equal(t, msgs[j][choice], msgsChosen[j])
+	}
+
+	// correlated OT
+	deltas := make([][]byte, otNumber)
+	for j := range deltas {
+		deltas[j] = []byte(fmt.Sprintf("delta-%08d", j))
+	}
+	cys, x0, err := sender.CorrelatedEncrypt(deltas)
+	if err != nil {
+		t.Fatalf("CorrelatedEncrypt err is %v", err)
+	}
+	xs, err := receiver.CorrelatedRetrieve(cys)
+	if err != nil {
+		t.Fatalf("CorrelatedRetrieve err is %v", err)
+	}
+	for j, choice := range choices {
+		expected := append([]byte{}, x0[j]...)
+		if choice == IndexTwo {
+			xorBytes(expected, expected, deltas[j])
+		}
+		require.Equal(t, expected, xs[j])
+	}
+
+	// random OT
+	senderMsgs, err := sender.RandomOTSender(otNumber, 16)
+	if err != nil {
+		t.Fatalf("RandomOTSender err is %v", err)
+	}
+	receiverMsgs, err := receiver.RandomOTReceiver(16)
+	if err != nil {
+		t.Fatalf("RandomOTReceiver err is %v", err)
+	}
+	for j, choice := range choices {
+		require.Equal(t, senderMsgs[j][choice], receiverMsgs[j])
+		if bytes.Equal(senderMsgs[j][1-choice], receiverMsgs[j]) {
+			t.Errorf("receiver learns both messages of ot %d", j)
+		}
+	}
+}
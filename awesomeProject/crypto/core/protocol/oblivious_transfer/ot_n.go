@@ -0,0 +1,230 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oblivious_transfer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+// 1 of N 不经意传输协议 - 基于CDH假设和椭圆曲线ECC，是1 of 2协议的推广
+// 1 of N Oblivious Transfer Protocol - based on the CDH assumption
+//
+// 数据传输的步骤：
+// Step 1：Alice产生1个公钥-私钥组合(Pub'A,Prv'A)，然后将公钥Pub'A发送给Bob。
+// Step 2：Bob产生1个公钥-私钥组合(Pub'B,Prv'B)，选择需要第c份数据M(c)，0 <= c < N，
+//			将Pub'B+c*Pub'A作为公钥Pub'B发送给Alice。c=0时即为Pub'B本身，与1 of 2协议一致。
+// Step 3：Alice对每份数据M(i)计算加密密钥：
+//			Key(i)=Hash((Pub'B-i*Pub'A)^Prv'A)
+//			当i=c时，(Pub'B-c*Pub'A)^Prv'A = Pub'B^Prv'A = (g^b)^a，其余的Key(i)对Bob来说是不可计算的
+// Step 4：Alice用Key(i)加密M(i)得到s(i)，将s(0),s(1),...,s(N-1)发给Bob。
+// Step 5：Bob计算Key(c)=Hash(Pub'A^Prv'B)，只能解密s(c)得到M(c)。
+//
+// 与1 of 2协议不同，这里使用对称加密(AES-GCM)加密数据，而不是ECIES，以降低N较大时的计算开销。
+//
+// 批量不经意传输：
+// 接收方在一次交互中完成k次相互独立的1 of N传输，所有传输共享发送方的公私钥对，
+// 接收方为每次传输产生独立的公私钥对，避免发送方通过公钥之差推断出不同传输选择的关系。
+// 密钥派生时加入传输编号j，即Key(j,i)=Hash(j||i||(Pub'Bj-i*Pub'A)^Prv'A)。
+
+var (
+	IndexOutOfRangeError  = errors.New("chosenIndex is invalid. Must be within [0, N)")
+	InvalidMsgNumberError = errors.New("the number of messages must be greater than one")
+	InvalidBatchSizeError = errors.New("the number of transfers does not match")
+)
+
+// ReceiverChooseOneOfN 接收方Bob选择需要哪份数据，也就是做1 of N选择
+// - receiverPrivateKey 接收方私钥
+// - senderPublicKey 发送方公钥
+// - chosenIndex 选择的数据编号，0 <= chosenIndex < msgNum
+// - msgNum 发送方持有的数据份数N
+func ReceiverChooseOneOfN(receiverPrivateKey *ecdsa.PrivateKey, senderPublicKey *ecdsa.PublicKey, chosenIndex, msgNum int) (*ecdsa.PublicKey, error) {
+	if msgNum < 2 {
+		return nil, InvalidMsgNumberError
+	}
+	if chosenIndex < 0 || chosenIndex >= msgNum {
+		return nil, IndexOutOfRangeError
+	}
+
+	// 如果选择M(0)，将公钥Pub'B发送给Alice。
+	if chosenIndex == 0 {
+		return &receiverPrivateKey.PublicKey, nil
+	}
+
+	// 否则，将Pub'B+c*Pub'A发送给Alice
+	curve := receiverPrivateKey.Curve
+	cX, cY := curve.ScalarMult(senderPublicKey.X, senderPublicKey.Y, big.NewInt(int64(chosenIndex)).Bytes())
+	newX, newY := curve.Add(receiverPrivateKey.PublicKey.X, receiverPrivateKey.PublicKey.Y, cX, cY)
+
+	newPubKey := new(ecdsa.PublicKey)
+	newPubKey.Curve = curve
+	newPubKey.X = newX
+	newPubKey.Y = newY
+
+	return newPubKey, nil
+}
+
+// SenderEncryptOneOfN 发送方Alice根据接收方Bob发来的公钥，加密全部N份数据
+func SenderEncryptOneOfN(senderPrivateKey *ecdsa.PrivateKey, receiverPublicKey *ecdsa.PublicKey, msgs [][]byte) ([][]byte, error) {
+	return senderEncryptOneOfN(senderPrivateKey, receiverPublicKey, msgs, 0)
+}
+
+// ReceiverRetrieveOneOfN Bob根据之前的选择结果，解密并获取自己需要的数据
+func ReceiverRetrieveOneOfN(receiverPrivateKey *ecdsa.PrivateKey, senderPublicKey *ecdsa.PublicKey, cts [][]byte, chosenIndex int) ([]byte, error) {
+	return receiverRetrieveOneOfN(receiverPrivateKey, senderPublicKey, cts, chosenIndex, 0)
+}
+
+// ReceiverBatchChoose 接收方Bob一次完成k次1 of N选择，为每次传输产生独立的公私钥对
+// 返回的私钥由接收方保存，用于解密；公钥发送给Alice
+// - senderPublicKey 发送方公钥
+// - chosenIndices 每次传输选择的数据编号
+// - msgNum 每次传输中发送方持有的数据份数N
+func ReceiverBatchChoose(senderPublicKey *ecdsa.PublicKey, chosenIndices []int, msgNum int) ([]*ecdsa.PrivateKey, []*ecdsa.PublicKey, error) {
+	var privateKeys []*ecdsa.PrivateKey
+	var publicKeys []*ecdsa.PublicKey
+
+	for _, chosenIndex := range chosenIndices {
+		privateKey, err := ecdsa.GenerateKey(senderPublicKey.Curve, rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		publicKey, err := ReceiverChooseOneOfN(privateKey, senderPublicKey, chosenIndex, msgNum)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		privateKeys = append(privateKeys, privateKey)
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	return privateKeys, publicKeys, nil
+}
+
+// SenderBatchEncryptMsg 发送方Alice一次加密k次传输的全部数据
+// - receiverPublicKeys 接收方为每次传输发来的公钥
+// - msgs msgs[j]为第j次传输的N份数据
+func SenderBatchEncryptMsg(senderPrivateKey *ecdsa.PrivateKey, receiverPublicKeys []*ecdsa.PublicKey, msgs [][][]byte) ([][][]byte, error) {
+	if len(receiverPublicKeys) != len(msgs) {
+		return nil, InvalidBatchSizeError
+	}
+
+	cts := make([][][]byte, len(msgs))
+	for j := range msgs {
+		ct, err := senderEncryptOneOfN(senderPrivateKey, receiverPublicKeys[j], msgs[j], j)
+		if err != nil {
+			return nil, err
+		}
+		cts[j] = ct
+	}
+
+	return cts, nil
+}
+
+// ReceiverBatchRetrieveMsg Bob根据之前的选择结果，解密每次传输中自己需要的数据
+// - receiverPrivateKeys ReceiverBatchChoose返回的私钥
+// - cts cts[j]为第j次传输的N份密文
+// - chosenIndices 每次传输选择的数据编号
+func ReceiverBatchRetrieveMsg(receiverPrivateKeys []*ecdsa.PrivateKey, senderPublicKey *ecdsa.PublicKey, cts [][][]byte, chosenIndices []int) ([][]byte, error) {
+	if len(receiverPrivateKeys) != len(cts) || len(cts) != len(chosenIndices) {
+		return nil, InvalidBatchSizeError
+	}
+
+	msgs := make([][]byte, len(cts))
+	for j := range cts {
+		msg, err := receiverRetrieveOneOfN(receiverPrivateKeys[j], senderPublicKey, cts[j], chosenIndices[j], j)
+		if err != nil {
+			return nil, err
+		}
+		msgs[j] = msg
+	}
+
+	return msgs, nil
+}
+
+// senderEncryptOneOfN 发送方加密第transfer次传输的N份数据
+func senderEncryptOneOfN(senderPrivateKey *ecdsa.PrivateKey, receiverPublicKey *ecdsa.PublicKey, msgs [][]byte, transfer int) ([][]byte, error) {
+	if len(msgs) < 2 {
+		return nil, InvalidMsgNumberError
+	}
+
+	curve := senderPrivateKey.Curve
+	if !curve.IsOnCurve(receiverPublicKey.X, receiverPublicKey.Y) {
+		return nil, errors.New("receiver public key is not on curve")
+	}
+
+	// -Pub'A，如果Pub'A = (x,y)，则 -Pub'A = (x, -y mod P)
+	negX := senderPrivateKey.PublicKey.X
+	negY := new(big.Int).Sub(curve.Params().P, senderPrivateKey.PublicKey.Y)
+
+	// 依次计算Pub'B-i*Pub'A，初始值为Pub'B
+	x, y := receiverPublicKey.X, receiverPublicKey.Y
+
+	var cts [][]byte
+	for i, msg := range msgs {
+		if i > 0 {
+			x, y = curve.Add(x, y, negX, negY)
+		}
+
+		// (Pub'B-i*Pub'A)^Prv'A
+		kX, kY := curve.ScalarMult(x, y, senderPrivateKey.D.Bytes())
+		aesKey := deriveOTKey(curve, kX, kY, transfer, i)
+
+		ct, err := aes.EncryptUsingAESGCM(aesKey, msg, nil)
+		if err != nil {
+			return nil, err
+		}
+		cts = append(cts, ct)
+	}
+
+	return cts, nil
+}
+
+// receiverRetrieveOneOfN 接收方解密第transfer次传输中选择的数据
+func receiverRetrieveOneOfN(receiverPrivateKey *ecdsa.PrivateKey, senderPublicKey *ecdsa.PublicKey, cts [][]byte, chosenIndex, transfer int) ([]byte, error) {
+	if chosenIndex < 0 || chosenIndex >= len(cts) {
+		return nil, IndexOutOfRangeError
+	}
+
+	// Pub'A^Prv'B
+	curve := receiverPrivateKey.Curve
+	kX, kY := curve.ScalarMult(senderPublicKey.X, senderPublicKey.Y, receiverPrivateKey.D.Bytes())
+	aesKey := deriveOTKey(curve, kX, kY, transfer, chosenIndex)
+
+	return aes.DecryptUsingAESGCM(aesKey, cts[chosenIndex], nil)
+}
+
+// deriveOTKey 由共享点派生出第transfer次传输中第index份数据的AES-GCM密钥
+// 每个密钥只加密一份数据，因此nonce同样可以确定性地派生
+func deriveOTKey(curve elliptic.Curve, x, y *big.Int, transfer, index int) aes.AESKey {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[:8], uint64(transfer))
+	binary.BigEndian.PutUint64(buf[8:], uint64(index))
+	buf = append(buf, elliptic.Marshal(curve, x, y)...)
+
+	key := hash.HashUsingSha256(buf)
+	nonce := hash.HashUsingSha256(key)
+
+	return aes.AESKey{
+		Key:   key,
+		Nonce: nonce[:12],
+	}
+}
@@ -17,7 +17,13 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"fmt"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
 )
 
 func TestOT(t *testing.T) {
@@ -48,3 +54,93 @@ func TestOT(t *testing.T) {
 	}
 	t.Logf("msgChosen is: %s", msgChosen)
 }
+
+func TestOTOneOfN(t *testing.T) {
+	var msgs [][]byte
+	for i := 0; i < 5; i++ {
+		msgs = append(msgs, []byte(fmt.Sprintf("msg %d for 1 of n ot protocol", i)))
+	}
+
+	senderPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	receiverPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for chosenIndex := range msgs {
+		receiverPublicKeyForSender, err := ReceiverChooseOneOfN(receiverPrivateKey, &senderPrivateKey.PublicKey, chosenIndex, len(msgs))
+		if err != nil {
+			t.Fatalf("ReceiverChooseOneOfN err is %v", err)
+		}
+		cts, err := SenderEncryptOneOfN(senderPrivateKey, receiverPublicKeyForSender, msgs)
+		if err != nil {
+			t.Fatalf("SenderEncryptOneOfN err is %v", err)
+		}
+		msgChosen, err := ReceiverRetrieveOneOfN(receiverPrivateKey, &senderPrivateKey.PublicKey, cts, chosenIndex)
+		if err != nil {
+			t.Fatalf("ReceiverRetrieveOneOfN err is %v", err)
+		}
+		require.Equal(t, msgs[chosenIndex], msgChosen)
+
+		// 其他数据无法解密
+		otherIndex := (chosenIndex + 1) % len(msgs)
+		if _, err := ReceiverRetrieveOneOfN(receiverPrivateKey, &senderPrivateKey.PublicKey, cts, otherIndex); err == nil {
+			t.Errorf("message %d should not be decrypted", otherIndex)
+		}
+	}
+
+	if _, err := ReceiverChooseOneOfN(receiverPrivateKey, &senderPrivateKey.PublicKey, len(msgs), len(msgs)); err != IndexOutOfRangeError {
+		t.Errorf("expected IndexOutOfRangeError, got %v", err)
+	}
+}
+
+func TestBatchOT(t *testing.T) {
+	msgNum := 4
+	chosenIndices := []int{3, 0, 1, 1, 2}
+
+	var msgs [][][]byte
+	for j := range chosenIndices {
+		var transferMsgs [][]byte
+		for i := 0; i < msgNum; i++ {
+			transferMsgs = append(transferMsgs, []byte(fmt.Sprintf("transfer %d msg %d", j, i)))
+		}
+		msgs = append(msgs, transferMsgs)
+	}
+
+	senderPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	receiverPrivateKeys, receiverPublicKeys, err := ReceiverBatchChoose(&senderPrivateKey.PublicKey, chosenIndices, msgNum)
+	if err != nil {
+		t.Fatalf("ReceiverBatchChoose err is %v", err)
+	}
+	cts, err := SenderBatchEncryptMsg(senderPrivateKey, receiverPublicKeys, msgs)
+	if err != nil {
+		t.Fatalf("SenderBatchEncryptMsg err is %v", err)
+	}
+	msgsChosen, err := ReceiverBatchRetrieveMsg(receiverPrivateKeys, &senderPrivateKey.PublicKey, cts, chosenIndices)
+	if err != nil {
+		t.Fatalf("ReceiverBatchRetrieveMsg err is %v", err)
+	}
+	for j, chosenIndex := range chosenIndices {
+		require.Equal(t, msgs[j][chosenIndex], msgsChosen[j])
+	}
+}
+
+func TestOTCurves(t *testing.T) {
+	msgs := []string{"msg 0 for ot protocol", "msg 1 for ot protocol"}
+
+	for _, name := range []string{config.CurveNistP384, config.CurveSecp256k1, config.CurveSM2} {
+		curve, err := ecc.CurveByName(name)
+		require.NoError(t, err)
+
+		senderPrivateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		receiverPrivateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		receiverPublicKey, err := ReceiverChoose(receiverPrivateKey, &senderPrivateKey.PublicKey, IndexOne)
+		require.NoError(t, err, name)
+		cts, err := SenderEncryptMsg(senderPrivateKey, receiverPublicKey, msgs)
+		require.NoError(t, err, name)
+		msgChosen, err := ReceiverRetrieveMsg(receiverPrivateKey, &senderPrivateKey.PublicKey, cts, IndexOne)
+		require.NoError(t, err, name)
+		require.Equal(t, msgs[IndexOne], msgChosen, name)
+	}
+}
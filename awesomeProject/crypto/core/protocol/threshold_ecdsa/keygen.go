@@ -0,0 +1,257 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package threshold_ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+	dtxecdsa "github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	vss "github.com/PaddlePaddle/PaddleDTX/crypto/core/secret_share/complex_secret_share"
+)
+
+// t-of-n门限ECDSA(GG18的简化版本，假设参与方是半诚实的)
+//
+// 分布式密钥生成，参与方序号为1...n：
+// Round 1：参与方i随机选择u(i)，生成常数项为u(i)的t-1次多项式F(i)(x)及其Feldman承诺，
+//			将F(i)(j)秘密地发送给参与方j，同时广播承诺和自己的Paillier公钥
+// Round 2：参与方j使用承诺验证收到的碎片，私钥碎片为x(j) = Σ F(i)(j)，
+//			公钥为Y = Σ u(i)G，即所有承诺的常数项之和，私钥x = Σ u(i)从未在任何一方出现
+//
+// 注意：
+// 1. 本实现未包含GG18中的零知识证明(Paillier密钥证明、MtA范围证明等)，仅能抵抗半诚实的参与方
+// 2. 协议依赖广播信道的一致性，即所有参与方收到的广播消息相同
+
+var (
+	ErrInvalidThreshold     = errors.New("threshold must within [2, partyNum]")
+	ErrInvalidPartyIndex    = errors.New("party index must within [1, partyNum]")
+	ErrUnexpectedMessage    = errors.New("unexpected message")
+	ErrMissingMessage       = errors.New("missing message from party")
+	ErrInvalidShare         = errors.New("secret share does not match the commitments")
+	ErrPaillierKeyTooShort  = errors.New("paillier modulus is too short for the curve")
+	ErrInvalidRoundSequence = errors.New("protocol rounds must be executed in order")
+)
+
+// KeyGenMessage 密钥生成Round 1中参与方From发送给参与方To的消息
+type KeyGenMessage struct {
+	From int
+	To   int
+
+	// 广播部分，发送给每个参与方的内容相同
+	Commitments       []*ecc.Point
+	PaillierPublicKey *paillier.PublicKey
+
+	// 点对点部分，需通过加密信道发送
+	Share *big.Int
+}
+
+// KeyShare 参与方持有的门限密钥
+type KeyShare struct {
+	Index     int
+	Threshold int
+	PartyNum  int
+	Curve     elliptic.Curve
+
+	// 私钥碎片x(i)
+	Share *big.Int
+
+	// 公共的ECDSA公钥
+	PublicKey *ecdsa.PublicKey
+
+	// 自己的Paillier私钥，以及所有参与方的Paillier公钥，用于签名时的MtA
+	PaillierPrivateKey *paillier.PrivateKey
+	PaillierPublicKeys map[int]*paillier.PublicKey
+}
+
+// KeyGenParty 分布式密钥生成的参与方
+type KeyGenParty struct {
+	index     int
+	partyNum  int
+	threshold int
+	curve     elliptic.Curve
+
+	paillierKey *paillier.PrivateKey
+	shares      map[int]*big.Int
+	commitments []*ecc.Point
+}
+
+// NewKeyGenParty 创建分布式密钥生成的参与方
+// - index 参与方序号，从1开始
+// - partyNum 参与方总数n
+// - threshold 签名所需的最少参与方数量t
+func NewKeyGenParty(index, partyNum, threshold int, curve elliptic.Curve) (*KeyGenParty, error) {
+	if threshold < 2 || threshold > partyNum {
+		return nil, ErrInvalidThreshold
+	}
+	if index < 1 || index > partyNum {
+		return nil, ErrInvalidPartyIndex
+	}
+
+	return &KeyGenParty{
+		index:     index,
+		partyNum:  partyNum,
+		threshold: threshold,
+		curve:     curve,
+	}, nil
+}
+
+// Round1 生成自己的秘密多项式和Paillier密钥，返回发送给其他每个参与方的消息
+func (kp *KeyGenParty) Round1() ([]*KeyGenMessage, error) {
+	u, err := rand.Int(rand.Reader, kp.curve.Params().N)
+	if err != nil {
+		return nil, err
+	}
+
+	kp.shares, kp.commitments, err = vss.ComplexSecretSplitWithVerifyPoints(kp.partyNum, kp.threshold, u.Bytes(), kp.curve)
+	if err != nil {
+		return nil, err
+	}
+
+	kp.paillierKey, err = paillier.GeneratePrivateKey(paillier.DefaultPrimeLength)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPaillierKey(&kp.paillierKey.PublicKey, kp.curve); err != nil {
+		return nil, err
+	}
+
+	var msgs []*KeyGenMessage
+	for j := 1; j <= kp.partyNum; j++ {
+		if j == kp.index {
+			continue
+		}
+		msgs = append(msgs, &KeyGenMessage{
+			From:              kp.index,
+			To:                j,
+			Commitments:       kp.commitments,
+			PaillierPublicKey: &kp.paillierKey.PublicKey,
+			Share:             kp.shares[j],
+		})
+	}
+
+	return msgs, nil
+}
+
+// Round2 验证收到的碎片，计算自己的私钥碎片和公共的ECDSA公钥
+func (kp *KeyGenParty) Round2(msgs []*KeyGenMessage) (*KeyShare, error) {
+	if kp.shares == nil {
+		return nil, ErrInvalidRoundSequence
+	}
+
+	routes := make([][2]int, len(msgs))
+	for i, msg := range msgs {
+		routes[i] = [2]int{msg.From, msg.To}
+	}
+	if err := checkRoutes(kp.index, allParties(kp.partyNum), routes); err != nil {
+		return nil, err
+	}
+
+	n := kp.curve.Params().N
+	share := new(big.Int).Mod(kp.shares[kp.index], n)
+	publicKey := kp.commitments[0]
+	paillierPublicKeys := map[int]*paillier.PublicKey{kp.index: &kp.paillierKey.PublicKey}
+
+	var err error
+	for _, msg := range msgs {
+		j := msg.From
+		if len(msg.Commitments) != kp.threshold {
+			return nil, fmt.Errorf("%w: party %d sent %d commitments", ErrUnexpectedMessage, j, len(msg.Commitments))
+		}
+		if !vss.VerifyShareWithPoints(kp.index, msg.Share, msg.Commitments, kp.curve) {
+			return nil, fmt.Errorf("%w: from party %d", ErrInvalidShare, j)
+		}
+		if err := checkPaillierKey(msg.PaillierPublicKey, kp.curve); err != nil {
+			return nil, fmt.Errorf("party %d: %w", j, err)
+		}
+
+		share.Add(share, msg.Share)
+		if publicKey, err = publicKey.Add(msg.Commitments[0]); err != nil {
+			return nil, err
+		}
+		paillierPublicKeys[j] = msg.PaillierPublicKey
+	}
+
+	return &KeyShare{
+		Index:     kp.index,
+		Threshold: kp.threshold,
+		PartyNum:  kp.partyNum,
+		Curve:     kp.curve,
+		Share:     share.Mod(share, n),
+		PublicKey: &ecdsa.PublicKey{
+			Curve: kp.curve,
+			X:     publicKey.X,
+			Y:     publicKey.Y,
+		},
+		PaillierPrivateKey: kp.paillierKey,
+		PaillierPublicKeys: paillierPublicKeys,
+	}, nil
+}
+
+// MarshalPublicKey 将门限密钥的公钥转换为core/ecdsa的公钥类型，可直接用于ecdsa.Verify
+func (ks *KeyShare) MarshalPublicKey() dtxecdsa.PublicKey {
+	return dtxecdsa.MarshalPublicKey(ks.PublicKey)
+}
+
+// checkPaillierKey MtA中k*γ + β'不能在模N上溢出，要求N比q^2大statisticalSecurity+2比特以上
+func checkPaillierKey(publicKey *paillier.PublicKey, curve elliptic.Curve) error {
+	if publicKey == nil || publicKey.N.BitLen() <= 2*curve.Params().N.BitLen()+statisticalSecurity+2 {
+		return ErrPaillierKeyTooShort
+	}
+	return nil
+}
+
+func allParties(partyNum int) []int {
+	parties := make([]int, partyNum)
+	for i := range parties {
+		parties[i] = i + 1
+	}
+	return parties
+}
+
+// checkRoutes 检查是否收到了parties中除自己以外每个参与方的恰好一条消息
+// routes为每条消息的(发送方, 接收方)，广播消息的接收方为0
+func checkRoutes(self int, parties []int, routes [][2]int) error {
+	expected := make(map[int]bool, len(parties))
+	for _, j := range parties {
+		if j != self {
+			expected[j] = true
+		}
+	}
+
+	received := make(map[int]bool, len(expected))
+	for _, route := range routes {
+		from, to := route[0], route[1]
+		if !expected[from] || (to != self && to != 0) {
+			return fmt.Errorf("%w: from %d to %d", ErrUnexpectedMessage, from, to)
+		}
+		if received[from] {
+			return fmt.Errorf("%w: duplicate message from %d", ErrUnexpectedMessage, from)
+		}
+		received[from] = true
+	}
+
+	for j := range expected {
+		if !received[j] {
+			return fmt.Errorf("%w %d", ErrMissingMessage, j)
+		}
+	}
+
+	return nil
+}
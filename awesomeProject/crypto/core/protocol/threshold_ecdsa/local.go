@@ -0,0 +1,188 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package threshold_ecdsa
+
+import (
+	"crypto/elliptic"
+	"sync"
+
+	dtxecdsa "github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+)
+
+// 进程内传输，所有参与方在同一进程中并发执行，每轮结束后按接收方转发消息，用于测试和单机部署
+
+// LocalKeyGen 在进程内执行分布式密钥生成，返回每个参与方的门限密钥，第i项为参与方i+1的密钥
+func LocalKeyGen(partyNum, threshold int, curve elliptic.Curve) ([]*KeyShare, error) {
+	parties := make([]*KeyGenParty, partyNum)
+	for i := range parties {
+		party, err := NewKeyGenParty(i+1, partyNum, threshold, curve)
+		if err != nil {
+			return nil, err
+		}
+		parties[i] = party
+	}
+
+	round1 := make([][]*KeyGenMessage, partyNum)
+	if err := runParallel(partyNum, func(i int) (err error) {
+		round1[i], err = parties[i].Round1()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	inbox := make([][]*KeyGenMessage, partyNum)
+	for _, msgs := range round1 {
+		for _, msg := range msgs {
+			inbox[msg.To-1] = append(inbox[msg.To-1], msg)
+		}
+	}
+
+	keys := make([]*KeyShare, partyNum)
+	if err := runParallel(partyNum, func(i int) (err error) {
+		keys[i], err = parties[i].Round2(inbox[i])
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// LocalSign 在进程内使用给定的门限密钥执行签名，keys中的每个参与方都参与签名
+func LocalSign(keys []*KeyShare, digest []byte) (dtxecdsa.Signature, error) {
+	signers := make([]int, len(keys))
+	for i, key := range keys {
+		signers[i] = key.Index
+	}
+	position := make(map[int]int, len(keys))
+	for i, j := range signers {
+		position[j] = i
+	}
+
+	num := len(keys)
+	parties := make([]*SignParty, num)
+	for i, key := range keys {
+		party, err := NewSignParty(key, signers, digest)
+		if err != nil {
+			return dtxecdsa.Signature{}, err
+		}
+		parties[i] = party
+	}
+
+	// Round 1，点对点
+	inbox1 := make([][]*SignRound1Message, num)
+	out1 := make([][]*SignRound1Message, num)
+	if err := runParallel(num, func(i int) (err error) {
+		out1[i], err = parties[i].Round1()
+		return err
+	}); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+	for _, msgs := range out1 {
+		for _, msg := range msgs {
+			inbox1[position[msg.To]] = append(inbox1[position[msg.To]], msg)
+		}
+	}
+
+	// Round 2，点对点
+	inbox2 := make([][]*SignRound2Message, num)
+	out2 := make([][]*SignRound2Message, num)
+	if err := runParallel(num, func(i int) (err error) {
+		out2[i], err = parties[i].Round2(inbox1[i])
+		return err
+	}); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+	for _, msgs := range out2 {
+		for _, msg := range msgs {
+			inbox2[position[msg.To]] = append(inbox2[position[msg.To]], msg)
+		}
+	}
+
+	// Round 3，广播
+	out3 := make([]*SignRound3Message, num)
+	if err := runParallel(num, func(i int) (err error) {
+		out3[i], err = parties[i].Round3(inbox2[i])
+		return err
+	}); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+
+	// Round 4，广播
+	out4 := make([]*SignRound4Message, num)
+	if err := runParallel(num, func(i int) (err error) {
+		var inbox []*SignRound3Message
+		for k, msg := range out3 {
+			if k != i {
+				inbox = append(inbox, msg)
+			}
+		}
+		out4[i], err = parties[i].Round4(inbox)
+		return err
+	}); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+
+	// Round 5，广播
+	out5 := make([]*SignRound5Message, num)
+	if err := runParallel(num, func(i int) (err error) {
+		var inbox []*SignRound4Message
+		for k, msg := range out4 {
+			if k != i {
+				inbox = append(inbox, msg)
+			}
+		}
+		out5[i], err = parties[i].Round5(inbox)
+		return err
+	}); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+
+	sigs := make([]dtxecdsa.Signature, num)
+	if err := runParallel(num, func(i int) (err error) {
+		var inbox []*SignRound5Message
+		for k, msg := range out5 {
+			if k != i {
+				inbox = append(inbox, msg)
+			}
+		}
+		sigs[i], err = parties[i].Finish(inbox)
+		return err
+	}); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+
+	return sigs[0], nil
+}
+
+// runParallel 并发执行每个参与方的本轮计算，返回第一个错误
+func runParallel(num int, f func(i int) error) error {
+	errs := make([]error, num)
+	var wg sync.WaitGroup
+	for i := 0; i < num; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = f(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,464 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package threshold_ecdsa
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	dtxecdsa "github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+	vss "github.com/PaddlePaddle/PaddleDTX/crypto/core/secret_share/complex_secret_share"
+)
+
+// 门限签名，由不少于t个参与方组成的集合S共同完成，参与方i使用w(i) = λ(i)x(i)作为私钥的加法分享，Σw(i) = x
+//
+// Round 1：参与方i随机选择k(i)、γ(i)，广播Γ(i) = γ(i)G的哈希承诺，并将E(i)(k(i))发送给其他参与方
+// Round 2：MtA(Multiplicative-to-Additive)，对于每个参与方j，参与方i随机选择β'、ν'，返回
+//			E(j)(k(j)γ(i) + β')和E(j)(k(j)w(i) + ν')，并记下β = -β'、ν = -ν'
+// Round 3：参与方i解密得到α、μ，满足α + β = k(j)γ(i)，μ + ν = k(j)w(i)，计算
+//			δ(i) = k(i)γ(i) + Σ(α + β)，σ(i) = k(i)w(i) + Σ(μ + ν)，于是Σδ(i) = kγ，Σσ(i) = kx，广播δ(i)
+// Round 4：参与方i公开Γ(i)和承诺的盲化因子
+// Round 5：验证承诺，计算R = (Σδ(i))^-1 * ΣΓ(i) = k^-1 * G，r = R.x mod q，广播s(i) = m*k(i) + r*σ(i)
+// Finish：s = Σs(i) = k(m + rx)，由于R对应的随机数为k^-1，(r, s)即为标准的ECDSA签名，输出前用公钥验证签名
+//
+// 签名统一转换为low-S形式，即s <= q/2
+
+const (
+	// statisticalSecurity MtA中统计掩码的安全参数
+	statisticalSecurity = 40
+
+	commitmentBlindLength = 32
+)
+
+var (
+	ErrNotEnoughSigners     = errors.New("the number of signers is smaller than the threshold")
+	ErrInvalidSigner        = errors.New("invalid signer index")
+	ErrCommitmentMismatch   = errors.New("decommitment does not match the commitment")
+	ErrInvalidSignature     = errors.New("the combined signature is invalid")
+	ErrDegenerateNonce      = errors.New("degenerate nonce, restart the signing protocol")
+	ErrSignerNotInKeyShares = errors.New("signer has no paillier public key")
+	ErrUnsupportedCurve     = errors.New("the signature format only supports 256-bit curves")
+)
+
+// SignRound1Message 签名Round 1中参与方From发送给参与方To的消息
+type SignRound1Message struct {
+	From int
+	To   int
+
+	Commitment []byte   // 广播，Γ(From)的哈希承诺
+	EncK       *big.Int // E(From)(k(From))
+}
+
+// SignRound2Message 签名Round 2中参与方From发送给参与方To的MtA回复
+type SignRound2Message struct {
+	From int
+	To   int
+
+	EncKGamma *big.Int // E(To)(k(To)γ(From) + β')
+	EncKW     *big.Int // E(To)(k(To)w(From) + ν')
+}
+
+// SignRound3Message 签名Round 3中参与方From广播的消息
+type SignRound3Message struct {
+	From  int
+	Delta *big.Int
+}
+
+// SignRound4Message 签名Round 4中参与方From广播的消息
+type SignRound4Message struct {
+	From  int
+	Gamma *ecc.Point
+	Blind []byte
+}
+
+// SignRound5Message 签名Round 5中参与方From广播的消息
+type SignRound5Message struct {
+	From int
+	S    *big.Int
+}
+
+// SignParty 门限签名的参与方
+type SignParty struct {
+	key     *KeyShare
+	signers []int
+	digest  []byte
+	m       *big.Int
+	round   int
+
+	w, k, gamma *big.Int
+	gammaPoint  *ecc.Point
+	blind       []byte
+
+	commitments map[int][]byte
+	betas, nus  []*big.Int
+	delta       *big.Int
+	sigma       *big.Int
+	deltaSum    *big.Int
+	r           *big.Int
+}
+
+// NewSignParty 创建门限签名的参与方
+// - key 密钥生成得到的门限密钥
+// - signers 参与本次签名的所有参与方序号，数量不少于门限值
+// - digest 待签名的消息摘要
+func NewSignParty(key *KeyShare, signers []int, digest []byte) (*SignParty, error) {
+	if len(signers) < key.Threshold {
+		return nil, ErrNotEnoughSigners
+	}
+	if (key.Curve.Params().N.BitLen()+7)/8 > len(dtxecdsa.Signature{})/2 {
+		return nil, ErrUnsupportedCurve
+	}
+
+	signers = append([]int{}, signers...)
+	sort.Ints(signers)
+	found := false
+	for _, j := range signers {
+		if j < 1 || j > key.PartyNum {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidSigner, j)
+		}
+		if _, ok := key.PaillierPublicKeys[j]; !ok {
+			return nil, fmt.Errorf("%w: %d", ErrSignerNotInKeyShares, j)
+		}
+		found = found || j == key.Index
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: party %d is not a signer", ErrInvalidSigner, key.Index)
+	}
+
+	lambda, err := vss.LagrangeCoefficient(key.Index, signers, key.Curve)
+	if err != nil {
+		return nil, err
+	}
+	w := new(big.Int).Mul(lambda, key.Share)
+
+	return &SignParty{
+		key:     key,
+		signers: signers,
+		digest:  digest,
+		m:       hashToInt(digest, key.Curve.Params().N),
+		w:       w.Mod(w, key.Curve.Params().N),
+	}, nil
+}
+
+// Round1 选择k(i)、γ(i)，返回发送给其他每个签名方的消息
+func (sp *SignParty) Round1() ([]*SignRound1Message, error) {
+	if err := sp.nextRound(1); err != nil {
+		return nil, err
+	}
+
+	curve := sp.key.Curve
+	var err error
+	if sp.k, err = randomScalar(curve.Params().N); err != nil {
+		return nil, err
+	}
+	if sp.gamma, err = randomScalar(curve.Params().N); err != nil {
+		return nil, err
+	}
+	x, y := curve.ScalarBaseMult(sp.gamma.Bytes())
+	if sp.gammaPoint, err = ecc.NewPoint(curve, x, y); err != nil {
+		return nil, err
+	}
+
+	sp.blind = make([]byte, commitmentBlindLength)
+	if _, err := rand.Read(sp.blind); err != nil {
+		return nil, err
+	}
+	commitment := commitPoint(sp.gammaPoint, sp.blind)
+
+	encK, err := sp.key.PaillierPrivateKey.PublicKey.Encrypt(sp.k)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []*SignRound1Message
+	for _, j := range sp.others() {
+		msgs = append(msgs, &SignRound1Message{
+			From:       sp.key.Index,
+			To:         j,
+			Commitment: commitment,
+			EncK:       encK,
+		})
+	}
+
+	return msgs, nil
+}
+
+// Round2 对每个签名方的E(k(j))执行MtA，返回发送给其他每个签名方的回复
+func (sp *SignParty) Round2(msgs []*SignRound1Message) ([]*SignRound2Message, error) {
+	if err := sp.nextRound(2); err != nil {
+		return nil, err
+	}
+
+	routes := make([][2]int, len(msgs))
+	for i, msg := range msgs {
+		routes[i] = [2]int{msg.From, msg.To}
+	}
+	if err := checkRoutes(sp.key.Index, sp.signers, routes); err != nil {
+		return nil, err
+	}
+
+	n := sp.key.Curve.Params().N
+	maskBound := new(big.Int).Mul(n, n)
+	maskBound.Lsh(maskBound, statisticalSecurity)
+
+	sp.commitments = make(map[int][]byte, len(msgs))
+	var out []*SignRound2Message
+	for _, msg := range msgs {
+		sp.commitments[msg.From] = msg.Commitment
+		pk := sp.key.PaillierPublicKeys[msg.From]
+
+		betaPrime, err := rand.Int(rand.Reader, maskBound)
+		if err != nil {
+			return nil, err
+		}
+		nuPrime, err := rand.Int(rand.Reader, maskBound)
+		if err != nil {
+			return nil, err
+		}
+		encBeta, err := pk.Encrypt(betaPrime)
+		if err != nil {
+			return nil, err
+		}
+		encNu, err := pk.Encrypt(nuPrime)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, &SignRound2Message{
+			From:      sp.key.Index,
+			To:        msg.From,
+			EncKGamma: pk.CyphersAdd(pk.CypherPlainMultiply(msg.EncK, sp.gamma), encBeta),
+			EncKW:     pk.CyphersAdd(pk.CypherPlainMultiply(msg.EncK, sp.w), encNu),
+		})
+		sp.betas = append(sp.betas, new(big.Int).Neg(betaPrime))
+		sp.nus = append(sp.nus, new(big.Int).Neg(nuPrime))
+	}
+
+	return out, nil
+}
+
+// Round3 解密MtA的回复，计算δ(i)、σ(i)，返回广播的δ(i)
+func (sp *SignParty) Round3(msgs []*SignRound2Message) (*SignRound3Message, error) {
+	if err := sp.nextRound(3); err != nil {
+		return nil, err
+	}
+
+	routes := make([][2]int, len(msgs))
+	for i, msg := range msgs {
+		routes[i] = [2]int{msg.From, msg.To}
+	}
+	if err := checkRoutes(sp.key.Index, sp.signers, routes); err != nil {
+		return nil, err
+	}
+
+	n := sp.key.Curve.Params().N
+	sp.delta = new(big.Int).Mul(sp.k, sp.gamma)
+	sp.sigma = new(big.Int).Mul(sp.k, sp.w)
+	for _, msg := range msgs {
+		sp.delta.Add(sp.delta, sp.key.PaillierPrivateKey.Decrypt(msg.EncKGamma))
+		sp.sigma.Add(sp.sigma, sp.key.PaillierPrivateKey.Decrypt(msg.EncKW))
+	}
+	for i := range sp.betas {
+		sp.delta.Add(sp.delta, sp.betas[i])
+		sp.sigma.Add(sp.sigma, sp.nus[i])
+	}
+	sp.delta.Mod(sp.delta, n)
+	sp.sigma.Mod(sp.sigma, n)
+
+	return &SignRound3Message{
+		From:  sp.key.Index,
+		Delta: sp.delta,
+	}, nil
+}
+
+// Round4 计算δ = kγ，返回广播的Γ(i)及其盲化因子
+func (sp *SignParty) Round4(msgs []*SignRound3Message) (*SignRound4Message, error) {
+	if err := sp.nextRound(4); err != nil {
+		return nil, err
+	}
+
+	routes := make([][2]int, len(msgs))
+	for i, msg := range msgs {
+		routes[i] = [2]int{msg.From, 0}
+	}
+	if err := checkRoutes(sp.key.Index, sp.signers, routes); err != nil {
+		return nil, err
+	}
+
+	n := sp.key.Curve.Params().N
+	sp.deltaSum = new(big.Int).Set(sp.delta)
+	for _, msg := range msgs {
+		sp.deltaSum.Add(sp.deltaSum, msg.Delta)
+	}
+	sp.deltaSum.Mod(sp.deltaSum, n)
+	if sp.deltaSum.Sign() == 0 {
+		return nil, ErrDegenerateNonce
+	}
+
+	return &SignRound4Message{
+		From:  sp.key.Index,
+		Gamma: sp.gammaPoint,
+		Blind: sp.blind,
+	}, nil
+}
+
+// Round5 验证Γ(j)的承诺，计算R和r，返回广播的s(i)
+func (sp *SignParty) Round5(msgs []*SignRound4Message) (*SignRound5Message, error) {
+	if err := sp.nextRound(5); err != nil {
+		return nil, err
+	}
+
+	routes := make([][2]int, len(msgs))
+	for i, msg := range msgs {
+		routes[i] = [2]int{msg.From, 0}
+	}
+	if err := checkRoutes(sp.key.Index, sp.signers, routes); err != nil {
+		return nil, err
+	}
+
+	gamma := sp.gammaPoint
+	for _, msg := range msgs {
+		if msg.Gamma == nil || !bytes.Equal(commitPoint(msg.Gamma, msg.Blind), sp.commitments[msg.From]) {
+			return nil, fmt.Errorf("%w: party %d", ErrCommitmentMismatch, msg.From)
+		}
+		var err error
+		if gamma, err = gamma.Add(msg.Gamma); err != nil {
+			return nil, ErrDegenerateNonce
+		}
+	}
+
+	// R = δ^-1 * Γ
+	n := sp.key.Curve.Params().N
+	deltaInv := new(big.Int).ModInverse(sp.deltaSum, n)
+	rPoint := gamma.ScalarMult(deltaInv)
+	sp.r = new(big.Int).Mod(rPoint.X, n)
+	if sp.r.Sign() == 0 {
+		return nil, ErrDegenerateNonce
+	}
+
+	// s(i) = m*k(i) + r*σ(i)
+	s := new(big.Int).Mul(sp.m, sp.k)
+	s.Add(s, new(big.Int).Mul(sp.r, sp.sigma))
+
+	return &SignRound5Message{
+		From: sp.key.Index,
+		S:    s.Mod(s, n),
+	}, nil
+}
+
+// Finish 合并s(i)得到签名，验证通过后返回core/ecdsa格式的签名
+func (sp *SignParty) Finish(msgs []*SignRound5Message) (dtxecdsa.Signature, error) {
+	if err := sp.nextRound(6); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+
+	routes := make([][2]int, len(msgs))
+	for i, msg := range msgs {
+		routes[i] = [2]int{msg.From, 0}
+	}
+	if err := checkRoutes(sp.key.Index, sp.signers, routes); err != nil {
+		return dtxecdsa.Signature{}, err
+	}
+
+	n := sp.key.Curve.Params().N
+	s := new(big.Int).Mul(sp.m, sp.k)
+	s.Add(s, new(big.Int).Mul(sp.r, sp.sigma))
+	for _, msg := range msgs {
+		s.Add(s, msg.S)
+	}
+	s.Mod(s, n)
+	if s.Sign() == 0 {
+		return dtxecdsa.Signature{}, ErrDegenerateNonce
+	}
+
+	// low-S
+	halfOrder := new(big.Int).Rsh(n, 1)
+	if s.Cmp(halfOrder) > 0 {
+		s.Sub(n, s)
+	}
+
+	if !ecdsa.Verify(sp.key.PublicKey, sp.digest, sp.r, s) {
+		return dtxecdsa.Signature{}, ErrInvalidSignature
+	}
+
+	var sig dtxecdsa.Signature
+	byteLen := len(sig) / 2
+	sp.r.FillBytes(sig[:byteLen])
+	s.FillBytes(sig[byteLen:])
+
+	return sig, nil
+}
+
+func (sp *SignParty) nextRound(round int) error {
+	if sp.round != round-1 {
+		return ErrInvalidRoundSequence
+	}
+	sp.round = round
+	return nil
+}
+
+func (sp *SignParty) others() []int {
+	var others []int
+	for _, j := range sp.signers {
+		if j != sp.key.Index {
+			others = append(others, j)
+		}
+	}
+	return others
+}
+
+// commitPoint 哈希承诺 H(Γ.X || Γ.Y || blind)
+func commitPoint(p *ecc.Point, blind []byte) []byte {
+	byteLen := (p.Curve.Params().BitSize + 7) / 8
+	data := make([]byte, 2*byteLen, 2*byteLen+len(blind))
+	p.X.FillBytes(data[:byteLen])
+	p.Y.FillBytes(data[byteLen:])
+	return hash.HashUsingSha256(append(data, blind...))
+}
+
+// randomScalar 生成[1, n)内的随机数
+func randomScalar(n *big.Int) (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+// hashToInt 与crypto/ecdsa一致，将摘要截断为曲线阶的比特长度
+func hashToInt(digest []byte, n *big.Int) *big.Int {
+	orderBits := n.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+
+	ret := new(big.Int).SetBytes(digest)
+	excess := len(digest)*8 - orderBits
+	if excess > 0 {
+		ret.Rsh(ret, uint(excess))
+	}
+	return ret
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package threshold_ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	dtxecdsa "github.com/PaddlePaddle/PaddleDTX/crypto/core/ecdsa"
+	vss "github.com/PaddlePaddle/PaddleDTX/crypto/core/secret_share/complex_secret_share"
+)
+
+func TestThresholdECDSA(t *testing.T) {
+	curve := elliptic.P256()
+
+	keys, err := LocalKeyGen(3, 2, curve)
+	require.NoError(t, err)
+	publicKey := keys[0].MarshalPublicKey()
+	for _, key := range keys[1:] {
+		require.Equal(t, publicKey, key.MarshalPublicKey())
+	}
+
+	// 任意2个碎片恢复出的私钥与公钥对应，仅用于测试
+	x := new(big.Int).Mod(new(big.Int).Add(
+		new(big.Int).Mul(mustLagrange(t, 1, []int{1, 3}), keys[0].Share),
+		new(big.Int).Mul(mustLagrange(t, 3, []int{1, 3}), keys[2].Share)), curve.Params().N)
+	px, py := curve.ScalarBaseMult(x.Bytes())
+	require.Equal(t, keys[0].PublicKey.X, px)
+	require.Equal(t, keys[0].PublicKey.Y, py)
+
+	digest := sha256.Sum256([]byte("threshold ecdsa"))
+	for _, signers := range [][]*KeyShare{{keys[0], keys[1]}, {keys[1], keys[2]}, keys} {
+		sig, err := LocalSign(signers, digest[:])
+		require.NoError(t, err)
+		require.NoError(t, dtxecdsa.Verify(publicKey, digest[:], sig))
+
+		other := sha256.Sum256([]byte("other message"))
+		require.Error(t, dtxecdsa.Verify(publicKey, other[:], sig))
+	}
+
+	_, err = LocalSign(keys[:1], digest[:])
+	require.Equal(t, ErrNotEnoughSigners, err)
+
+	_, err = NewSignParty(keys[0], []int{2, 3}, digest[:])
+	require.ErrorIs(t, err, ErrInvalidSigner)
+
+	party, err := NewSignParty(keys[0], []int{1, 2}, digest[:])
+	require.NoError(t, err)
+	_, err = party.Round2(nil)
+	require.Equal(t, ErrInvalidRoundSequence, err)
+
+	_, err = NewKeyGenParty(1, 3, 1, curve)
+	require.Equal(t, ErrInvalidThreshold, err)
+}
+
+func mustLagrange(t *testing.T, index int, indices []int) *big.Int {
+	lambda, err := vss.LagrangeCoefficient(index, indices, elliptic.P256())
+	require.NoError(t, err)
+	return lambda
+}
@@ -0,0 +1,673 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_reencrypt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/aes"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/secret_share/complex_secret_share"
+)
+
+// 基于椭圆曲线的门限代理重加密，参考Umbral方案
+// 数据所有者Alice(私钥a，公钥A=aG)将数据加密给自己，之后为数据使用者Bob(公钥B)生成n个重加密密钥碎片(kfrag)，
+// 分发给n个代理(如存储节点)，任意t个代理的重加密结果(cfrag)即可让Bob解密，代理无法获知明文
+//
+// 封装：随机选取r、u，E = rG，V = uG，s = u + r*H(E,V)，对称密钥K = KDF((r+u)A)，胶囊capsule = (E, V, s)
+// 生成重加密密钥：随机选取x，X = xG，d = H(X, B, xB)，以a/d为常数项生成t-1次多项式f(x)，
+//		 kfrag_i = (i, rk_i = f(i), X, U_i = rk_i*U)，U为不知道离散对数的第二个生成元，Alice对kfrag签名
+// 重加密：代理验证capsule后计算 E_i = rk_i*E，V_i = rk_i*V，并给出rk_i在E、V、U上离散对数相等的证明
+// 解密：Bob计算 d = H(X, B, bX)，E' = Σλ_i*E_i，V' = Σλ_i*V_i，K = KDF(d*(E'+V')) = KDF(a(E+V))
+//
+// 序列化格式(点均为压缩格式，标量与序号为大端序，标量长度与坐标长度相同)：
+//	capsule: E | V | s
+//	kfrag:   序号(4) | rk | X | U_i | 签名
+//	cfrag:   E_i | V_i | 序号(4) | X | U_i | E2 | V2 | U2 | z | 签名
+// 解析时检查长度、每个点都在曲线上以及标量小于曲线的阶，签名和证明仍需通过VerifyKFrag、VerifyCFrag验证
+//
+// 参考：Umbral: A Threshold Proxy Re-Encryption Scheme, David Nuñez
+
+var (
+	ErrInvalidCapsule   = errors.New("invalid capsule")
+	ErrInvalidKFrag     = errors.New("invalid re-encryption key fragment")
+	ErrInvalidCFrag     = errors.New("invalid capsule fragment")
+	ErrNotEnoughCFrags  = errors.New("not enough capsule fragments")
+	ErrCurveMismatching = errors.New("keys are not on the same curve")
+)
+
+const (
+	dataKeySize = 32
+	nonceSize   = 12
+	kdfInfo     = "PaddleDTX umbral"
+)
+
+// Capsule encapsulated data key 封装了对称密钥的胶囊
+type Capsule struct {
+	E *ecc.Point
+	V *ecc.Point
+	S *big.Int
+}
+
+// KFrag re-encryption key fragment held by a proxy 代理持有的重加密密钥碎片
+type KFrag struct {
+	ID         int        // share index 碎片序号
+	RK         *big.Int   // re-encryption key share 重加密密钥碎片
+	X          *ecc.Point // ephemeral public key X = xG 临时公钥
+	Commitment *ecc.Point // U_i = rk_i*U
+	Signature  []byte     // delegator signature 数据所有者的签名
+}
+
+// CFrag capsule fragment re-encrypted by a proxy 代理重加密后的胶囊碎片
+type CFrag struct {
+	E1         *ecc.Point // rk_i*E
+	V1         *ecc.Point // rk_i*V
+	ID         int
+	X          *ecc.Point
+	Commitment *ecc.Point
+	Signature  []byte
+	Proof      *CorrectnessProof
+}
+
+// CorrectnessProof proof that E1, V1 and the commitment share the same discrete logarithm rk_i
+// 证明E1、V1和承诺具有相同的离散对数rk_i
+type CorrectnessProof struct {
+	E2 *ecc.Point // t*E
+	V2 *ecc.Point // t*V
+	U2 *ecc.Point // t*U
+	Z  *big.Int   // t + h*rk_i
+}
+
+// Encrypt encrypt msg to the delegator, the capsule can be re-encrypted to delegatees later
+// 将msg加密给数据所有者，之后可将capsule重加密给数据使用者
+func Encrypt(pubkey *ecdsa.PublicKey, msg []byte) (*Capsule, []byte, error) {
+	capsule, key, err := Encapsulate(pubkey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := aes.EncryptUsingAESGCM(aes.AESKey{
+		Key:   key[:dataKeySize],
+		Nonce: key[dataKeySize:],
+		AD:    capsule.Bytes(),
+	}, msg, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return capsule, ciphertext, nil
+}
+
+// Decrypt decrypt by the delegator with its own private key 数据所有者使用自己的私钥解密
+func Decrypt(privkey *ecdsa.PrivateKey, capsule *Capsule, ciphertext []byte) ([]byte, error) {
+	key, err := Decapsulate(privkey, capsule)
+	if err != nil {
+		return nil, err
+	}
+	return decryptWithKey(key, capsule, ciphertext)
+}
+
+// DecryptFragments decrypt by the delegatee with at least threshold capsule fragments
+// 数据使用者使用不少于门限值的胶囊碎片解密
+// - delegator public key of the delegator 数据所有者的公钥
+func DecryptFragments(privkey *ecdsa.PrivateKey, delegator *ecdsa.PublicKey, capsule *Capsule, cfrags []*CFrag, ciphertext []byte) ([]byte, error) {
+	key, err := DecapsulateFragments(privkey, delegator, capsule, cfrags)
+	if err != nil {
+		return nil, err
+	}
+	return decryptWithKey(key, capsule, ciphertext)
+}
+
+func decryptWithKey(key []byte, capsule *Capsule, ciphertext []byte) ([]byte, error) {
+	return aes.DecryptUsingAESGCM(aes.AESKey{
+		Key:   key[:dataKeySize],
+		Nonce: key[dataKeySize:],
+		AD:    capsule.Bytes(),
+	}, ciphertext, nil)
+}
+
+// Encapsulate generate a random symmetric key and its capsule 生成随机对称密钥及其胶囊
+func Encapsulate(pubkey *ecdsa.PublicKey) (*Capsule, []byte, error) {
+	curve := pubkey.Curve
+	pk, err := ecc.NewPoint(curve, pubkey.X, pubkey.Y)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := randomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := randomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	E := baseMult(curve, r)
+	V := baseMult(curve, u)
+	h := hashToScalar(curve, E, V)
+	s := new(big.Int).Mul(r, h)
+	s.Add(s, u)
+	s.Mod(s, curve.Params().N)
+
+	shared := pk.ScalarMult(new(big.Int).Add(r, u))
+	capsule := &Capsule{E: E, V: V, S: s}
+	return capsule, kdf(shared), nil
+}
+
+// Decapsulate recover the symmetric key with the private key of the delegator 数据所有者使用私钥恢复对称密钥
+func Decapsulate(privkey *ecdsa.PrivateKey, capsule *Capsule) ([]byte, error) {
+	if err := capsule.Verify(privkey.Curve); err != nil {
+		return nil, err
+	}
+	ev, err := capsule.E.Add(capsule.V)
+	if err != nil {
+		return nil, err
+	}
+	return kdf(ev.ScalarMult(privkey.D)), nil
+}
+
+// Verify check that sG = V + H(E,V)*E 验证胶囊的有效性
+func (c *Capsule) Verify(curve elliptic.Curve) error {
+	if c == nil || c.E == nil || c.V == nil || c.S == nil {
+		return ErrInvalidCapsule
+	}
+	if !onCurve(curve, c.E) || !onCurve(curve, c.V) {
+		return ErrInvalidCapsule
+	}
+	right, err := c.E.ScalarMult(hashToScalar(curve, c.E, c.V)).Add(c.V)
+	if err != nil {
+		return ErrInvalidCapsule
+	}
+	if !baseMult(curve, c.S).Equals(right) {
+		return ErrInvalidCapsule
+	}
+	return nil
+}
+
+// Bytes serialize the capsule as E || V || s 将胶囊序列化为 E || V || s
+func (c *Capsule) Bytes() []byte {
+	curve := c.E.Curve
+	out := append(encodePoint(c.E), encodePoint(c.V)...)
+	s := make([]byte, ecc.CoordinateLength(curve))
+	return append(out, c.S.FillBytes(s)...)
+}
+
+// ParseCapsule parse and verify a capsule serialized by Bytes 解析并验证由Bytes序列化的胶囊
+func ParseCapsule(curve elliptic.Curve, data []byte) (*Capsule, error) {
+	size := ecc.CoordinateLength(curve)
+	if len(data) != 2*(1+size)+size {
+		return nil, ErrInvalidCapsule
+	}
+	E, errE := decodePoint(curve, data[:1+size])
+	V, errV := decodePoint(curve, data[1+size:2*(1+size)])
+	s, errS := decodeScalar(curve, data[2*(1+size):])
+	if errE != nil || errV != nil || errS != nil {
+		return nil, ErrInvalidCapsule
+	}
+	capsule := &Capsule{E: E, V: V, S: s}
+	if err := capsule.Verify(curve); err != nil {
+		return nil, err
+	}
+	return capsule, nil
+}
+
+// GenerateKFrags generate re-encryption key fragments from the delegator to the delegatee
+// 生成从数据所有者到数据使用者的重加密密钥碎片
+// - threshold the minimum number of fragments to decrypt 解密所需的最少碎片数
+// - shares the number of fragments, one for each proxy 碎片数量，每个代理一个
+func GenerateKFrags(privkey *ecdsa.PrivateKey, delegatee *ecdsa.PublicKey, threshold, shares int) ([]*KFrag, error) {
+	curve := privkey.Curve
+	if delegatee.Curve.Params().Name != curve.Params().Name {
+		return nil, ErrCurveMismatching
+	}
+	if threshold < 1 || threshold > shares {
+		return nil, fmt.Errorf("invalid threshold %d for %d shares", threshold, shares)
+	}
+	B, err := ecc.NewPoint(curve, delegatee.X, delegatee.Y)
+	if err != nil {
+		return nil, err
+	}
+	U, err := generatorU(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// d = H(X, B, xB)，只有Bob能重新计算
+	x, err := randomScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	X := baseMult(curve, x)
+	d := hashToScalar(curve, X, B, B.ScalarMult(x))
+	n := curve.Params().N
+	f0 := new(big.Int).ModInverse(d, n)
+	f0.Mul(f0, privkey.D)
+	f0.Mod(f0, n)
+
+	rks := map[int]*big.Int{1: f0}
+	if shares > 1 {
+		poly, err := complex_secret_share.ComplexSecretToPolynomial(shares, threshold, f0.Bytes(), curve)
+		if err != nil {
+			return nil, err
+		}
+		for i := 1; i <= shares; i++ {
+			rk := complex_secret_share.GetSpecifiedSecretShareByPolynomial(poly, big.NewInt(int64(i)), curve)
+			rks[i] = rk.Mod(rk, n)
+		}
+	}
+
+	A := &ecc.Point{Curve: curve, X: privkey.X, Y: privkey.Y}
+	kfrags := make([]*KFrag, 0, shares)
+	for i := 1; i <= shares; i++ {
+		kfrag := &KFrag{
+			ID:         i,
+			RK:         rks[i],
+			X:          X,
+			Commitment: U.ScalarMult(rks[i]),
+		}
+		kfrag.Signature, err = ecdsa.SignASN1(rand.Reader, privkey, kfragDigest(kfrag.ID, kfrag.X, kfrag.Commitment, A, B))
+		if err != nil {
+			return nil, err
+		}
+		kfrags = append(kfrags, kfrag)
+	}
+	return kfrags, nil
+}
+
+// VerifyKFrag verify a key fragment by the proxy 代理验证重加密密钥碎片
+func VerifyKFrag(kfrag *KFrag, delegator, delegatee *ecdsa.PublicKey) error {
+	curve := delegator.Curve
+	if kfrag == nil || kfrag.RK == nil || kfrag.RK.Sign() <= 0 || kfrag.RK.Cmp(curve.Params().N) >= 0 ||
+		!onCurve(curve, kfrag.X) || !onCurve(curve, kfrag.Commitment) {
+		return ErrInvalidKFrag
+	}
+	U, err := generatorU(curve)
+	if err != nil {
+		return err
+	}
+	if !U.ScalarMult(kfrag.RK).Equals(kfrag.Commitment) {
+		return ErrInvalidKFrag
+	}
+	return verifySignature(kfrag.ID, kfrag.X, kfrag.Commitment, kfrag.Signature, delegator, delegatee)
+}
+
+// Marshal serialize the key fragment 序列化重加密密钥碎片
+func (k *KFrag) Marshal() ([]byte, error) {
+	if k.ID <= 0 || uint64(k.ID) > math.MaxUint32 || k.RK == nil || k.RK.Sign() < 0 || k.X == nil || k.Commitment == nil {
+		return nil, ErrInvalidKFrag
+	}
+	size := ecc.CoordinateLength(k.X.Curve)
+	if k.RK.BitLen() > 8*size {
+		return nil, ErrInvalidKFrag
+	}
+	out := make([]byte, 4)
+	binary.BigEndian.PutUint32(out, uint32(k.ID))
+	out = append(out, k.RK.FillBytes(make([]byte, size))...)
+	out = append(out, encodePoint(k.X)...)
+	out = append(out, encodePoint(k.Commitment)...)
+	return append(out, k.Signature...), nil
+}
+
+// ParseKFrag parse a key fragment serialized by Marshal, the signature is checked by VerifyKFrag
+// 解析由Marshal序列化的重加密密钥碎片，签名由VerifyKFrag验证
+func ParseKFrag(curve elliptic.Curve, data []byte) (*KFrag, error) {
+	size := ecc.CoordinateLength(curve)
+	pointSize := 1 + size
+	if len(data) < 4+size+2*pointSize {
+		return nil, ErrInvalidKFrag
+	}
+	id := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	rk, errRK := decodeScalar(curve, data[:size])
+	X, errX := decodePoint(curve, data[size:size+pointSize])
+	commitment, errC := decodePoint(curve, data[size+pointSize:size+2*pointSize])
+	if id == 0 || errRK != nil || errX != nil || errC != nil {
+		return nil, ErrInvalidKFrag
+	}
+	return &KFrag{
+		ID:         int(id),
+		RK:         rk,
+		X:          X,
+		Commitment: commitment,
+		Signature:  append([]byte{}, data[size+2*pointSize:]...),
+	}, nil
+}
+
+// ReEncrypt re-encrypt a capsule with a key fragment by the proxy, the plaintext is not revealed to the proxy
+// 代理使用重加密密钥碎片对胶囊重加密，代理无法获知明文
+func ReEncrypt(capsule *Capsule, kfrag *KFrag) (*CFrag, error) {
+	if capsule == nil || capsule.E == nil || capsule.E.Curve == nil {
+		return nil, ErrInvalidCapsule
+	}
+	curve := capsule.E.Curve
+	if err := capsule.Verify(curve); err != nil {
+		return nil, err
+	}
+	if kfrag == nil || kfrag.RK == nil || !onCurve(curve, kfrag.X) || !onCurve(curve, kfrag.Commitment) {
+		return nil, ErrInvalidKFrag
+	}
+	U, err := generatorU(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := randomScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	cfrag := &CFrag{
+		E1:         capsule.E.ScalarMult(kfrag.RK),
+		V1:         capsule.V.ScalarMult(kfrag.RK),
+		ID:         kfrag.ID,
+		X:          kfrag.X,
+		Commitment: kfrag.Commitment,
+		Signature:  kfrag.Signature,
+		Proof: &CorrectnessProof{
+			E2: capsule.E.ScalarMult(t),
+			V2: capsule.V.ScalarMult(t),
+			U2: U.ScalarMult(t),
+		},
+	}
+	h := proofChallenge(capsule, cfrag, U)
+	z := new(big.Int).Mul(h, kfrag.RK)
+	z.Add(z, t)
+	cfrag.Proof.Z = z.Mod(z, curve.Params().N)
+	return cfrag, nil
+}
+
+// VerifyCFrag verify that a capsule fragment is correctly re-encrypted with a key fragment signed by the delegator
+// 验证胶囊碎片是使用数据所有者签名的密钥碎片正确重加密的
+func VerifyCFrag(capsule *Capsule, cfrag *CFrag, delegator, delegatee *ecdsa.PublicKey) error {
+	curve := delegator.Curve
+	if err := capsule.Verify(curve); err != nil {
+		return err
+	}
+	if cfrag == nil || cfrag.Proof == nil || cfrag.Proof.Z == nil {
+		return ErrInvalidCFrag
+	}
+	for _, p := range []*ecc.Point{cfrag.E1, cfrag.V1, cfrag.X, cfrag.Commitment, cfrag.Proof.E2, cfrag.Proof.V2, cfrag.Proof.U2} {
+		if !onCurve(curve, p) {
+			return ErrInvalidCFrag
+		}
+	}
+	if err := verifySignature(cfrag.ID, cfrag.X, cfrag.Commitment, cfrag.Signature, delegator, delegatee); err != nil {
+		return err
+	}
+	U, err := generatorU(curve)
+	if err != nil {
+		return err
+	}
+
+	// z*P = P2 + h*P1，P分别为E、V、U
+	h := proofChallenge(capsule, cfrag, U)
+	checks := [][3]*ecc.Point{
+		{capsule.E, cfrag.Proof.E2, cfrag.E1},
+		{capsule.V, cfrag.Proof.V2, cfrag.V1},
+		{U, cfrag.Proof.U2, cfrag.Commitment},
+	}
+	for _, c := range checks {
+		right, err := c[1].Add(c[2].ScalarMult(h))
+		if err != nil || !c[0].ScalarMult(cfrag.Proof.Z).Equals(right) {
+			return ErrInvalidCFrag
+		}
+	}
+	return nil
+}
+
+// Marshal serialize the capsule fragment 序列化胶囊碎片
+func (c *CFrag) Marshal() ([]byte, error) {
+	if c.ID <= 0 || uint64(c.ID) > math.MaxUint32 || c.Proof == nil || c.Proof.Z == nil || c.Proof.Z.Sign() < 0 {
+		return nil, ErrInvalidCFrag
+	}
+	points := []*ecc.Point{c.E1, c.V1, c.X, c.Commitment, c.Proof.E2, c.Proof.V2, c.Proof.U2}
+	for _, p := range points {
+		if p == nil {
+			return nil, ErrInvalidCFrag
+		}
+	}
+	size := ecc.CoordinateLength(c.E1.Curve)
+	if c.Proof.Z.BitLen() > 8*size {
+		return nil, ErrInvalidCFrag
+	}
+
+	out := append(encodePoint(c.E1), encodePoint(c.V1)...)
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], uint32(c.ID))
+	out = append(out, id[:]...)
+	for _, p := range points[2:] {
+		out = append(out, encodePoint(p)...)
+	}
+	out = append(out, c.Proof.Z.FillBytes(make([]byte, size))...)
+	return append(out, c.Signature...), nil
+}
+
+// ParseCFrag parse a capsule fragment serialized by Marshal, the proof and signature are checked by VerifyCFrag
+// 解析由Marshal序列化的胶囊碎片，证明和签名由VerifyCFrag验证
+func ParseCFrag(curve elliptic.Curve, data []byte) (*CFrag, error) {
+	size := ecc.CoordinateLength(curve)
+	pointSize := 1 + size
+	if len(data) < 7*pointSize+4+size {
+		return nil, ErrInvalidCFrag
+	}
+
+	points := make([]*ecc.Point, 7)
+	offset := 0
+	for i := range points {
+		if i == 2 {
+			offset += 4
+		}
+		p, err := decodePoint(curve, data[offset:offset+pointSize])
+		if err != nil {
+			return nil, ErrInvalidCFrag
+		}
+		points[i] = p
+		offset += pointSize
+	}
+	id := binary.BigEndian.Uint32(data[2*pointSize:])
+	z, err := decodeScalar(curve, data[offset:offset+size])
+	if id == 0 || err != nil {
+		return nil, ErrInvalidCFrag
+	}
+	return &CFrag{
+		E1:         points[0],
+		V1:         points[1],
+		ID:         int(id),
+		X:          points[2],
+		Commitment: points[3],
+		Signature:  append([]byte{}, data[offset+size:]...),
+		Proof: &CorrectnessProof{
+			E2: points[4],
+			V2: points[5],
+			U2: points[6],
+			Z:  z,
+		},
+	}, nil
+}
+
+// DecapsulateFragments recover the symmetric key by the delegatee, every capsule fragment is verified first
+// 数据使用者恢复对称密钥，先验证每个胶囊碎片
+func DecapsulateFragments(privkey *ecdsa.PrivateKey, delegator *ecdsa.PublicKey, capsule *Capsule, cfrags []*CFrag) ([]byte, error) {
+	curve := privkey.Curve
+	if delegator.Curve.Params().Name != curve.Params().Name {
+		return nil, ErrCurveMismatching
+	}
+	if len(cfrags) == 0 {
+		return nil, ErrNotEnoughCFrags
+	}
+
+	ids := make([]int, 0, len(cfrags))
+	for _, cfrag := range cfrags {
+		if err := VerifyCFrag(capsule, cfrag, delegator, &privkey.PublicKey); err != nil {
+			return nil, err
+		}
+		if !cfrag.X.Equals(cfrags[0].X) {
+			return nil, fmt.Errorf("%w: capsule fragments from different key fragment sets", ErrInvalidCFrag)
+		}
+		ids = append(ids, cfrag.ID)
+	}
+
+	var E, V *ecc.Point
+	for _, cfrag := range cfrags {
+		lambda, err := complex_secret_share.LagrangeCoefficient(cfrag.ID, ids, curve)
+		if err != nil {
+			return nil, err
+		}
+		E, err = addPoint(E, cfrag.E1.ScalarMult(lambda))
+		if err != nil {
+			return nil, err
+		}
+		V, err = addPoint(V, cfrag.V1.ScalarMult(lambda))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	B := &ecc.Point{Curve: curve, X: privkey.X, Y: privkey.Y}
+	X := cfrags[0].X
+	d := hashToScalar(curve, X, B, X.ScalarMult(privkey.D))
+	ev, err := E.Add(V)
+	if err != nil {
+		return nil, err
+	}
+	return kdf(ev.ScalarMult(d)), nil
+}
+
+func verifySignature(id int, X, commitment *ecc.Point, signature []byte, delegator, delegatee *ecdsa.PublicKey) error {
+	if delegatee.Curve.Params().Name != delegator.Curve.Params().Name {
+		return ErrCurveMismatching
+	}
+	A := &ecc.Point{Curve: delegator.Curve, X: delegator.X, Y: delegator.Y}
+	B := &ecc.Point{Curve: delegatee.Curve, X: delegatee.X, Y: delegatee.Y}
+	if !ecdsa.VerifyASN1(delegator, kfragDigest(id, X, commitment, A, B), signature) {
+		return fmt.Errorf("%w: invalid signature", ErrInvalidKFrag)
+	}
+	return nil
+}
+
+// kfragDigest 数据所有者签名的内容，绑定碎片序号、临时公钥、承诺以及双方公钥
+func kfragDigest(id int, X, commitment, A, B *ecc.Point) []byte {
+	h := sha256.New()
+	var idBytes [8]byte
+	binary.BigEndian.PutUint64(idBytes[:], uint64(id))
+	h.Write(idBytes[:])
+	for _, p := range []*ecc.Point{X, commitment, A, B} {
+		h.Write(encodePoint(p))
+	}
+	return h.Sum(nil)
+}
+
+func proofChallenge(capsule *Capsule, cfrag *CFrag, U *ecc.Point) *big.Int {
+	return hashToScalar(capsule.E.Curve, capsule.E, cfrag.E1, cfrag.Proof.E2,
+		capsule.V, cfrag.V1, cfrag.Proof.V2, U, cfrag.Commitment, cfrag.Proof.U2)
+}
+
+// generatorU 由曲线名称哈希得到的第二个生成元，任何人都不知道其相对于G的离散对数
+func generatorU(curve elliptic.Curve) (*ecc.Point, error) {
+	params := curve.Params()
+	for counter := 0; counter < 256; counter++ {
+		seed := sha256.Sum256([]byte(fmt.Sprintf("paddledtx/umbral/%s/%d", params.Name, counter)))
+		x := new(big.Int).SetBytes(seed[:])
+		x.Mod(x, params.P)
+		y, err := ecc.DecompressY(curve, x, false)
+		if err != nil {
+			continue
+		}
+		if point, err := ecc.NewPoint(curve, x, y); err == nil {
+			return point, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to derive generator U for curve %s", params.Name)
+}
+
+func kdf(p *ecc.Point) []byte {
+	key := make([]byte, dataKeySize+nonceSize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, encodePoint(p), nil, []byte(kdfInfo)), key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func hashToScalar(curve elliptic.Curve, points ...*ecc.Point) *big.Int {
+	h := sha256.New()
+	for _, p := range points {
+		h.Write(encodePoint(p))
+	}
+	s := new(big.Int).SetBytes(h.Sum(nil))
+	return s.Mod(s, curve.Params().N)
+}
+
+func encodePoint(p *ecc.Point) []byte {
+	return elliptic.MarshalCompressed(p.Curve, p.X, p.Y)
+}
+
+// decodePoint 解析压缩格式的点，要求点在曲线上
+func decodePoint(curve elliptic.Curve, data []byte) (*ecc.Point, error) {
+	size := ecc.CoordinateLength(curve)
+	if len(data) != 1+size || (data[0] != 2 && data[0] != 3) {
+		return nil, errors.New("invalid point encoding")
+	}
+	x := new(big.Int).SetBytes(data[1:])
+	y, err := ecc.DecompressY(curve, x, data[0] == 3)
+	if err != nil {
+		return nil, err
+	}
+	return ecc.NewPoint(curve, x, y)
+}
+
+// decodeScalar 解析大端序的标量，要求小于曲线的阶
+func decodeScalar(curve elliptic.Curve, data []byte) (*big.Int, error) {
+	k := new(big.Int).SetBytes(data)
+	if k.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("scalar out of range")
+	}
+	return k, nil
+}
+
+func baseMult(curve elliptic.Curve, k *big.Int) *ecc.Point {
+	x, y := curve.ScalarBaseMult(new(big.Int).Mod(k, curve.Params().N).Bytes())
+	return &ecc.Point{Curve: curve, X: x, Y: y}
+}
+
+func addPoint(sum, p *ecc.Point) (*ecc.Point, error) {
+	if sum == nil {
+		return p, nil
+	}
+	return sum.Add(p)
+}
+
+func onCurve(curve elliptic.Curve, p *ecc.Point) bool {
+	return p != nil && p.Curve != nil && p.X != nil && p.Y != nil && p.Curve.Params().Name == curve.Params().Name && curve.IsOnCurve(p.X, p.Y)
+}
+
+func randomScalar(curve elliptic.Curve) (*big.Int, error) {
+	n := curve.Params().N
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
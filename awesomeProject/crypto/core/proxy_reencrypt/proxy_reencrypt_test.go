@@ -0,0 +1,162 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_reencrypt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+)
+
+func TestProxyReEncrypt(t *testing.T) {
+	secp256k1, err := ecc.CurveByName(config.CurveSecp256k1)
+	require.NoError(t, err)
+
+	for _, curve := range []elliptic.Curve{elliptic.P256(), secp256k1} {
+		alice, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		bob, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		carol, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		msg := []byte("dataset shared with an approved data user")
+		capsule, ciphertext, err := Encrypt(&alice.PublicKey, msg)
+		require.NoError(t, err)
+
+		// the delegator decrypts directly 数据所有者直接解密
+		plain, err := Decrypt(alice, capsule, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, msg, plain)
+		_, err = Decrypt(bob, capsule, ciphertext)
+		require.Error(t, err)
+
+		// 3 of 5 proxies 5个代理中的任意3个
+		kfrags, err := GenerateKFrags(alice, &bob.PublicKey, 3, 5)
+		require.NoError(t, err)
+		require.Equal(t, 5, len(kfrags))
+		var cfrags []*CFrag
+		for _, kfrag := range kfrags {
+			require.NoError(t, VerifyKFrag(kfrag, &alice.PublicKey, &bob.PublicKey))
+			require.Error(t, VerifyKFrag(kfrag, &alice.PublicKey, &carol.PublicKey))
+			cfrag, err := ReEncrypt(capsule, kfrag)
+			require.NoError(t, err)
+			require.NoError(t, VerifyCFrag(capsule, cfrag, &alice.PublicKey, &bob.PublicKey))
+			cfrags = append(cfrags, cfrag)
+		}
+
+		for _, subset := range [][]*CFrag{cfrags[:3], cfrags[2:], {cfrags[4], cfrags[0], cfrags[2]}, cfrags} {
+			plain, err := DecryptFragments(bob, &alice.PublicKey, capsule, subset, ciphertext)
+			require.NoError(t, err)
+			require.Equal(t, msg, plain)
+		}
+
+		// fewer than threshold fragments 少于门限值的碎片
+		_, err = DecryptFragments(bob, &alice.PublicKey, capsule, cfrags[:2], ciphertext)
+		require.Error(t, err)
+
+		// another user cannot decrypt 其他用户无法解密
+		_, err = DecryptFragments(carol, &alice.PublicKey, capsule, cfrags[:3], ciphertext)
+		require.Error(t, err)
+
+		// a cheating proxy is detected 作弊的代理可被发现
+		cheat := *cfrags[1]
+		cheat.E1 = cheat.E1.ScalarMult(big.NewInt(2))
+		require.Equal(t, ErrInvalidCFrag, VerifyCFrag(capsule, &cheat, &alice.PublicKey, &bob.PublicKey))
+		_, err = DecryptFragments(bob, &alice.PublicKey, capsule, []*CFrag{cfrags[0], &cheat, cfrags[2]}, ciphertext)
+		require.Error(t, err)
+
+		// a fragment of another capsule is rejected 其他胶囊的碎片被拒绝
+		capsule2, _, err := Encrypt(&alice.PublicKey, msg)
+		require.NoError(t, err)
+		cfrag2, err := ReEncrypt(capsule2, kfrags[3])
+		require.NoError(t, err)
+		require.Error(t, VerifyCFrag(capsule, cfrag2, &alice.PublicKey, &bob.PublicKey))
+
+		// a modified capsule is rejected 被修改的胶囊被拒绝
+		modified := *capsule
+		modified.S = new(big.Int).Add(capsule.S, big.NewInt(1))
+		_, err = ReEncrypt(&modified, kfrags[0])
+		require.Equal(t, ErrInvalidCapsule, err)
+
+		// serialization and parsing 序列化与解析
+		parsedCapsule, err := ParseCapsule(curve, capsule.Bytes())
+		require.NoError(t, err)
+		require.True(t, parsedCapsule.E.Equals(capsule.E) && parsedCapsule.V.Equals(capsule.V))
+		require.Equal(t, capsule.S, parsedCapsule.S)
+		encodedKFrag, err := kfrags[0].Marshal()
+		require.NoError(t, err)
+		parsedKFrag, err := ParseKFrag(curve, encodedKFrag)
+		require.NoError(t, err)
+		require.NoError(t, VerifyKFrag(parsedKFrag, &alice.PublicKey, &bob.PublicKey))
+		encodedCFrag, err := cfrags[0].Marshal()
+		require.NoError(t, err)
+		parsedCFrag, err := ParseCFrag(curve, encodedCFrag)
+		require.NoError(t, err)
+		require.NoError(t, VerifyCFrag(parsedCapsule, parsedCFrag, &alice.PublicKey, &bob.PublicKey))
+		plain, err = DecryptFragments(bob, &alice.PublicKey, parsedCapsule, []*CFrag{parsedCFrag, cfrags[1], cfrags[2]}, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, msg, plain)
+
+		// malformed encodings are rejected 格式错误的编码被拒绝
+		size := ecc.CoordinateLength(curve)
+		badCapsule := capsule.Bytes()
+		badCapsule[0] = 4
+		_, err = ParseCapsule(curve, badCapsule)
+		require.Equal(t, ErrInvalidCapsule, err)
+		_, err = ParseCapsule(curve, capsule.Bytes()[1:])
+		require.Equal(t, ErrInvalidCapsule, err)
+		badKFrag := append([]byte{}, encodedKFrag...)
+		for i := 4; i < 4+size; i++ {
+			badKFrag[i] = 0xff
+		}
+		_, err = ParseKFrag(curve, badKFrag)
+		require.Equal(t, ErrInvalidKFrag, err)
+		_, err = ParseCFrag(curve, encodedCFrag[:7*(1+size)])
+		require.Equal(t, ErrInvalidCFrag, err)
+
+		// points not on the curve are rejected 不在曲线上的点被拒绝
+		offCurve := *kfrags[0]
+		offCurve.Commitment = &ecc.Point{Curve: curve, X: big.NewInt(1), Y: big.NewInt(1)}
+		require.Equal(t, ErrInvalidKFrag, VerifyKFrag(&offCurve, &alice.PublicKey, &bob.PublicKey))
+		offCurve = *kfrags[0]
+		offCurve.X = &ecc.Point{Curve: curve, X: big.NewInt(1), Y: big.NewInt(1)}
+		require.Equal(t, ErrInvalidKFrag, VerifyKFrag(&offCurve, &alice.PublicKey, &bob.PublicKey))
+		_, err = ReEncrypt(capsule, &offCurve)
+		require.Equal(t, ErrInvalidKFrag, err)
+		for _, invalid := range []*Capsule{nil, {}, {E: &ecc.Point{}}} {
+			_, err = ReEncrypt(invalid, kfrags[0])
+			require.Equal(t, ErrInvalidCapsule, err)
+		}
+
+		// a single proxy 单个代理
+		kfrags, err = GenerateKFrags(alice, &bob.PublicKey, 1, 1)
+		require.NoError(t, err)
+		cfrag, err := ReEncrypt(capsule, kfrags[0])
+		require.NoError(t, err)
+		plain, err = DecryptFragments(bob, &alice.PublicKey, capsule, []*CFrag{cfrag}, ciphertext)
+		require.NoError(t, err)
+		require.Equal(t, msg, plain)
+
+		_, err = GenerateKFrags(alice, &bob.PublicKey, 4, 3)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schnorr
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+)
+
+/*
+	MuSig2 two-round multi-signature, the result is an ordinary Schnorr signature under the aggregated key.
+	MuSig2两轮多签名，聚合结果是聚合公钥下的普通Schnorr签名，可直接用Verify验证
+	  密钥聚合: L = H(P_1 || ... || P_n), a_i = H_agg(L || P_i), X = Σa_i*P_i
+	  第一轮:   每个签名者生成两个随机数 r_i1, r_i2，广播 R_i1 = r_i1*G, R_i2 = r_i2*G
+	  第二轮:   R_1 = ΣR_i1, R_2 = ΣR_i2, b = H_non(X || R_1 || R_2 || m), R = R_1 + b*R_2,
+	           e = H(R || X || m), s_i = r_i1 + b*r_i2 + e*a_i*x_i
+	  聚合:     s = Σs_i，签名为 (R, s)
+	系数a_i防止恶意公钥攻击，不需要持有证明；两个随机数使第一轮可以在消息确定前预先完成
+	SecretNonce只能使用一次，重复使用会泄露私钥，PartialSign后会被清零
+*/
+
+const (
+	tagKeyAggList  = "DTX/MuSig2/keyagg list"
+	tagKeyAggCoeff = "DTX/MuSig2/keyagg coef"
+	tagNonceCoeff  = "DTX/MuSig2/noncecoef"
+	tagNonceGen    = "DTX/MuSig2/nonce"
+)
+
+var (
+	ErrNotEnoughSigners  = errors.New("musig2 needs at least one public key")
+	ErrCurveMismatch     = errors.New("public keys are on different curves")
+	ErrSignerNotFound    = errors.New("signer's public key is not in the key aggregation context")
+	ErrNonceReused       = errors.New("secret nonce has already been used")
+	ErrInvalidPartialSig = errors.New("invalid musig2 partial signature")
+	ErrInfinityPoint     = errors.New("point at infinity")
+)
+
+// KeyAggContext 密钥聚合结果
+type KeyAggContext struct {
+	Curve         elliptic.Curve
+	PublicKeys    []*ecdsa.PublicKey
+	AggregatedKey *ecdsa.PublicKey
+
+	coefficients []*big.Int
+}
+
+// SecretNonce 签名者第一轮生成的私有随机数，只能使用一次
+type SecretNonce struct {
+	k1, k2 *big.Int
+}
+
+// PublicNonce 签名者第一轮广播的公开随机数 R_i1、R_i2
+type PublicNonce struct {
+	X1, Y1 *big.Int
+	X2, Y2 *big.Int
+}
+
+// Session 一次签名会话，所有签名者使用相同的密钥聚合结果、聚合随机数和消息创建会话
+type Session struct {
+	ctx *KeyAggContext
+	b   *big.Int
+	e   *big.Int
+	R   []byte
+}
+
+// AggregatePublicKeys 聚合公钥，所有签名者须使用相同顺序的公钥列表
+func AggregatePublicKeys(pubkeys []*ecdsa.PublicKey) (*KeyAggContext, error) {
+	if len(pubkeys) == 0 {
+		return nil, ErrNotEnoughSigners
+	}
+	curve := pubkeys[0].Curve
+	if _, err := ecc.IDOfCurve(curve); err != nil {
+		return nil, ErrUnsupportedCurve
+	}
+
+	encoded := make([][]byte, len(pubkeys))
+	for i, pk := range pubkeys {
+		if pk.Curve.Params().Name != curve.Params().Name {
+			return nil, ErrCurveMismatch
+		}
+		if !curve.IsOnCurve(pk.X, pk.Y) {
+			return nil, ErrInvalidPoint
+		}
+		encoded[i] = marshalPoint(curve, pk.X, pk.Y)
+	}
+	L := taggedHash(tagKeyAggList, encoded...)
+
+	coefficients := make([]*big.Int, len(pubkeys))
+	var ax, ay *big.Int
+	for i, pk := range pubkeys {
+		coefficients[i] = hashToScalar(curve, tagKeyAggCoeff, L, encoded[i])
+		px, py := curve.ScalarMult(pk.X, pk.Y, coefficients[i].Bytes())
+		if ax == nil {
+			ax, ay = px, py
+		} else {
+			ax, ay = curve.Add(ax, ay, px, py)
+		}
+	}
+	if ax.Sign() == 0 && ay.Sign() == 0 {
+		return nil, ErrInfinityPoint
+	}
+
+	return &KeyAggContext{
+		Curve:         curve,
+		PublicKeys:    pubkeys,
+		AggregatedKey: &ecdsa.PublicKey{Curve: curve, X: ax, Y: ay},
+		coefficients:  coefficients,
+	}, nil
+}
+
+// GenerateNonce 第一轮：生成私有随机数和公开随机数，随机数由新鲜随机数、私钥和聚合公钥派生
+func GenerateNonce(privkey *ecdsa.PrivateKey, ctx *KeyAggContext) (*SecretNonce, *PublicNonce, error) {
+	curve := ctx.Curve
+	size := ecc.CoordinateLength(curve)
+
+	rnd := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, rnd); err != nil {
+		return nil, nil, err
+	}
+	sk := privkey.D.FillBytes(make([]byte, size))
+	aggKey := marshalPoint(curve, ctx.AggregatedKey.X, ctx.AggregatedKey.Y)
+
+	k1 := hashToScalar(curve, tagNonceGen, rnd, sk, aggKey, []byte{1})
+	k2 := hashToScalar(curve, tagNonceGen, rnd, sk, aggKey, []byte{2})
+	if k1.Sign() == 0 || k2.Sign() == 0 {
+		return nil, nil, ErrInfinityPoint
+	}
+
+	x1, y1 := curve.ScalarBaseMult(k1.Bytes())
+	x2, y2 := curve.ScalarBaseMult(k2.Bytes())
+	return &SecretNonce{k1: k1, k2: k2}, &PublicNonce{X1: x1, Y1: y1, X2: x2, Y2: y2}, nil
+}
+
+// AggregateNonces 聚合所有签名者的公开随机数，可由任一参与方或协调者完成
+// 公开随机数来自其它签名者，与ParsePublicNonce一样要求每个点都不为空且在曲线上
+func AggregateNonces(curve elliptic.Curve, nonces []*PublicNonce) (*PublicNonce, error) {
+	if len(nonces) == 0 {
+		return nil, ErrNotEnoughSigners
+	}
+	for _, nonce := range nonces {
+		if nonce == nil || !isOnCurve(curve, nonce.X1, nonce.Y1) || !isOnCurve(curve, nonce.X2, nonce.Y2) {
+			return nil, ErrInvalidPoint
+		}
+	}
+
+	agg := &PublicNonce{X1: nonces[0].X1, Y1: nonces[0].Y1, X2: nonces[0].X2, Y2: nonces[0].Y2}
+	for _, nonce := range nonces[1:] {
+		agg.X1, agg.Y1 = curve.Add(agg.X1, agg.Y1, nonce.X1, nonce.Y1)
+		agg.X2, agg.Y2 = curve.Add(agg.X2, agg.Y2, nonce.X2, nonce.Y2)
+	}
+	return agg, nil
+}
+
+// Bytes 公开随机数编码为两个压缩点 R_i1 || R_i2
+func (nonce *PublicNonce) Bytes(curve elliptic.Curve) []byte {
+	return append(marshalPoint(curve, nonce.X1, nonce.Y1), marshalPoint(curve, nonce.X2, nonce.Y2)...)
+}
+
+// ParsePublicNonce 从字节解析公开随机数
+func ParsePublicNonce(curve elliptic.Curve, data []byte) (*PublicNonce, error) {
+	size := 1 + ecc.CoordinateLength(curve)
+	if len(data) != 2*size {
+		return nil, ErrInvalidPoint
+	}
+	x1, y1, err := unmarshalPoint(curve, data[:size])
+	if err != nil {
+		return nil, err
+	}
+	x2, y2, err := unmarshalPoint(curve, data[size:])
+	if err != nil {
+		return nil, err
+	}
+	return &PublicNonce{X1: x1, Y1: y1, X2: x2, Y2: y2}, nil
+}
+
+// NewSession 第二轮：根据聚合随机数和消息计算 b、R、e
+func NewSession(ctx *KeyAggContext, aggNonce *PublicNonce, msg []byte) (*Session, error) {
+	curve := ctx.Curve
+	n := curve.Params().N
+	if !isOnCurve(curve, aggNonce.X1, aggNonce.Y1) || !isOnCurve(curve, aggNonce.X2, aggNonce.Y2) {
+		return nil, ErrInvalidPoint
+	}
+
+	X := marshalPoint(curve, ctx.AggregatedKey.X, ctx.AggregatedKey.Y)
+	b := hashToScalar(curve, tagNonceCoeff, X,
+		marshalPoint(curve, aggNonce.X1, aggNonce.Y1), marshalPoint(curve, aggNonce.X2, aggNonce.Y2), msg)
+
+	// R = R_1 + b*R_2
+	bx, by := curve.ScalarMult(aggNonce.X2, aggNonce.Y2, b.Bytes())
+	rx, ry := curve.Add(aggNonce.X1, aggNonce.Y1, bx, by)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return nil, ErrInfinityPoint
+	}
+	R := marshalPoint(curve, rx, ry)
+	e := challenge(curve, R, X, msg)
+
+	return &Session{
+		ctx: ctx,
+		b:   new(big.Int).Mod(b, n),
+		e:   e,
+		R:   R,
+	}, nil
+}
+
+// PartialSign 计算部分签名 s_i = k_i1 + b*k_i2 + e*a_i*x_i，secNonce使用后清零
+func (s *Session) PartialSign(privkey *ecdsa.PrivateKey, secNonce *SecretNonce) (*big.Int, error) {
+	if secNonce.k1 == nil || secNonce.k2 == nil {
+		return nil, ErrNonceReused
+	}
+	index, err := s.ctx.indexOf(&privkey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	n := s.ctx.Curve.Params().N
+
+	partial := new(big.Int).Mul(s.e, s.ctx.coefficients[index])
+	partial.Mul(partial, privkey.D)
+	partial.Add(partial, secNonce.k1)
+	partial.Add(partial, new(big.Int).Mul(s.b, secNonce.k2))
+	partial.Mod(partial, n)
+
+	secNonce.k1, secNonce.k2 = nil, nil
+	return partial, nil
+}
+
+// VerifyPartial 验证第index个签名者的部分签名 s_i*G == R_i1 + b*R_i2 + e*a_i*P_i，用于定位作恶的签名者
+func (s *Session) VerifyPartial(index int, partial *big.Int, pubNonce *PublicNonce) error {
+	if index < 0 || index >= len(s.ctx.PublicKeys) {
+		return ErrSignerNotFound
+	}
+	curve := s.ctx.Curve
+	if partial.Sign() < 0 || partial.Cmp(curve.Params().N) >= 0 {
+		return ErrInvalidPartialSig
+	}
+	if !isOnCurve(curve, pubNonce.X1, pubNonce.Y1) || !isOnCurve(curve, pubNonce.X2, pubNonce.Y2) {
+		return ErrInvalidPoint
+	}
+	pk := s.ctx.PublicKeys[index]
+
+	lx, ly := curve.ScalarBaseMult(partial.Bytes())
+
+	bx, by := curve.ScalarMult(pubNonce.X2, pubNonce.Y2, s.b.Bytes())
+	rx, ry := curve.Add(pubNonce.X1, pubNonce.Y1, bx, by)
+	ea := new(big.Int).Mul(s.e, s.ctx.coefficients[index])
+	ea.Mod(ea, curve.Params().N)
+	px, py := curve.ScalarMult(pk.X, pk.Y, ea.Bytes())
+	qx, qy := curve.Add(rx, ry, px, py)
+
+	if lx.Cmp(qx) != 0 || ly.Cmp(qy) != 0 {
+		return ErrInvalidPartialSig
+	}
+	return nil
+}
+
+// Aggregate 聚合部分签名，得到聚合公钥下的Schnorr签名
+func (s *Session) Aggregate(partials []*big.Int) ([]byte, error) {
+	if len(partials) != len(s.ctx.PublicKeys) {
+		return nil, fmt.Errorf("%w: got %d partial signatures for %d signers", ErrInvalidPartialSig, len(partials), len(s.ctx.PublicKeys))
+	}
+	curve := s.ctx.Curve
+	n := curve.Params().N
+
+	sum := new(big.Int)
+	for _, partial := range partials {
+		sum.Add(sum, partial)
+	}
+	sum.Mod(sum, n)
+
+	sig := append([]byte{}, s.R...)
+	sig = append(sig, sum.FillBytes(make([]byte, ecc.CoordinateLength(curve)))...)
+	return sig, nil
+}
+
+// indexOf 查找公钥在聚合列表中的位置
+func (ctx *KeyAggContext) indexOf(pk *ecdsa.PublicKey) (int, error) {
+	target := marshalPoint(ctx.Curve, pk.X, pk.Y)
+	for i, p := range ctx.PublicKeys {
+		if bytes.Equal(target, marshalPoint(ctx.Curve, p.X, p.Y)) {
+			return i, nil
+		}
+	}
+	return -1, ErrSignerNotFound
+}
+
+func isOnCurve(curve elliptic.Curve, x, y *big.Int) bool {
+	return x != nil && y != nil && curve.IsOnCurve(x, y)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schnorr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+)
+
+/*
+	Schnorr signatures on the curves of the ecc registry (P-256, P-384, P-521, secp256k1, SM2), keys are standard ecdsa keys.
+	基于ecc曲线注册表中曲线的Schnorr签名，密钥使用标准库的ecdsa密钥
+	  Sign:   R = k*G, e = H(R || P || m) mod n, s = k + e*x mod n
+	  Verify: s*G == R + e*P
+	签名编码为 R(压缩点，1+L字节) || s(L字节)，L为曲线的坐标长度
+	哈希使用带标签的SHA-256：H_tag(x) = SHA256(SHA256(tag) || SHA256(tag) || x)，不同用途的哈希互不干扰
+*/
+
+var (
+	ErrInvalidSignature = errors.New("invalid schnorr signature")
+	ErrVerifyFailed     = errors.New("failed to verify schnorr signature")
+	ErrInvalidPoint     = errors.New("invalid curve point")
+	ErrUnsupportedCurve = errors.New("unsupported curve")
+)
+
+const (
+	tagChallenge = "DTX/Schnorr/challenge"
+	tagNonce     = "DTX/Schnorr/nonce"
+)
+
+// Sign 使用私钥对消息签名，随机数k由私钥、消息和新鲜随机数派生，随机源失效时也不会重复使用k
+func Sign(privkey *ecdsa.PrivateKey, msg []byte) ([]byte, error) {
+	curve := privkey.Curve
+	if _, err := ecc.IDOfCurve(curve); err != nil {
+		return nil, ErrUnsupportedCurve
+	}
+	n := curve.Params().N
+	size := ecc.CoordinateLength(curve)
+
+	aux := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, aux); err != nil {
+		return nil, err
+	}
+	k := hashToScalar(curve, tagNonce, privkey.D.FillBytes(make([]byte, size)), aux, msg)
+	if k.Sign() == 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	rx, ry := curve.ScalarBaseMult(k.Bytes())
+	R := marshalPoint(curve, rx, ry)
+	e := challenge(curve, R, marshalPoint(curve, privkey.X, privkey.Y), msg)
+
+	// s = k + e*x mod n
+	s := new(big.Int).Mul(e, privkey.D)
+	s.Add(s, k)
+	s.Mod(s, n)
+
+	return append(R, s.FillBytes(make([]byte, size))...), nil
+}
+
+// Verify 验证签名
+func Verify(pubkey *ecdsa.PublicKey, msg, sig []byte) error {
+	curve := pubkey.Curve
+	if _, err := ecc.IDOfCurve(curve); err != nil {
+		return ErrUnsupportedCurve
+	}
+	if !curve.IsOnCurve(pubkey.X, pubkey.Y) {
+		return ErrInvalidPoint
+	}
+	n := curve.Params().N
+	size := ecc.CoordinateLength(curve)
+
+	if len(sig) != 1+2*size {
+		return ErrInvalidSignature
+	}
+	R := sig[:1+size]
+	rx, ry, err := unmarshalPoint(curve, R)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	s := new(big.Int).SetBytes(sig[1+size:])
+	if s.Cmp(n) >= 0 {
+		return ErrInvalidSignature
+	}
+
+	e := challenge(curve, R, marshalPoint(curve, pubkey.X, pubkey.Y), msg)
+
+	// s*G == R + e*P
+	lx, ly := curve.ScalarBaseMult(s.Bytes())
+	ex, ey := curve.ScalarMult(pubkey.X, pubkey.Y, e.Bytes())
+	qx, qy := curve.Add(rx, ry, ex, ey)
+	if lx.Cmp(qx) != 0 || ly.Cmp(qy) != 0 {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// challenge e = H(R || P || m) mod n
+func challenge(curve elliptic.Curve, R, P, msg []byte) *big.Int {
+	return hashToScalar(curve, tagChallenge, R, P, msg)
+}
+
+// taggedHash H_tag(x) = SHA256(SHA256(tag) || SHA256(tag) || x)
+func taggedHash(tag string, data ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// hashToScalar 带标签的哈希对曲线的阶取模
+func hashToScalar(curve elliptic.Curve, tag string, data ...[]byte) *big.Int {
+	e := new(big.Int).SetBytes(taggedHash(tag, data...))
+	return e.Mod(e, curve.Params().N)
+}
+
+// marshalPoint 压缩点编码，elliptic.MarshalCompressed对所有曲线通用
+func marshalPoint(curve elliptic.Curve, x, y *big.Int) []byte {
+	return elliptic.MarshalCompressed(curve, x, y)
+}
+
+// unmarshalPoint 解析压缩点，elliptic.UnmarshalCompressed假设a=-3，不适用于secp256k1，这里使用ecc.DecompressY
+func unmarshalPoint(curve elliptic.Curve, data []byte) (*big.Int, *big.Int, error) {
+	size := ecc.CoordinateLength(curve)
+	if len(data) != 1+size || (data[0] != 2 && data[0] != 3) {
+		return nil, nil, ErrInvalidPoint
+	}
+	x := new(big.Int).SetBytes(data[1:])
+	y, err := ecc.DecompressY(curve, x, data[0] == 3)
+	if err != nil {
+		return nil, nil, ErrInvalidPoint
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil, ErrInvalidPoint
+	}
+	return x, y, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schnorr
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+)
+
+var testCurves = []string{config.CurveNist, config.CurveNistP384, config.CurveNistP521, config.CurveSecp256k1, config.CurveSM2}
+
+func TestSchnorr(t *testing.T) {
+	msg := []byte("schnorr message")
+	for _, name := range testCurves {
+		curve, err := ecc.CurveByName(name)
+		require.NoError(t, err)
+		privkey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		sig, err := Sign(privkey, msg)
+		require.NoError(t, err, name)
+		require.NoError(t, Verify(&privkey.PublicKey, msg, sig), name)
+		require.Equal(t, ErrVerifyFailed, Verify(&privkey.PublicKey, []byte("other"), sig))
+
+		sig[len(sig)-1] ^= 1
+		require.Error(t, Verify(&privkey.PublicKey, msg, sig))
+		require.Equal(t, ErrInvalidSignature, Verify(&privkey.PublicKey, msg, sig[1:]))
+	}
+}
+
+func TestMuSig2(t *testing.T) {
+	msg := []byte("jointly signed training result")
+	num := 3
+	for _, name := range testCurves {
+		curve, err := ecc.CurveByName(name)
+		require.NoError(t, err)
+
+		privkeys := make([]*ecdsa.PrivateKey, num)
+		pubkeys := make([]*ecdsa.PublicKey, num)
+		for i := range privkeys {
+			privkeys[i], err = ecdsa.GenerateKey(curve, rand.Reader)
+			require.NoError(t, err)
+			pubkeys[i] = &privkeys[i].PublicKey
+		}
+		ctx, err := AggregatePublicKeys(pubkeys)
+		require.NoError(t, err, name)
+
+		// 第一轮
+		secNonces := make([]*SecretNonce, num)
+		pubNonces := make([]*PublicNonce, num)
+		for i := range privkeys {
+			secNonces[i], pubNonces[i], err = GenerateNonce(privkeys[i], ctx)
+			require.NoError(t, err)
+
+			parsed, err := ParsePublicNonce(curve, pubNonces[i].Bytes(curve))
+			require.NoError(t, err, name)
+			require.Equal(t, pubNonces[i].Bytes(curve), parsed.Bytes(curve))
+		}
+		aggNonce, err := AggregateNonces(curve, pubNonces)
+		require.NoError(t, err)
+		for _, invalid := range []*PublicNonce{nil, {}, {X1: big.NewInt(1), Y1: big.NewInt(1), X2: pubNonces[0].X2, Y2: pubNonces[0].Y2}} {
+			_, err = AggregateNonces(curve, append([]*PublicNonce{pubNonces[0]}, invalid))
+			require.Equal(t, ErrInvalidPoint, err, name)
+		}
+
+		// 第二轮
+		session, err := NewSession(ctx, aggNonce, msg)
+		require.NoError(t, err, name)
+		partials := make([]*big.Int, num)
+		for i := range privkeys {
+			partials[i], err = session.PartialSign(privkeys[i], secNonces[i])
+			require.NoError(t, err)
+			require.NoError(t, session.VerifyPartial(i, partials[i], pubNonces[i]), name)
+		}
+		_, err = session.PartialSign(privkeys[0], secNonces[0])
+		require.Equal(t, ErrNonceReused, err)
+		require.Equal(t, ErrInvalidPartialSig, session.VerifyPartial(1, partials[0], pubNonces[1]))
+
+		sig, err := session.Aggregate(partials)
+		require.NoError(t, err)
+		require.NoError(t, Verify(ctx.AggregatedKey, msg, sig), name)
+		require.Error(t, Verify(pubkeys[0], msg, sig))
+
+		// 缺少一个签名者的部分签名
+		_, err = session.Aggregate(partials[:num-1])
+		require.Error(t, err)
+
+		outsider, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		secNonce, _, err := GenerateNonce(outsider, ctx)
+		require.NoError(t, err)
+		_, err = session.PartialSign(outsider, secNonce)
+		require.Equal(t, ErrSignerNotFound, err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package additive_secret_share
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+)
+
+// 加法秘密分享 - 多方安全计算的算术层
+//
+// 秘密x被分割为n份，x = x(0) + x(1) + ... + x(n-1) mod M，任意n-1份都与x无关
+// M可以是一个素数(素数域)，也可以是2^64(Z_2^64，与机器字长一致)
+//
+// 线性运算可以由各方在本地完成：
+//	[x+y] = [x] + [y]
+//	[c*x] = c * [x]
+//	[x+c] = [x] + c，常数只由编号为0的参与方加上
+// 乘法需要一次交互，使用Beaver三元组，参见beaver_triple.go
+//
+// 定点数：实数r编码为round(r*2^f) mod M，负数编码为M-|r|*2^f，f为小数位数
+// 两个定点数相乘后小数位数变为2f，需要截断f位，参见TruncateShare
+
+var (
+	ErrInvalidPartyNumber = errors.New("the number of parties must be greater than one")
+	ErrInvalidModulus     = errors.New("modulus must be greater than one")
+	ErrTruncatePartyIndex = errors.New("local truncation only supports two parties, party index must be 0 or 1")
+	ErrNonFiniteValue     = errors.New("fixed point encoding requires a finite value")
+)
+
+// AdditiveShareClient 在模M的环上进行加法秘密分享
+type AdditiveShareClient struct {
+	// 模数，素数或2^64
+	modulus *big.Int
+}
+
+// New 使用指定的模数创建AdditiveShareClient，模数可以是素数，也可以是2的幂
+func New(modulus *big.Int) (*AdditiveShareClient, error) {
+	if modulus == nil || modulus.Cmp(big.NewInt(1)) <= 0 {
+		return nil, ErrInvalidModulus
+	}
+
+	asc := new(AdditiveShareClient)
+	asc.modulus = new(big.Int).Set(modulus)
+
+	return asc, nil
+}
+
+// NewRing64 创建模2^64的AdditiveShareClient
+func NewRing64() *AdditiveShareClient {
+	asc, _ := New(new(big.Int).Lsh(big.NewInt(1), 64))
+	return asc
+}
+
+// Modulus 返回模数
+func (asc *AdditiveShareClient) Modulus() *big.Int {
+	return new(big.Int).Set(asc.modulus)
+}
+
+// Random 生成[0, M)内的随机数
+func (asc *AdditiveShareClient) Random() (*big.Int, error) {
+	return rand.Int(rand.Reader, asc.modulus)
+}
+
+// Split 将秘密分割为partyNumber份加法分享
+func (asc *AdditiveShareClient) Split(secret *big.Int, partyNumber int) ([]*big.Int, error) {
+	if partyNumber < 2 {
+		return nil, ErrInvalidPartyNumber
+	}
+
+	shares := make([]*big.Int, partyNumber)
+	last := new(big.Int).Mod(secret, asc.modulus)
+	for i := 0; i < partyNumber-1; i++ {
+		share, err := asc.Random()
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = share
+		last.Sub(last, share)
+	}
+	shares[partyNumber-1] = last.Mod(last, asc.modulus)
+
+	return shares, nil
+}
+
+// Reconstruct 将所有分享相加，恢复出秘密
+func (asc *AdditiveShareClient) Reconstruct(shares []*big.Int) *big.Int {
+	secret := big.NewInt(0)
+	for _, share := range shares {
+		secret.Add(secret, share)
+	}
+	return secret.Mod(secret, asc.modulus)
+}
+
+// Add 本地计算[x+y]
+func (asc *AdditiveShareClient) Add(x, y *big.Int) *big.Int {
+	z := new(big.Int).Add(x, y)
+	return z.Mod(z, asc.modulus)
+}
+
+// Sub 本地计算[x-y]
+func (asc *AdditiveShareClient) Sub(x, y *big.Int) *big.Int {
+	z := new(big.Int).Sub(x, y)
+	return z.Mod(z, asc.modulus)
+}
+
+// AddConst 本地计算[x+c]，常数c只由编号为0的参与方加上
+func (asc *AdditiveShareClient) AddConst(partyIndex int, x, c *big.Int) *big.Int {
+	if partyIndex != 0 {
+		return new(big.Int).Set(x)
+	}
+	return asc.Add(x, c)
+}
+
+// MulConst 本地计算[c*x]
+func (asc *AdditiveShareClient) MulConst(x, c *big.Int) *big.Int {
+	z := new(big.Int).Mul(x, c)
+	return z.Mod(z, asc.modulus)
+}
+
+// EncodeFixedPoint 将实数编码为小数位数为fracBits的定点数，NaN、±Inf以及放大后溢出的值返回错误
+func (asc *AdditiveShareClient) EncodeFixedPoint(r float64, fracBits uint) (*big.Int, error) {
+	scaled := math.Round(math.Ldexp(r, int(fracBits)))
+	if math.IsNaN(scaled) || math.IsInf(scaled, 0) {
+		return nil, ErrNonFiniteValue
+	}
+	v, _ := new(big.Float).SetFloat64(scaled).Int(nil)
+	return v.Mod(v, asc.modulus), nil
+}
+
+// DecodeFixedPoint 将小数位数为fracBits的定点数解码为实数，大于M/2的值视为负数
+func (asc *AdditiveShareClient) DecodeFixedPoint(v *big.Int, fracBits uint) float64 {
+	signed := new(big.Int).Mod(v, asc.modulus)
+	half := new(big.Int).Rsh(asc.modulus, 1)
+	if signed.Cmp(half) > 0 {
+		signed.Sub(signed, asc.modulus)
+	}
+
+	f, _ := new(big.Float).SetInt(signed).Float64()
+	return math.Ldexp(f, -int(fracBits))
+}
+
+// TruncateShare 两方场景下对定点数的分享进行本地截断，去掉fracBits个小数位(SecureML)
+// 参与方0计算floor(x(0)/2^f)，参与方1计算M-floor((M-x(1))/2^f)
+// 当|x|远小于M时，截断结果与明文截断最多相差最低位的1，
+// 出现较大误差的概率约为|x|/M，因此M需要比定点数的取值范围大得多(例如Z_2^64上使用不超过40比特的数值)
+func (asc *AdditiveShareClient) TruncateShare(partyIndex int, share *big.Int, fracBits uint) (*big.Int, error) {
+	switch partyIndex {
+	case 0:
+		return new(big.Int).Rsh(share, fracBits), nil
+	case 1:
+		z := new(big.Int).Sub(asc.modulus, share)
+		z.Rsh(z, fracBits)
+		z.Sub(asc.modulus, z)
+		return z.Mod(z, asc.modulus), nil
+	default:
+		return nil, ErrTruncatePartyIndex
+	}
+}
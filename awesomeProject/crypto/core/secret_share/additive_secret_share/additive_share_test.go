@@ -0,0 +1,186 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package additive_secret_share
+
+import (
+	"crypto/elliptic"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+)
+
+// multiply 模拟各方交互，使用三元组计算x*y的分享
+func multiply(t *testing.T, asc *AdditiveShareClient, xs, ys []*big.Int, triples []*TripleShare) []*big.Int {
+	var ds, es []*big.Int
+	for i := range xs {
+		d, e, err := asc.BeaverMask(xs[i], ys[i], triples[i])
+		require.NoError(t, err)
+		ds = append(ds, d)
+		es = append(es, e)
+	}
+	d, e := asc.Reconstruct(ds), asc.Reconstruct(es)
+
+	zs := make([]*big.Int, len(xs))
+	for i := range xs {
+		z, err := asc.BeaverMultiply(i, d, e, triples[i])
+		require.NoError(t, err)
+		zs[i] = z
+	}
+	return zs
+}
+
+func TestArithmetic(t *testing.T) {
+	prime := elliptic.P256().Params().N
+	prime64, _ := new(big.Int).SetString("18446744073709551557", 10)
+
+	for _, modulus := range []*big.Int{prime, prime64, new(big.Int).Lsh(big.NewInt(1), 64)} {
+		asc, err := New(modulus)
+		require.NoError(t, err)
+
+		x, y, c := big.NewInt(123456), big.NewInt(-789), big.NewInt(42)
+		xs, err := asc.Split(x, 3)
+		require.NoError(t, err)
+		ys, err := asc.Split(y, 3)
+		require.NoError(t, err)
+
+		var sum, scaled, shifted []*big.Int
+		for i := range xs {
+			sum = append(sum, asc.Add(xs[i], ys[i]))
+			scaled = append(scaled, asc.MulConst(xs[i], c))
+			shifted = append(shifted, asc.AddConst(i, ys[i], c))
+		}
+		require.Equal(t, new(big.Int).Mod(new(big.Int).Add(x, y), modulus), asc.Reconstruct(sum))
+		require.Equal(t, new(big.Int).Mod(new(big.Int).Mul(x, c), modulus), asc.Reconstruct(scaled))
+		require.Equal(t, new(big.Int).Mod(new(big.Int).Add(y, c), modulus), asc.Reconstruct(shifted))
+
+		triples, err := asc.GenerateTriples(3, 1)
+		require.NoError(t, err)
+		product := multiply(t, asc, xs, ys, []*TripleShare{triples[0][0], triples[1][0], triples[2][0]})
+		require.Equal(t, new(big.Int).Mod(new(big.Int).Mul(x, y), modulus), asc.Reconstruct(product))
+	}
+
+	_, err := New(big.NewInt(1))
+	require.Equal(t, ErrInvalidModulus, err)
+	_, err = NewRing64().Split(big.NewInt(1), 1)
+	require.Equal(t, ErrInvalidPartyNumber, err)
+}
+
+func TestPaillierTriples(t *testing.T) {
+	asc := NewRing64()
+
+	privateKey, err := paillier.GeneratePrivateKey(paillier.DefaultPrimeLength)
+	require.NoError(t, err)
+
+	triplesA, request, err := asc.PaillierTripleStart(&privateKey.PublicKey, 4)
+	require.NoError(t, err)
+	triplesB, response, err := asc.PaillierTripleRespond(&privateKey.PublicKey, request)
+	require.NoError(t, err)
+
+	_, _, err = asc.BeaverMask(big.NewInt(1), big.NewInt(1), triplesA[0])
+	require.Equal(t, ErrIncompleteTriple, err)
+
+	require.NoError(t, asc.PaillierTripleFinish(privateKey, triplesA, response))
+
+	for k := range triplesA {
+		a := asc.Add(triplesA[k].A, triplesB[k].A)
+		b := asc.Add(triplesA[k].B, triplesB[k].B)
+		c := asc.Add(triplesA[k].C, triplesB[k].C)
+		require.Equal(t, asc.MulConst(a, b), c)
+	}
+
+	// 安全内积
+	xVec := []int64{3, -5, 7, 11}
+	yVec := []int64{-2, 4, 6, -8}
+	var xsA, xsB, ysA, ysB []*big.Int
+	var expected int64
+	for k := range xVec {
+		xs, _ := asc.Split(big.NewInt(xVec[k]), 2)
+		ys, _ := asc.Split(big.NewInt(yVec[k]), 2)
+		xsA, xsB = append(xsA, xs[0]), append(xsB, xs[1])
+		ysA, ysB = append(ysA, ys[0]), append(ysB, ys[1])
+		expected += xVec[k] * yVec[k]
+	}
+
+	dsA, esA, err := asc.InnerProductMask(xsA, ysA, triplesA)
+	require.NoError(t, err)
+	dsB, esB, err := asc.InnerProductMask(xsB, ysB, triplesB)
+	require.NoError(t, err)
+	var ds, es []*big.Int
+	for k := range dsA {
+		ds = append(ds, asc.Add(dsA[k], dsB[k]))
+		es = append(es, asc.Add(esA[k], esB[k]))
+	}
+
+	zA, err := asc.InnerProduct(0, ds, es, triplesA)
+	require.NoError(t, err)
+	zB, err := asc.InnerProduct(1, ds, es, triplesB)
+	require.NoError(t, err)
+	require.Equal(t, float64(expected), asc.DecodeFixedPoint(asc.Reconstruct([]*big.Int{zA, zB}), 0))
+
+	// Paillier的N太短
+	smallKey, err := paillier.GeneratePrivateKey(128)
+	require.NoError(t, err)
+	_, _, err = mustNew(t, elliptic.P256().Params().N).PaillierTripleStart(&smallKey.PublicKey, 1)
+	require.Equal(t, ErrPaillierKeyTooShort, err)
+}
+
+func TestFixedPointTruncation(t *testing.T) {
+	var fracBits uint = 16
+
+	for _, asc := range []*AdditiveShareClient{NewRing64(), mustNew(t, elliptic.P256().Params().N)} {
+		for _, pair := range [][2]float64{{1.5, -2.25}, {-3.125, -0.5}, {12.75, 8.5}, {0, 7}} {
+			x, err := asc.EncodeFixedPoint(pair[0], fracBits)
+			require.NoError(t, err)
+			y, err := asc.EncodeFixedPoint(pair[1], fracBits)
+			require.NoError(t, err)
+			require.Equal(t, pair[0], asc.DecodeFixedPoint(x, fracBits))
+
+			xs, err := asc.Split(x, 2)
+			require.NoError(t, err)
+			ys, err := asc.Split(y, 2)
+			require.NoError(t, err)
+			triples, err := asc.GenerateTriples(2, 1)
+			require.NoError(t, err)
+
+			// 乘积有2f个小数位，截断f位
+			zs := multiply(t, asc, xs, ys, []*TripleShare{triples[0][0], triples[1][0]})
+			for i := range zs {
+				zs[i], err = asc.TruncateShare(i, zs[i], fracBits)
+				require.NoError(t, err)
+			}
+
+			result := asc.DecodeFixedPoint(asc.Reconstruct(zs), fracBits)
+			require.True(t, math.Abs(result-pair[0]*pair[1]) <= math.Ldexp(1, -int(fracBits)),
+				"expected %v, got %v", pair[0]*pair[1], result)
+		}
+	}
+
+	_, err := NewRing64().TruncateShare(2, big.NewInt(1), fracBits)
+	require.Equal(t, ErrTruncatePartyIndex, err)
+
+	for _, r := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), math.MaxFloat64} {
+		_, err = NewRing64().EncodeFixedPoint(r, fracBits)
+		require.Equal(t, ErrNonFiniteValue, err)
+	}
+}
+
+func mustNew(t *testing.T, modulus *big.Int) *AdditiveShareClient {
+	asc, err := New(modulus)
+	require.NoError(t, err)
+	return asc
+}
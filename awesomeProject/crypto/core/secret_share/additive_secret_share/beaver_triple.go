@@ -0,0 +1,307 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package additive_secret_share
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/homomorphism/paillier"
+)
+
+// Beaver三元组乘法
+//
+// 离线阶段生成随机三元组的分享[a]、[b]、[c]，满足c = a*b mod M
+// 在线阶段计算[x*y]：
+// Step 1：各方本地计算d(i) = x(i)-a(i)，e(i) = y(i)-b(i)，并公开给其他参与方
+// Step 2：各方将公开值相加得到d = x-a，e = y-b，由于a和b是随机的，d和e不泄露x和y
+// Step 3：各方本地计算z(i) = c(i) + d*b(i) + e*a(i)，参与方0额外加上d*e
+//			z = c + d*b + e*a + d*e = (d+a)(e+b) = x*y
+//
+// 三元组的生成方式：
+// 1. 可信第三方：由可信第三方生成a、b、c并分发分享，参见GenerateTriples
+// 2. 两方Paillier：无需可信第三方，参见PaillierTripleStart
+//		参与方A生成a(A)、b(A)，用自己的Paillier公钥加密后发送给参与方B
+//		参与方B生成a(B)、b(B)和统计掩码r，同态计算E(a(A)*b(B) + b(A)*a(B) + r)发送给A
+//		A解密得到v，c(A) = a(A)*b(A) + v，c(B) = a(B)*b(B) - r
+//		为保证同态运算不在模N上溢出，要求Paillier的N比M^2大statisticalSecurity+2比特以上
+
+const (
+	// statisticalSecurity Paillier三元组生成中统计掩码的安全参数
+	statisticalSecurity = 40
+)
+
+var (
+	ErrInvalidTripleNumber  = errors.New("the number of triples must be greater than zero")
+	ErrTripleNumberMismatch = errors.New("the number of triples does not match")
+	ErrVectorLengthMismatch = errors.New("the length of vectors does not match")
+	ErrPaillierKeyTooShort  = errors.New("paillier modulus is too short for the ring")
+	ErrIncompleteTriple     = errors.New("triple share is incomplete")
+)
+
+// TripleShare 参与方持有的一个Beaver三元组的分享
+type TripleShare struct {
+	A *big.Int `json:"a"`
+	B *big.Int `json:"b"`
+	C *big.Int `json:"c"`
+}
+
+// TripleRequest 两方Paillier三元组生成中，参与方A发送给参与方B的密文
+type TripleRequest struct {
+	EncA []*big.Int `json:"enc_a"`
+	EncB []*big.Int `json:"enc_b"`
+}
+
+// GenerateTriples 可信第三方生成tripleNumber个Beaver三元组，并分割为partyNumber份
+// 返回值的第i项为参与方i持有的全部三元组分享
+func (asc *AdditiveShareClient) GenerateTriples(partyNumber, tripleNumber int) ([][]*TripleShare, error) {
+	if partyNumber < 2 {
+		return nil, ErrInvalidPartyNumber
+	}
+	if tripleNumber < 1 {
+		return nil, ErrInvalidTripleNumber
+	}
+
+	triples := make([][]*TripleShare, partyNumber)
+	for i := range triples {
+		triples[i] = make([]*TripleShare, tripleNumber)
+	}
+
+	for k := 0; k < tripleNumber; k++ {
+		a, err := asc.Random()
+		if err != nil {
+			return nil, err
+		}
+		b, err := asc.Random()
+		if err != nil {
+			return nil, err
+		}
+		c := asc.MulConst(a, b)
+
+		aShares, err := asc.Split(a, partyNumber)
+		if err != nil {
+			return nil, err
+		}
+		bShares, err := asc.Split(b, partyNumber)
+		if err != nil {
+			return nil, err
+		}
+		cShares, err := asc.Split(c, partyNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < partyNumber; i++ {
+			triples[i][k] = &TripleShare{
+				A: aShares[i],
+				B: bShares[i],
+				C: cShares[i],
+			}
+		}
+	}
+
+	return triples, nil
+}
+
+// PaillierTripleStart 两方Paillier三元组生成，参与方A生成自己的a(A)、b(A)并加密
+// 返回的三元组分享中C为空，需在收到参与方B的回复后调用PaillierTripleFinish补全
+func (asc *AdditiveShareClient) PaillierTripleStart(publicKey *paillier.PublicKey, tripleNumber int) ([]*TripleShare, *TripleRequest, error) {
+	if tripleNumber < 1 {
+		return nil, nil, ErrInvalidTripleNumber
+	}
+	if err := asc.checkPaillierKey(publicKey); err != nil {
+		return nil, nil, err
+	}
+
+	triples := make([]*TripleShare, tripleNumber)
+	request := &TripleRequest{
+		EncA: make([]*big.Int, tripleNumber),
+		EncB: make([]*big.Int, tripleNumber),
+	}
+	for k := 0; k < tripleNumber; k++ {
+		a, err := asc.Random()
+		if err != nil {
+			return nil, nil, err
+		}
+		b, err := asc.Random()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if request.EncA[k], err = publicKey.Encrypt(a); err != nil {
+			return nil, nil, err
+		}
+		if request.EncB[k], err = publicKey.Encrypt(b); err != nil {
+			return nil, nil, err
+		}
+		triples[k] = &TripleShare{A: a, B: b}
+	}
+
+	return triples, request, nil
+}
+
+// PaillierTripleRespond 两方Paillier三元组生成，参与方B生成自己的三元组分享，并同态计算交叉项
+// - publicKey 参与方A的Paillier公钥
+// - request 参与方A发来的密文
+// 返回参与方B的三元组分享和需要发送给参与方A的密文
+func (asc *AdditiveShareClient) PaillierTripleRespond(publicKey *paillier.PublicKey, request *TripleRequest) ([]*TripleShare, []*big.Int, error) {
+	if len(request.EncA) != len(request.EncB) {
+		return nil, nil, ErrTripleNumberMismatch
+	}
+	if len(request.EncA) < 1 {
+		return nil, nil, ErrInvalidTripleNumber
+	}
+	if err := asc.checkPaillierKey(publicKey); err != nil {
+		return nil, nil, err
+	}
+
+	// 统计掩码的范围为[0, 2^σ * 2M^2)
+	maskBound := new(big.Int).Mul(asc.modulus, asc.modulus)
+	maskBound.Lsh(maskBound, statisticalSecurity+1)
+
+	triples := make([]*TripleShare, len(request.EncA))
+	response := make([]*big.Int, len(request.EncA))
+	for k := range request.EncA {
+		a, err := asc.Random()
+		if err != nil {
+			return nil, nil, err
+		}
+		b, err := asc.Random()
+		if err != nil {
+			return nil, nil, err
+		}
+		r, err := rand.Int(rand.Reader, maskBound)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// E(a(A)*b(B) + b(A)*a(B) + r)
+		encR, err := publicKey.Encrypt(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		response[k] = publicKey.CyphersAdd(
+			publicKey.CypherPlainMultiply(request.EncA[k], b),
+			publicKey.CypherPlainMultiply(request.EncB[k], a),
+			encR)
+
+		// c(B) = a(B)*b(B) - r
+		c := new(big.Int).Mul(a, b)
+		c.Sub(c, r)
+		triples[k] = &TripleShare{
+			A: a,
+			B: b,
+			C: c.Mod(c, asc.modulus),
+		}
+	}
+
+	return triples, response, nil
+}
+
+// PaillierTripleFinish 两方Paillier三元组生成，参与方A解密参与方B的回复，补全自己的三元组分享
+// - privateKey 参与方A的Paillier私钥
+// - triples PaillierTripleStart返回的三元组分享
+// - response 参与方B发来的密文
+func (asc *AdditiveShareClient) PaillierTripleFinish(privateKey *paillier.PrivateKey, triples []*TripleShare, response []*big.Int) error {
+	if len(triples) != len(response) {
+		return ErrTripleNumberMismatch
+	}
+
+	for k, triple := range triples {
+		// c(A) = a(A)*b(A) + v
+		c := new(big.Int).Mul(triple.A, triple.B)
+		c.Add(c, privateKey.Decrypt(response[k]))
+		triple.C = c.Mod(c, asc.modulus)
+	}
+
+	return nil
+}
+
+// BeaverMask Beaver乘法Step 1，计算需要公开的d(i) = x(i)-a(i)，e(i) = y(i)-b(i)
+func (asc *AdditiveShareClient) BeaverMask(x, y *big.Int, triple *TripleShare) (*big.Int, *big.Int, error) {
+	if triple.A == nil || triple.B == nil || triple.C == nil {
+		return nil, nil, ErrIncompleteTriple
+	}
+	return asc.Sub(x, triple.A), asc.Sub(y, triple.B), nil
+}
+
+// BeaverMultiply Beaver乘法Step 3，使用公开的d、e计算[x*y]的分享
+// - d 所有参与方d(i)之和
+// - e 所有参与方e(i)之和
+func (asc *AdditiveShareClient) BeaverMultiply(partyIndex int, d, e *big.Int, triple *TripleShare) (*big.Int, error) {
+	if triple.A == nil || triple.B == nil || triple.C == nil {
+		return nil, ErrIncompleteTriple
+	}
+
+	z := new(big.Int).Set(triple.C)
+	z.Add(z, new(big.Int).Mul(d, triple.B))
+	z.Add(z, new(big.Int).Mul(e, triple.A))
+	if partyIndex == 0 {
+		z.Add(z, new(big.Int).Mul(d, e))
+	}
+
+	return z.Mod(z, asc.modulus), nil
+}
+
+// InnerProductMask 安全内积Step 1，对向量的每一项计算需要公开的d(i)、e(i)，每一项消耗一个三元组
+func (asc *AdditiveShareClient) InnerProductMask(xs, ys []*big.Int, triples []*TripleShare) ([]*big.Int, []*big.Int, error) {
+	if len(xs) != len(ys) {
+		return nil, nil, ErrVectorLengthMismatch
+	}
+	if len(xs) != len(triples) {
+		return nil, nil, ErrTripleNumberMismatch
+	}
+
+	ds := make([]*big.Int, len(xs))
+	es := make([]*big.Int, len(xs))
+	for k := range xs {
+		d, e, err := asc.BeaverMask(xs[k], ys[k], triples[k])
+		if err != nil {
+			return nil, nil, err
+		}
+		ds[k], es[k] = d, e
+	}
+
+	return ds, es, nil
+}
+
+// InnerProduct 安全内积Step 3，使用公开的ds、es计算[Σx(k)*y(k)]的分享
+func (asc *AdditiveShareClient) InnerProduct(partyIndex int, ds, es []*big.Int, triples []*TripleShare) (*big.Int, error) {
+	if len(ds) != len(es) {
+		return nil, ErrVectorLengthMismatch
+	}
+	if len(ds) != len(triples) {
+		return nil, ErrTripleNumberMismatch
+	}
+
+	sum := big.NewInt(0)
+	for k := range ds {
+		z, err := asc.BeaverMultiply(partyIndex, ds[k], es[k], triples[k])
+		if err != nil {
+			return nil, err
+		}
+		sum.Add(sum, z)
+	}
+
+	return sum.Mod(sum, asc.modulus), nil
+}
+
+// checkPaillierKey 检查Paillier的N是否足够大，保证a(A)*b(B) + b(A)*a(B) + r不会在模N上溢出
+func (asc *AdditiveShareClient) checkPaillierKey(publicKey *paillier.PublicKey) error {
+	if publicKey.N.BitLen() <= 2*asc.modulus.BitLen()+statisticalSecurity+2 {
+		return ErrPaillierKeyTooShort
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package complex_secret_share
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+// 任意长度秘密的分享与碎片编码
+//
+// 秘密按照曲线阶的字节长度减1进行分块，保证每块都小于曲线的阶，每块使用独立的随机多项式分享，
+// 同一参与方在所有多项式上的取值组成一个碎片Share
+//
+// 碎片的二进制编码(大端序)，EncodeToString()输出其十六进制形式：
+//	版本(1) | 序号(2) | 门限值(2) | 曲线名称长度(1) | 曲线名称 | 秘密ID(16) | 秘密长度(4) | 分块数(2) | 每块的值(曲线阶的字节长度) | 校验和(4)
+// 校验和为之前所有字节的SHA256的前4字节
+// 序号、门限值和分块数不能超过65535，秘密长度不能超过2^32-1，超出范围的碎片在分享时和编码时都会被拒绝
+
+const (
+	// ShareEncodingVersion 碎片编码的版本
+	ShareEncodingVersion = 1
+
+	// SecretIDLength 秘密ID的长度，同一秘密的所有碎片具有相同的秘密ID
+	SecretIDLength = 16
+
+	shareChecksumLength = 4
+)
+
+var (
+	EmptySecretError         = errors.New("secret must not be empty")
+	SecretTooLongError       = errors.New("secret must be smaller than the curve order, use ComplexSecretSplitToShares for long secrets")
+	InvalidShareFormatError  = errors.New("invalid share format")
+	ShareChecksumError       = errors.New("share checksum mismatch")
+	UnsupportedCurveError    = errors.New("unsupported curve")
+	InconsistentSharesError  = errors.New("shares do not belong to the same secret")
+	NotEnoughSharesError     = errors.New("the number of shares is smaller than the threshold")
+	DuplicateShareIndexError = errors.New("duplicate share index")
+	ShareOutOfRangeError     = errors.New("share field out of the encoding range")
+)
+
+// Share 任意长度秘密的一个碎片
+type Share struct {
+	Index        int        // 碎片序号，即多项式的x值
+	Threshold    int        // 恢复秘密所需的最少碎片数
+	Curve        string     // 曲线名称
+	SecretID     []byte     // 秘密ID
+	SecretLength int        // 秘密的字节长度
+	Values       []*big.Int // 每个分块对应的碎片值
+}
+
+// ComplexSecretSplitToShares 分享任意长度的秘密，返回totalShareNumber个碎片，序号为1...totalShareNumber
+func ComplexSecretSplitToShares(totalShareNumber, minimumShareNumber int, secret []byte, curve elliptic.Curve) ([]*Share, error) {
+	if len(secret) == 0 {
+		return nil, EmptySecretError
+	}
+	if _, err := curveByName(curve.Params().Name); err != nil {
+		return nil, err
+	}
+	chunkSize := secretChunkSize(curve)
+	if totalShareNumber > math.MaxUint16 || uint64(len(secret)) > math.MaxUint32 ||
+		(len(secret)+chunkSize-1)/chunkSize > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: %d shares, secret of %d bytes", ShareOutOfRangeError, totalShareNumber, len(secret))
+	}
+
+	secretID := make([]byte, SecretIDLength)
+	if _, err := rand.Read(secretID); err != nil {
+		return nil, err
+	}
+
+	shares := make([]*Share, totalShareNumber)
+	for i := range shares {
+		shares[i] = &Share{
+			Index:        i + 1,
+			Threshold:    minimumShareNumber,
+			Curve:        curve.Params().Name,
+			SecretID:     secretID,
+			SecretLength: len(secret),
+		}
+	}
+
+	for start := 0; start < len(secret); start += chunkSize {
+		end := start + chunkSize
+		if end > len(secret) {
+			end = len(secret)
+		}
+
+		chunkShares, err := ComplexSecretSplit(totalShareNumber, minimumShareNumber, secret[start:end], curve)
+		if err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			value := chunkShares[share.Index]
+			share.Values = append(share.Values, value.Mod(value, curve.Params().N))
+		}
+	}
+
+	return shares, nil
+}
+
+// ComplexSecretRetrieveFromShares 使用不少于门限值个碎片恢复任意长度的秘密
+func ComplexSecretRetrieveFromShares(shares []*Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, NotEnoughSharesError
+	}
+
+	first := shares[0]
+	curve, err := curveByName(first.Curve)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) < first.Threshold {
+		return nil, fmt.Errorf("%w: need %d, got %d", NotEnoughSharesError, first.Threshold, len(shares))
+	}
+
+	chunkSize := secretChunkSize(curve)
+	chunkNum := (first.SecretLength + chunkSize - 1) / chunkSize
+	seen := make(map[int]bool, len(shares))
+	for _, share := range shares {
+		if share.Threshold != first.Threshold || share.Curve != first.Curve || share.SecretLength != first.SecretLength ||
+			!bytes.Equal(share.SecretID, first.SecretID) || len(share.Values) != chunkNum {
+			return nil, InconsistentSharesError
+		}
+		if seen[share.Index] {
+			return nil, DuplicateShareIndexError
+		}
+		seen[share.Index] = true
+	}
+
+	secret := make([]byte, first.SecretLength)
+	for k := 0; k < chunkNum; k++ {
+		points := make(map[int]*big.Int, len(shares))
+		for _, share := range shares {
+			points[share.Index] = share.Values[k]
+		}
+
+		start := k * chunkSize
+		end := start + chunkSize
+		if end > len(secret) {
+			end = len(secret)
+		}
+		chunk := lagrangeInterpolate(points, curve)
+		if chunk.BitLen() > 8*(end-start) {
+			return nil, InconsistentSharesError
+		}
+		chunk.FillBytes(secret[start:end])
+	}
+
+	return secret, nil
+}
+
+// EncodeToString 将碎片编码为十六进制字符串
+func (s *Share) EncodeToString() (string, error) {
+	bs, err := s.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bs), nil
+}
+
+// Marshal 将碎片编码为字节，格式参见文件开头的说明，字段超出编码范围时返回错误
+func (s *Share) Marshal() ([]byte, error) {
+	valueLength := curveOrderLength(s.Curve)
+	if valueLength == 0 {
+		return nil, fmt.Errorf("%w: %s", UnsupportedCurveError, s.Curve)
+	}
+	if s.Index <= 0 || s.Index > math.MaxUint16 || s.Threshold <= 0 || s.Threshold > math.MaxUint16 ||
+		s.SecretLength < 0 || uint64(s.SecretLength) > math.MaxUint32 || len(s.Values) > math.MaxUint16 ||
+		len(s.SecretID) != SecretIDLength {
+		return nil, ShareOutOfRangeError
+	}
+	for _, value := range s.Values {
+		if value == nil || value.Sign() < 0 || value.BitLen() > 8*valueLength {
+			return nil, ShareOutOfRangeError
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(ShareEncodingVersion)
+	binary.Write(&buf, binary.BigEndian, uint16(s.Index))
+	binary.Write(&buf, binary.BigEndian, uint16(s.Threshold))
+	buf.WriteByte(byte(len(s.Curve)))
+	buf.WriteString(s.Curve)
+	buf.Write(s.SecretID)
+	binary.Write(&buf, binary.BigEndian, uint32(s.SecretLength))
+	binary.Write(&buf, binary.BigEndian, uint16(len(s.Values)))
+
+	for _, value := range s.Values {
+		buf.Write(value.FillBytes(make([]byte, valueLength)))
+	}
+
+	checksum := hash.HashUsingSha256(buf.Bytes())
+	buf.Write(checksum[:shareChecksumLength])
+
+	return buf.Bytes(), nil
+}
+
+// DecodeShareFromString 从十六进制字符串解码碎片，会检查版本、曲线和校验和
+func DecodeShareFromString(s string) (*Share, error) {
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, InvalidShareFormatError
+	}
+
+	return DecodeShareFromBytes(bs)
+}
+
+// DecodeShareFromBytes 从字节解码碎片
+func DecodeShareFromBytes(bs []byte) (*Share, error) {
+	if len(bs) < shareChecksumLength+1 {
+		return nil, InvalidShareFormatError
+	}
+	body, checksum := bs[:len(bs)-shareChecksumLength], bs[len(bs)-shareChecksumLength:]
+	if !bytes.Equal(hash.HashUsingSha256(body)[:shareChecksumLength], checksum) {
+		return nil, ShareChecksumError
+	}
+
+	r := bytes.NewReader(body)
+	version, _ := r.ReadByte()
+	if version != ShareEncodingVersion {
+		return nil, fmt.Errorf("%w: unknown version %d", InvalidShareFormatError, version)
+	}
+
+	var header struct {
+		Index     uint16
+		Threshold uint16
+		CurveLen  uint8
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, InvalidShareFormatError
+	}
+
+	curveName := make([]byte, header.CurveLen)
+	secretID := make([]byte, SecretIDLength)
+	var secretLength uint32
+	var valueNum uint16
+	if _, err := io.ReadFull(r, curveName); err != nil {
+		return nil, InvalidShareFormatError
+	}
+	if _, err := io.ReadFull(r, secretID); err != nil {
+		return nil, InvalidShareFormatError
+	}
+	if err := binary.Read(r, binary.BigEndian, &secretLength); err != nil {
+		return nil, InvalidShareFormatError
+	}
+	if err := binary.Read(r, binary.BigEndian, &valueNum); err != nil {
+		return nil, InvalidShareFormatError
+	}
+
+	curve, err := curveByName(string(curveName))
+	if err != nil {
+		return nil, err
+	}
+	valueLength := curveOrderLength(curve.Params().Name)
+	if r.Len() != int(valueNum)*valueLength {
+		return nil, InvalidShareFormatError
+	}
+
+	values := make([]*big.Int, valueNum)
+	for i := range values {
+		value := make([]byte, valueLength)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, InvalidShareFormatError
+		}
+		values[i] = new(big.Int).SetBytes(value)
+	}
+
+	return &Share{
+		Index:        int(header.Index),
+		Threshold:    int(header.Threshold),
+		Curve:        string(curveName),
+		SecretID:     secretID,
+		SecretLength: int(secretLength),
+		Values:       values,
+	}, nil
+}
+
+// secretChunkSize 每个分块的字节长度，比曲线阶的字节长度少1，保证分块小于曲线的阶
+func secretChunkSize(curve elliptic.Curve) int {
+	return (curve.Params().N.BitLen()+7)/8 - 1
+}
+
+// curveOrderLength 曲线阶的字节长度
+func curveOrderLength(name string) int {
+	curve, err := curveByName(name)
+	if err != nil {
+		return 0
+	}
+	return (curve.Params().N.BitLen() + 7) / 8
+}
+
+// curveByName 根据曲线名称获取曲线，支持ecc曲线注册表中的曲线以及P-224
+func curveByName(name string) (elliptic.Curve, error) {
+	if name == elliptic.P224().Params().Name {
+		return elliptic.P224(), nil
+	}
+	curve, err := ecc.CurveByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", UnsupportedCurveError, name)
+	}
+	return curve, nil
+}
+
+// checkSecretRange 检查秘密作为整数时是否小于曲线的阶，否则会在取模后被静默截断
+func checkSecretRange(secret []byte, curve elliptic.Curve) error {
+	if new(big.Int).SetBytes(secret).Cmp(curve.Params().N) >= 0 {
+		return SecretTooLongError
+	}
+	return nil
+}
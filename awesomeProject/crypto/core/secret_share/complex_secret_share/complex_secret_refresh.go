@@ -0,0 +1,159 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package complex_secret_share
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	polynomial "github.com/PaddlePaddle/PaddleDTX/crypto/common/math/big_polynomial"
+)
+
+// 主动秘密分享(Proactive Secret Sharing)与重新分享(Resharing)，整个过程中秘密不会被恢复
+//
+// 碎片刷新，门限值t和参与方不变：
+// Step 1：每个持有者i生成常数项为0的t-1次随机多项式D(i)(x)，将D(i)(j)秘密地发送给持有者j
+// Step 2：持有者j计算新碎片 s'(j) = s(j) + Σ D(i)(j)
+//			新碎片位于多项式F(x) + ΣD(i)(x)上，常数项不变，旧碎片与新碎片无法混合使用，泄露的旧碎片随之失效
+//
+// 重新分享，从(t, n)变为(t', n')：
+// Step 1：任意不少于t个旧持有者组成集合S，持有者i计算拉格朗日系数λ(i)，
+//			将λ(i)*s(i)作为常数项生成t'-1次随机多项式G(i)(x)，将G(i)(j)秘密地发送给新持有者j，j = 1...n'
+// Step 2：新持有者j计算新碎片 s'(j) = Σ G(i)(j)
+//			新碎片位于多项式ΣG(i)(x)上，其常数项Σλ(i)*s(i)即为原秘密
+
+var (
+	InvalidMinimumShareNumberError = errors.New("minimumShareNumber must be greater than one")
+	IndexNotInSetError             = errors.New("share index is not in the index set")
+	InvalidIndexSetError           = errors.New("share indices must be positive and distinct")
+)
+
+// GenerateRefreshSubShares 碎片刷新Step 1，持有者生成常数项为0的多项式，计算发送给每个持有者的子碎片
+// - indices 所有持有者的序号
+// - minimumShareNumber 门限值，刷新后保持不变
+func GenerateRefreshSubShares(indices []int, minimumShareNumber int, curve elliptic.Curve) (map[int]*big.Int, error) {
+	return generateSubShares(indices, minimumShareNumber, big.NewInt(0), curve)
+}
+
+// RefreshShare 碎片刷新Step 2，持有者将旧碎片与收到的所有子碎片相加，得到新碎片
+func RefreshShare(share *big.Int, subShares []*big.Int, curve elliptic.Curve) *big.Int {
+	newShare := new(big.Int).Set(share)
+	for _, subShare := range subShares {
+		newShare.Add(newShare, subShare)
+	}
+
+	return newShare.Mod(newShare, curve.Params().N)
+}
+
+// GenerateReshareSubShares 重新分享Step 1，旧持有者将λ(i)*s(i)分享给新持有者
+// - index 旧持有者的序号
+// - share 旧持有者的碎片
+// - oldIndices 参与重新分享的所有旧持有者的序号，数量不少于旧门限值
+// - newTotalShareNumber 新持有者的数量，新持有者的序号为1...newTotalShareNumber
+// - newMinimumShareNumber 新门限值
+func GenerateReshareSubShares(index int, share *big.Int, oldIndices []int, newTotalShareNumber, newMinimumShareNumber int, curve elliptic.Curve) (map[int]*big.Int, error) {
+	if newTotalShareNumber < 2 {
+		return nil, InvalidTotalShareNumberError
+	}
+	if newMinimumShareNumber > newTotalShareNumber {
+		return nil, InvalidShareNumberError
+	}
+
+	lambda, err := LagrangeCoefficient(index, oldIndices, curve)
+	if err != nil {
+		return nil, err
+	}
+	weighted := new(big.Int).Mul(lambda, share)
+	weighted.Mod(weighted, curve.Params().N)
+
+	newIndices := make([]int, newTotalShareNumber)
+	for i := range newIndices {
+		newIndices[i] = i + 1
+	}
+
+	return generateSubShares(newIndices, newMinimumShareNumber, weighted, curve)
+}
+
+// CombineReshareSubShares 重新分享Step 2，新持有者将收到的所有子碎片相加，得到新碎片
+func CombineReshareSubShares(subShares []*big.Int, curve elliptic.Curve) *big.Int {
+	return RefreshShare(big.NewInt(0), subShares, curve)
+}
+
+// LagrangeCoefficient 计算序号集合indices中index对应的x=0处的拉格朗日系数 λ = Π j/(j-index)，j≠index
+func LagrangeCoefficient(index int, indices []int, curve elliptic.Curve) (*big.Int, error) {
+	if err := checkIndices(indices); err != nil {
+		return nil, err
+	}
+
+	n := curve.Params().N
+	numerator := big.NewInt(1)
+	denominator := big.NewInt(1)
+	found := false
+	for _, j := range indices {
+		if j == index {
+			found = true
+			continue
+		}
+		numerator.Mul(numerator, big.NewInt(int64(j)))
+		denominator.Mul(denominator, big.NewInt(int64(j-index)))
+	}
+	if !found {
+		return nil, IndexNotInSetError
+	}
+
+	denominator.Mod(denominator, n)
+	lambda := new(big.Int).ModInverse(denominator, n)
+	lambda.Mul(lambda, numerator)
+
+	return lambda.Mod(lambda, n), nil
+}
+
+// generateSubShares 以constant为常数项生成minimumShareNumber-1次随机多项式，计算每个序号对应的子碎片
+func generateSubShares(indices []int, minimumShareNumber int, constant *big.Int, curve elliptic.Curve) (map[int]*big.Int, error) {
+	if minimumShareNumber < 2 {
+		return nil, InvalidMinimumShareNumberError
+	}
+	if minimumShareNumber > len(indices) {
+		return nil, InvalidShareNumberError
+	}
+	if err := checkIndices(indices); err != nil {
+		return nil, err
+	}
+
+	polynomialClient := polynomial.New(curve.Params().N)
+	poly, err := polynomialClient.RandomGenerate(minimumShareNumber-1, constant.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	subShares := make(map[int]*big.Int, len(indices))
+	for _, j := range indices {
+		subShare := polynomialClient.Evaluate(poly, big.NewInt(int64(j)))
+		subShares[j] = subShare.Mod(subShare, curve.Params().N)
+	}
+
+	return subShares, nil
+}
+
+func checkIndices(indices []int) error {
+	seen := make(map[int]bool, len(indices))
+	for _, j := range indices {
+		if j <= 0 || seen[j] {
+			return InvalidIndexSetError
+		}
+		seen[j] = true
+	}
+	return nil
+}
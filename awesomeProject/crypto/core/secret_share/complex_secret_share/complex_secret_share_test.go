@@ -0,0 +1,217 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package complex_secret_share
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/config"
+)
+
+func TestFeldmanVSS(t *testing.T) {
+	curve := elliptic.P256()
+	secret := []byte("custodian key share")
+
+	shares, points, err := ComplexSecretSplitWithVerifyPoints(5, 3, secret, curve)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+
+	for index, share := range shares {
+		require.True(t, VerifyShareWithPoints(index, share, points, curve))
+	}
+
+	// 篡改两个碎片
+	shares[2] = new(big.Int).Add(shares[2], big.NewInt(1))
+	shares[5] = big.NewInt(12345)
+	require.False(t, VerifyShareWithPoints(2, shares[2], points, curve))
+
+	retrieved, cheaters, err := ComplexSecretRetrieveWithVerifyPoints(shares, points, curve)
+	require.NoError(t, err)
+	require.Equal(t, secret, retrieved)
+	require.Equal(t, []int{2, 5}, cheaters)
+
+	// 合法碎片不足
+	shares[1] = big.NewInt(1)
+	_, cheaters, err = ComplexSecretRetrieveWithVerifyPoints(shares, points, curve)
+	require.True(t, errors.Is(err, NotEnoughValidSharesError))
+	require.Equal(t, []int{1, 2, 5}, cheaters)
+}
+
+func TestPedersenVSS(t *testing.T) {
+	secp256k1, err := ecc.CurveByName(config.CurveSecp256k1)
+	require.NoError(t, err)
+
+	for _, curve := range []elliptic.Curve{elliptic.P256(), secp256k1} {
+		secret := []byte("hiding commitments")
+
+		shares, blindings, commitments, err := ComplexSecretSplitWithPedersen(4, 2, secret, curve)
+		require.NoError(t, err)
+		require.Len(t, commitments, 2)
+
+		for index, share := range shares {
+			require.True(t, VerifyPedersenShare(index, share, blindings[index], commitments, curve))
+		}
+		require.False(t, VerifyPedersenShare(1, shares[1], blindings[2], commitments, curve))
+
+		shares[3] = new(big.Int).Sub(shares[3], big.NewInt(7))
+		delete(blindings, 4)
+
+		retrieved, cheaters, err := ComplexSecretRetrieveWithPedersen(shares, blindings, commitments, curve)
+		require.NoError(t, err)
+		require.Equal(t, secret, retrieved)
+		require.Equal(t, []int{3, 4}, cheaters)
+
+		h1, err := PedersenGenerator(curve)
+		require.NoError(t, err)
+		require.True(t, curve.IsOnCurve(h1.X, h1.Y))
+		h2, err := PedersenGenerator(curve)
+		require.NoError(t, err)
+		require.True(t, h1.Equals(h2))
+	}
+}
+
+func TestRefreshAndReshare(t *testing.T) {
+	curve := elliptic.P256()
+	secret := []byte("rotate custodians")
+
+	shares, err := ComplexSecretSplit(5, 3, secret, curve)
+	require.NoError(t, err)
+	indices := []int{1, 2, 3, 4, 5}
+
+	// 碎片刷新
+	received := make(map[int][]*big.Int)
+	for range indices {
+		subShares, err := GenerateRefreshSubShares(indices, 3, curve)
+		require.NoError(t, err)
+		for j, subShare := range subShares {
+			received[j] = append(received[j], subShare)
+		}
+	}
+	refreshed := make(map[int]*big.Int)
+	for _, j := range indices {
+		refreshed[j] = RefreshShare(shares[j], received[j], curve)
+		require.NotEqual(t, 0, refreshed[j].Cmp(new(big.Int).Mod(shares[j], curve.Params().N)))
+	}
+
+	retrieved, err := ComplexSecretRetrieve(map[int]*big.Int{1: refreshed[1], 3: refreshed[3], 5: refreshed[5]}, curve)
+	require.NoError(t, err)
+	require.Equal(t, secret, retrieved)
+
+	// 旧碎片与新碎片混合使用无法恢复秘密
+	mixed, err := ComplexSecretRetrieve(map[int]*big.Int{1: shares[1], 3: refreshed[3], 5: refreshed[5]}, curve)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, mixed)
+
+	// 从(3, 5)重新分享为(2, 3)
+	oldIndices := []int{2, 4, 5}
+	received = make(map[int][]*big.Int)
+	for _, i := range oldIndices {
+		subShares, err := GenerateReshareSubShares(i, refreshed[i], oldIndices, 3, 2, curve)
+		require.NoError(t, err)
+		for j, subShare := range subShares {
+			received[j] = append(received[j], subShare)
+		}
+	}
+	reshared := make(map[int]*big.Int)
+	for j := 1; j <= 3; j++ {
+		reshared[j] = CombineReshareSubShares(received[j], curve)
+	}
+
+	retrieved, err = ComplexSecretRetrieve(map[int]*big.Int{1: reshared[1], 3: reshared[3]}, curve)
+	require.NoError(t, err)
+	require.Equal(t, secret, retrieved)
+
+	_, err = GenerateReshareSubShares(1, refreshed[1], oldIndices, 3, 2, curve)
+	require.Equal(t, IndexNotInSetError, err)
+	_, err = GenerateRefreshSubShares(indices, 1, curve)
+	require.Equal(t, InvalidMinimumShareNumberError, err)
+}
+
+func TestLongSecretAndShareEncoding(t *testing.T) {
+	curve := elliptic.P256()
+	secret := make([]byte, 100)
+	for i := range secret {
+		secret[i] = byte(i)
+	}
+
+	_, err := ComplexSecretSplit(3, 2, secret, curve)
+	require.Equal(t, SecretTooLongError, err)
+
+	shares, err := ComplexSecretSplitToShares(4, 3, secret, curve)
+	require.NoError(t, err)
+	require.Len(t, shares[0].Values, 4)
+
+	// 编码与解码
+	var decoded []*Share
+	for _, share := range shares[1:] {
+		encoded, err := share.EncodeToString()
+		require.NoError(t, err)
+		d, err := DecodeShareFromString(encoded)
+		require.NoError(t, err)
+		require.Equal(t, share, d)
+		decoded = append(decoded, d)
+	}
+
+	retrieved, err := ComplexSecretRetrieveFromShares(decoded)
+	require.NoError(t, err)
+	require.Equal(t, secret, retrieved)
+
+	_, err = ComplexSecretRetrieveFromShares(decoded[:2])
+	require.True(t, errors.Is(err, NotEnoughSharesError))
+
+	// 不同秘密的碎片不能混用
+	others, err := ComplexSecretSplitToShares(4, 3, secret, curve)
+	require.NoError(t, err)
+	_, err = ComplexSecretRetrieveFromShares([]*Share{decoded[0], decoded[1], others[0]})
+	require.Equal(t, InconsistentSharesError, err)
+
+	// 篡改编码
+	encodedShare, err := shares[0].EncodeToString()
+	require.NoError(t, err)
+	encoded := []byte(encodedShare)
+	encoded[10] ^= 1
+	_, err = DecodeShareFromString(string(encoded))
+	require.Error(t, err)
+
+	// 超出编码范围的碎片
+	_, err = ComplexSecretSplitToShares(1<<16, 3, secret, curve)
+	require.True(t, errors.Is(err, ShareOutOfRangeError))
+	for _, modify := range []func(s *Share){
+		func(s *Share) { s.Index = 1 << 16 },
+		func(s *Share) { s.Threshold = -1 },
+		func(s *Share) { s.Values = make([]*big.Int, 1<<16) },
+		func(s *Share) { s.Values[0] = new(big.Int).Lsh(big.NewInt(1), 512) },
+		func(s *Share) { s.SecretID = s.SecretID[:4] },
+	} {
+		invalid := *shares[1]
+		invalid.Values = append([]*big.Int{}, shares[1].Values...)
+		modify(&invalid)
+		_, err = invalid.Marshal()
+		require.True(t, errors.Is(err, ShareOutOfRangeError))
+	}
+
+	// 保留前导零
+	short := []byte{0, 0, 1, 2}
+	shares, err = ComplexSecretSplitToShares(3, 2, short, elliptic.P384())
+	require.NoError(t, err)
+	retrieved, err = ComplexSecretRetrieveFromShares(shares[:2])
+	require.NoError(t, err)
+	require.Equal(t, short, retrieved)
+}
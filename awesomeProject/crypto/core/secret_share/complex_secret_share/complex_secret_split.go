@@ -70,7 +70,7 @@ func ComplexSecretSplitWithVerifyPoints(totalShareNumber, minimumShareNumber int
 	}
 
 	for _, coefficient := range poly {
-		x, y := elliptic.P256().ScalarBaseMult(coefficient.Bytes())
+		x, y := curve.ScalarBaseMult(coefficient.Bytes())
 		point, err := ecc.NewPoint(curve, x, y)
 		if err != nil {
 			return nil, nil, err
@@ -99,6 +99,10 @@ func ComplexSecretToPolynomial(totalShareNumber, minimumShareNumber int, secret
 		return nil, InvalidShareNumberError
 	}
 
+	if err := checkSecretRange(secret, curve); err != nil {
+		return nil, err
+	}
+
 	polynomialClient := polynomial.New(curve.Params().N)
 
 	poly, err := polynomialClient.RandomGenerate(minimumShareNumber-1, secret)
@@ -111,7 +115,7 @@ func ComplexSecretToPolynomial(totalShareNumber, minimumShareNumber int, secret
 
 // GetVerifyPointByPolynomial 为产生本地秘密的私钥碎片做准备，通过目标多项式生成验证点
 func GetVerifyPointByPolynomial(poly []*big.Int, curve elliptic.Curve) (*ecc.Point, error) {
-	x, y := elliptic.P256().ScalarBaseMult(poly[0].Bytes())
+	x, y := curve.ScalarBaseMult(poly[0].Bytes())
 	point, err := ecc.NewPoint(curve, x, y)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,242 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package complex_secret_share
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	polynomial "github.com/PaddlePaddle/PaddleDTX/crypto/common/math/big_polynomial"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/common/math/ecc"
+	"github.com/PaddlePaddle/PaddleDTX/crypto/core/hash"
+)
+
+// 可验证秘密分享(Verifiable Secret Sharing)
+//
+// Feldman VSS：分发者公开多项式F(x) = a(0) + a(1)x + ... + a(t-1)x^(t-1)每个系数的承诺C(j) = a(j)G
+//		持有碎片(i, s(i))的参与方验证 s(i)G = Σ i^j * C(j)
+//		C(0) = SG会泄露秘密S的公钥，适用于秘密本身就是私钥的场景
+// Pedersen VSS：分发者额外选择随机多项式B(x)，公开承诺C(j) = a(j)G + b(j)H
+//		参与方同时持有s(i) = F(i)和盲化因子r(i) = B(i)，验证 s(i)G + r(i)H = Σ i^j * C(j)
+//		承诺在信息论意义上隐藏秘密，H为无人知道其相对于G的离散对数的生成元
+//
+// 恢复秘密时，先用承诺验证每个碎片，排除作弊的碎片，只要剩余的合法碎片不少于t个即可恢复
+
+var (
+	EmptyCommitmentsError      = errors.New("commitments must not be empty")
+	NotEnoughValidSharesError  = errors.New("not enough valid shares to retrieve the secret")
+	BlindingShareNotFoundError = errors.New("blinding share not found")
+)
+
+// ComplexSecretSplitWithPedersen 生成带Pedersen承诺的秘密碎片
+// 返回秘密碎片、对应的盲化因子碎片以及多项式系数的承诺，碎片和盲化因子需一同秘密地发送给参与方
+func ComplexSecretSplitWithPedersen(totalShareNumber, minimumShareNumber int, secret []byte, curve elliptic.Curve) (shares, blindings map[int]*big.Int, commitments []*ecc.Point, err error) {
+	poly, err := ComplexSecretToPolynomial(totalShareNumber, minimumShareNumber, secret, curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	blinding, err := rand.Int(rand.Reader, curve.Params().N)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	blindingPoly, err := ComplexSecretToPolynomial(totalShareNumber, minimumShareNumber, blinding.Bytes(), curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	h, err := PedersenGenerator(curve)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for j := range poly {
+		commitment, err := pedersenCommit(poly[j], blindingPoly[j], h, curve)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		commitments = append(commitments, commitment)
+	}
+
+	polynomialClient := polynomial.New(curve.Params().N)
+
+	shares = make(map[int]*big.Int, totalShareNumber)
+	blindings = make(map[int]*big.Int, totalShareNumber)
+	for x := 1; x <= totalShareNumber; x++ {
+		shares[x] = polynomialClient.Evaluate(poly, big.NewInt(int64(x)))
+		blindings[x] = polynomialClient.Evaluate(blindingPoly, big.NewInt(int64(x)))
+	}
+
+	return shares, blindings, commitments, nil
+}
+
+// VerifyShareWithPoints Feldman VSS，使用ComplexSecretSplitWithVerifyPoints生成的验证点验证碎片
+// - index 碎片的序号，即多项式的x值
+// - share 碎片的值
+// - points 多项式系数的承诺，按照常数项在前的顺序排列
+func VerifyShareWithPoints(index int, share *big.Int, points []*ecc.Point, curve elliptic.Curve) bool {
+	expected, err := evaluateCommitments(index, points)
+	if err != nil {
+		return false
+	}
+
+	actual, err := scalarBaseMult(share, curve)
+	if err != nil {
+		return false
+	}
+
+	return actual.Equals(expected)
+}
+
+// VerifyPedersenShare Pedersen VSS，使用ComplexSecretSplitWithPedersen生成的承诺验证碎片和盲化因子
+func VerifyPedersenShare(index int, share, blinding *big.Int, commitments []*ecc.Point, curve elliptic.Curve) bool {
+	expected, err := evaluateCommitments(index, commitments)
+	if err != nil {
+		return false
+	}
+
+	h, err := PedersenGenerator(curve)
+	if err != nil {
+		return false
+	}
+	actual, err := pedersenCommit(share, blinding, h, curve)
+	if err != nil {
+		return false
+	}
+
+	return actual.Equals(expected)
+}
+
+// ComplexSecretRetrieveWithVerifyPoints 使用Feldman承诺验证每个碎片，排除作弊的碎片后恢复秘密
+// 返回恢复的秘密和作弊碎片的序号，合法碎片少于门限值时返回错误
+func ComplexSecretRetrieveWithVerifyPoints(shares map[int]*big.Int, points []*ecc.Point, curve elliptic.Curve) ([]byte, []int, error) {
+	if len(points) == 0 {
+		return nil, nil, EmptyCommitmentsError
+	}
+
+	return retrieveValidShares(shares, len(points), curve, func(index int, share *big.Int) bool {
+		return VerifyShareWithPoints(index, share, points, curve)
+	})
+}
+
+// ComplexSecretRetrieveWithPedersen 使用Pedersen承诺验证每个碎片，排除作弊的碎片后恢复秘密
+// 返回恢复的秘密和作弊碎片的序号，缺少盲化因子的碎片同样视为作弊
+func ComplexSecretRetrieveWithPedersen(shares, blindings map[int]*big.Int, commitments []*ecc.Point, curve elliptic.Curve) ([]byte, []int, error) {
+	if len(commitments) == 0 {
+		return nil, nil, EmptyCommitmentsError
+	}
+
+	return retrieveValidShares(shares, len(commitments), curve, func(index int, share *big.Int) bool {
+		blinding, ok := blindings[index]
+		if !ok {
+			return false
+		}
+		return VerifyPedersenShare(index, share, blinding, commitments, curve)
+	})
+}
+
+// PedersenGenerator 获取Pedersen承诺使用的第二个生成元H
+// H由曲线名称哈希得到(try-and-increment)，任何人都不知道H相对于G的离散对数
+func PedersenGenerator(curve elliptic.Curve) (*ecc.Point, error) {
+	params := curve.Params()
+
+	for counter := 0; counter < 256; counter++ {
+		seed := fmt.Sprintf("paddledtx/pedersen/%s/%d", params.Name, counter)
+		x := new(big.Int).SetBytes(hash.HashUsingSha256([]byte(seed)))
+		x.Mod(x, params.P)
+
+		// y² = x³ + ax + b，a由ecc.CurveA获得，兼容secp256k1等a≠-3的曲线
+		y, err := ecc.DecompressY(curve, x, false)
+		if err != nil {
+			continue
+		}
+		if point, err := ecc.NewPoint(curve, x, y); err == nil {
+			return point, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to derive pedersen generator for curve %s", params.Name)
+}
+
+// retrieveValidShares 使用verify验证每个碎片，用合法碎片恢复秘密
+func retrieveValidShares(shares map[int]*big.Int, threshold int, curve elliptic.Curve, verify func(int, *big.Int) bool) ([]byte, []int, error) {
+	validShares := make(map[int]*big.Int, len(shares))
+	var cheaters []int
+	for index, share := range shares {
+		if verify(index, share) {
+			validShares[index] = share
+		} else {
+			cheaters = append(cheaters, index)
+		}
+	}
+	sort.Ints(cheaters)
+
+	if len(validShares) < threshold {
+		return nil, cheaters, fmt.Errorf("%w: need %d, got %d", NotEnoughValidSharesError, threshold, len(validShares))
+	}
+
+	secret, err := ComplexSecretRetrieve(validShares, curve)
+	if err != nil {
+		return nil, cheaters, err
+	}
+
+	return secret, cheaters, nil
+}
+
+// evaluateCommitments 计算 Σ index^j * C(j)
+func evaluateCommitments(index int, commitments []*ecc.Point) (*ecc.Point, error) {
+	if len(commitments) == 0 {
+		return nil, EmptyCommitmentsError
+	}
+
+	n := commitments[0].Curve.Params().N
+	x := big.NewInt(int64(index))
+	power := big.NewInt(1)
+
+	result := commitments[0]
+	for j := 1; j < len(commitments); j++ {
+		power = new(big.Int).Mod(new(big.Int).Mul(power, x), n)
+		var err error
+		result, err = result.Add(commitments[j].ScalarMult(power))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// pedersenCommit 计算 aG + bH
+func pedersenCommit(a, b *big.Int, h *ecc.Point, curve elliptic.Curve) (*ecc.Point, error) {
+	aG, err := scalarBaseMult(a, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	bH := h.ScalarMult(new(big.Int).Mod(b, curve.Params().N))
+
+	return aG.Add(bH)
+}
+
+// scalarBaseMult 计算 kG，k先对曲线的阶取模
+func scalarBaseMult(k *big.Int, curve elliptic.Curve) (*ecc.Point, error) {
+	kModN := new(big.Int).Mod(k, curve.Params().N)
+	x, y := curve.ScalarBaseMult(kModN.Bytes())
+
+	return ecc.NewPoint(curve, x, y)
+}
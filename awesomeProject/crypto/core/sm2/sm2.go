@@ -0,0 +1,272 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sm2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	gmsm2 "github.com/tjfoc/gmsm/sm2"
+)
+
+/*
+	SM2 signature and encryption based on GM/T 0003-2012, keys use the same fixed-size layout as the ecdsa package.
+	国密SM2签名和加密，密钥与ecdsa包采用相同的定长格式：
+	  PublicKey:  X || Y
+	  PrivateKey: D
+	  Signature:  r || s
+	签名时先计算 ZA = SM3(ENTL || ID || a || b || xG || yG || xA || yA)，再对 SM3(ZA || M) 签名，
+	因此Sign和Verify的输入为原始消息而不是摘要，签名方和验签方必须使用相同的用户标识
+*/
+
+const (
+	PublicKeyLength  = 64
+	PrivateKeyLength = 32
+	SignatureLength  = 64
+
+	// 密文格式为 0x04 || C1(64字节) || C3(32字节) || C2，C2与明文等长
+	minCiphertextLength = 1 + 64 + 32
+)
+
+// DefaultUID 未指定用户标识时使用的默认标识 "1234567812345678"，见GM/T 0009-2012
+var DefaultUID = []byte("1234567812345678")
+
+type PublicKey [PublicKeyLength]byte
+
+type PrivateKey [PrivateKeyLength]byte
+
+type Signature [SignatureLength]byte
+
+func (pk PublicKey) String() string {
+	return hex.EncodeToString(pk[:])
+}
+
+func (sk PrivateKey) String() string {
+	return hex.EncodeToString(sk[:])
+}
+
+func (s Signature) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// GenerateKeyPair generate a SM2 key pair 生成SM2密钥对
+func GenerateKeyPair() (privkey PrivateKey, pubkey PublicKey, err error) {
+	privateKey, err := gmsm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return
+	}
+
+	privkey = MarshalPrivateKey(privateKey)
+	pubkey = MarshalPublicKey(&privateKey.PublicKey)
+	return
+}
+
+// ParsePrivateKey parse from local type to SM2 private key 从本地类型解析为SM2私钥
+// 私钥须在[1, n-2]范围内，否则签名时 (1+d) 不可逆
+func ParsePrivateKey(privkey PrivateKey) (*gmsm2.PrivateKey, error) {
+	curve := gmsm2.P256Sm2()
+	D := new(big.Int).SetBytes(privkey[:])
+	if D.Sign() == 0 || D.Cmp(new(big.Int).Sub(curve.Params().N, big.NewInt(1))) >= 0 {
+		return nil, errors.New("invalid sm2 private key")
+	}
+
+	x, y := curve.ScalarBaseMult(D.Bytes())
+	return &gmsm2.PrivateKey{
+		PublicKey: gmsm2.PublicKey{
+			Curve: curve,
+			X:     x,
+			Y:     y,
+		},
+		D: D,
+	}, nil
+}
+
+// ParsePublicKey parse from local type to SM2 public key 从本地类型解析为SM2公钥
+func ParsePublicKey(pubkey PublicKey) (*gmsm2.PublicKey, error) {
+	curve := gmsm2.P256Sm2()
+	x := new(big.Int).SetBytes(pubkey[:32])
+	y := new(big.Int).SetBytes(pubkey[32:])
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("public key not on curve")
+	}
+
+	return &gmsm2.PublicKey{
+		Curve: curve,
+		X:     x,
+		Y:     y,
+	}, nil
+}
+
+// MarshalPrivateKey marshal SM2 private key to local types 将SM2私钥封送到本地类型
+func MarshalPrivateKey(privkey *gmsm2.PrivateKey) PrivateKey {
+	var res PrivateKey
+	privkey.D.FillBytes(res[:])
+	return res
+}
+
+// MarshalPublicKey marshal SM2 public key to local types 将SM2公钥封送到本地类型
+func MarshalPublicKey(pubkey *gmsm2.PublicKey) PublicKey {
+	var res PublicKey
+	pubkey.X.FillBytes(res[:32])
+	pubkey.Y.FillBytes(res[32:])
+	return res
+}
+
+// PublicKeyFromPrivateKey 从私钥得到对应的公钥
+func PublicKeyFromPrivateKey(privkey PrivateKey) (PublicKey, error) {
+	privateKey, err := ParsePrivateKey(privkey)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return MarshalPublicKey(&privateKey.PublicKey), nil
+}
+
+// Digest 计算待签名的摘要 e = SM3(ZA || M)，uid为空时使用DefaultUID，
+// 用于在外部签名设备上签名时预先计算摘要
+func Digest(pubkey PublicKey, msg, uid []byte) ([]byte, error) {
+	publicKey, err := ParsePublicKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return publicKey.Sm3Digest(msg, userID(uid))
+}
+
+// Sign sign a message with user ID 使用用户标识对消息签名，uid为空时使用DefaultUID
+func Sign(privkey PrivateKey, msg, uid []byte) (Signature, error) {
+	privateKey, err := ParsePrivateKey(privkey)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	r, s, err := gmsm2.Sm2Sign(privateKey, msg, userID(uid), rand.Reader)
+	if err != nil {
+		return Signature{}, fmt.Errorf("failed to sign message %w", err)
+	}
+
+	var sig Signature
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return sig, nil
+}
+
+// Verify verify a signature with user ID 使用用户标识验证签名，uid须与签名时一致
+func Verify(pubkey PublicKey, msg, uid []byte, signature Signature) error {
+	publicKey, err := ParsePublicKey(pubkey)
+	if err != nil {
+		return err
+	}
+
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !gmsm2.Sm2Verify(publicKey, msg, userID(uid), r, s) {
+		return errors.New("failed to verify")
+	}
+
+	return nil
+}
+
+// Encrypt SM2公钥加密，密文格式为 C1 || C3 || C2
+func Encrypt(pubkey PublicKey, msg []byte) ([]byte, error) {
+	if len(msg) == 0 {
+		return nil, errors.New("empty message")
+	}
+	publicKey, err := ParsePublicKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	return gmsm2.Encrypt(publicKey, msg, rand.Reader, gmsm2.C1C3C2)
+}
+
+// Decrypt SM2私钥解密
+func Decrypt(privkey PrivateKey, cypherText []byte) ([]byte, error) {
+	privateKey, err := ParsePrivateKey(privkey)
+	if err != nil {
+		return nil, err
+	}
+	if len(cypherText) <= minCiphertextLength || cypherText[0] != 0x04 {
+		return nil, errors.New("invalid sm2 ciphertext")
+	}
+	// 检查C1是否在曲线上，避免无效曲线攻击
+	x := new(big.Int).SetBytes(cypherText[1:33])
+	y := new(big.Int).SetBytes(cypherText[33:65])
+	if !privateKey.Curve.IsOnCurve(x, y) {
+		return nil, errors.New("invalid sm2 ciphertext")
+	}
+
+	return gmsm2.Decrypt(privateKey, cypherText, gmsm2.C1C3C2)
+}
+
+// DecodePrivateKeyFromString decode SM2 private key from string 从字符串解码SM2私钥
+func DecodePrivateKeyFromString(s string) (PrivateKey, error) {
+	var privateKey PrivateKey
+
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return PrivateKey{}, fmt.Errorf("invalid private key format")
+	}
+	if len(bs) != PrivateKeyLength {
+		return PrivateKey{}, fmt.Errorf("invalid private key length")
+	}
+	copy(privateKey[:], bs)
+	if _, err := ParsePrivateKey(privateKey); err != nil {
+		return PrivateKey{}, err
+	}
+	return privateKey, nil
+}
+
+// DecodePublicKeyFromString decode SM2 public key from string 从字符串解码SM2公钥
+func DecodePublicKeyFromString(s string) (PublicKey, error) {
+	var publicKey PublicKey
+
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key format")
+	}
+	if len(bs) != PublicKeyLength {
+		return PublicKey{}, fmt.Errorf("invalid public key length")
+	}
+	copy(publicKey[:], bs)
+	if _, err := ParsePublicKey(publicKey); err != nil {
+		return PublicKey{}, fmt.Errorf("invalid sm2 public key")
+	}
+
+	return publicKey, nil
+}
+
+// DecodeSignatureFromString decode SM2 signature from string 从字符串解码SM2签名
+func DecodeSignatureFromString(s string) (Signature, error) {
+	var sig Signature
+
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return Signature{}, err
+	}
+	if len(bs) != SignatureLength {
+		return Signature{}, fmt.Errorf("invalid signature length")
+	}
+	copy(sig[:], bs)
+	return sig, nil
+}
+
+// userID 用户标识为空时使用默认标识
+func userID(uid []byte) []byte {
+	if len(uid) == 0 {
+		return DefaultUID
+	}
+	return uid
+}
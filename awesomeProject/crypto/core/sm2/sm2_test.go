@@ -0,0 +1,101 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sm2
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gmsm2 "github.com/tjfoc/gmsm/sm2"
+)
+
+func TestSM2Sign(t *testing.T) {
+	privkey, pubkey, err := GenerateKeyPair()
+	require.NoError(t, err)
+
+	pubFromPriv, err := PublicKeyFromPrivateKey(privkey)
+	require.NoError(t, err)
+	require.Equal(t, pubkey, pubFromPriv)
+
+	msg := []byte("sm2 message")
+	uid := []byte("alice@example.com")
+
+	sign, err := Sign(privkey, msg, uid)
+	require.NoError(t, err)
+	require.NoError(t, Verify(pubkey, msg, uid, sign))
+	require.Error(t, Verify(pubkey, msg, nil, sign))
+	require.Error(t, Verify(pubkey, []byte("other message"), uid, sign))
+
+	// 未指定用户标识时使用默认标识
+	sign, err = Sign(privkey, msg, nil)
+	require.NoError(t, err)
+	require.NoError(t, Verify(pubkey, msg, DefaultUID, sign))
+
+	privFromStr, err := DecodePrivateKeyFromString(privkey.String())
+	require.NoError(t, err)
+	require.Equal(t, privkey, privFromStr)
+	pubFromStr, err := DecodePublicKeyFromString(pubkey.String())
+	require.NoError(t, err)
+	require.Equal(t, pubkey, pubFromStr)
+	signFromStr, err := DecodeSignatureFromString(sign.String())
+	require.NoError(t, err)
+	require.Equal(t, sign, signFromStr)
+}
+
+func TestSM2Digest(t *testing.T) {
+	// 固定私钥，检查Digest计算的 SM3(ZA || M) 与签名时使用的摘要一致
+	var privkey PrivateKey
+	d, _ := hex.DecodeString("3945208f7b2144b13f36e38ac6d39f95889393692860b51a42fb81ef4df7c5b8")
+	copy(privkey[:], d)
+	pubkey, err := PublicKeyFromPrivateKey(privkey)
+	require.NoError(t, err)
+
+	msg := []byte("message digest")
+	digest, err := Digest(pubkey, msg, nil)
+	require.NoError(t, err)
+
+	sign, err := Sign(privkey, msg, nil)
+	require.NoError(t, err)
+
+	// 使用摘要直接验证：s*G + (r+s)*P 的x坐标加e等于r
+	publicKey, err := ParsePublicKey(pubkey)
+	require.NoError(t, err)
+	r, s := new(big.Int).SetBytes(sign[:32]), new(big.Int).SetBytes(sign[32:])
+	require.True(t, gmsm2.Verify(publicKey, digest, r, s))
+}
+
+func TestSM2Encrypt(t *testing.T) {
+	privkey, pubkey, err := GenerateKeyPair()
+	require.NoError(t, err)
+
+	msg := []byte("sm2 plaintext")
+	ct, err := Encrypt(pubkey, msg)
+	require.NoError(t, err)
+
+	pt, err := Decrypt(privkey, ct)
+	require.NoError(t, err)
+	require.Equal(t, msg, pt)
+
+	ct[len(ct)-1] ^= 1
+	_, err = Decrypt(privkey, ct)
+	require.Error(t, err)
+
+	_, err = Decrypt(privkey, ct[:50])
+	require.Error(t, err)
+
+	_, err = Encrypt(pubkey, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sm4
+
+import (
+	"crypto/cipher"
+
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+type SM4Key struct {
+	Key   []byte // 16 bytes
+	Nonce []byte // 12 bytes = GCM.NonceSize
+	AD    []byte // optional
+}
+
+// EncryptUsingSM4GCM encrypt using SM4_GCM  使用国密SM4_GCM加密
+func EncryptUsingSM4GCM(key SM4Key, plaintext []byte, dst []byte) ([]byte, error) {
+	// SM4分组和密钥长度均为128比特
+	block, err := sm4.NewCipher(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Seal(dst, key.Nonce, plaintext, key.AD), nil
+}
+
+// DecryptUsingSM4GCM decrypt SM4-GCM 解密 SM4-GCM
+func DecryptUsingSM4GCM(key SM4Key, ciphertext []byte, dst []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := c.Open(dst, key.Nonce, ciphertext, key.AD)
+	if err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sm4
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSM4(t *testing.T) {
+	key := sha256.Sum256([]byte("test key"))
+	nonce := sha256.Sum256([]byte("test nonce"))
+	plaintext := []byte("sm4 plaintext")
+	sm4Key := SM4Key{
+		Key:   key[:16],
+		Nonce: nonce[:12],
+		AD:    []byte("additional data"),
+	}
+
+	cipher, err := EncryptUsingSM4GCM(sm4Key, plaintext, nil)
+	require.NoError(t, err)
+
+	plain, err := DecryptUsingSM4GCM(sm4Key, cipher, nil)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, plain)
+
+	cipher[0] ^= 1
+	_, err = DecryptUsingSM4GCM(sm4Key, cipher, nil)
+	require.Error(t, err)
+
+	_, err = EncryptUsingSM4GCM(SM4Key{Key: key[:], Nonce: nonce[:12]}, plaintext, nil)
+	require.Error(t, err)
+}